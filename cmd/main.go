@@ -1,47 +1,363 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"time"
 
 	"github.com/csaf-poc/ghsa/internal"
 	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/cyclonedx"
+	"github.com/csaf-poc/ghsa/models/ghsa/global"
 	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+	"golang.org/x/time/rate"
 )
 
-// TODO(lebogg): Implement entrypoint: URL of GHSA as argument |
 func main() {
 	var (
-		ghsa  *repository.Advisory
-		csafa *csaf.Advisory
-		err   error
+		workers = flag.Int("workers", 4, "number of advisories processed in parallel")
+		rps     = flag.Float64("rate", 0, "maximum number of GitHub API requests per second shared by all workers (0 means unlimited)")
+		out     = flag.String("out", internal.DefaultStoreDir, "directory the CSAF advisories are stored in")
+		token   = flag.String("token", os.Getenv("GITHUB_TOKEN"), "GitHub token used to authenticate API requests (defaults to $GITHUB_TOKEN)")
+		list    = flag.String("list", "", "convert all repository advisories of `OWNER[/REPO]` instead of the given URLs")
+		osvDB   = flag.String("osv-db", "", "convert all reviewed advisories of a local checkout of the github/advisory-database repository in `DIR` instead of the given URLs, without GitHub API requests")
+		force   = flag.Bool("force", false, "store advisories that are not published yet even if their TLP label is WHITE")
+		lenient = flag.Bool("lenient", false, "store best-effort advisories if parts of a GHSA cannot be converted and report the problems as warnings")
+		config  = flag.String("config", "", "JSON configuration `file` of the conversion (see internal.Config)")
+		version = flag.String("csaf-version", "", "CSAF `version` of the documents: 2.0 or 2.1 (default 2.0, overrides the configuration file)")
+		profile = flag.String("profile", "", "document `category` of the CSAF profile the documents comply with: csaf_security_advisory or csaf_vex (overrides the configuration file)")
+		formats = flag.String("formats", "", "comma-separated additional `formats` stored next to the CSAF documents: osv, cyclonedx, openvex, html, markdown")
+		cdx     = flag.String("cyclonedx-version", internal.DefaultCycloneDXVersion, "CycloneDX `version` of the cyclonedx format: 1.5 or 1.6")
+		sbom    = flag.String("sbom", "", "CycloneDX SBOM `file` the vulnerabilities of all converted advisories are merged into")
+		sbomOut = flag.String("sbom-out", "", "`file` the merged SBOM is written to (default the SBOM file with suffix .vex.json)")
+		report  = flag.String("report", "", "`file` a JUnit XML report of the processed advisories is written to, e.g. for CI")
+		apiURL  = flag.String("api-url", internal.DefaultAPIURL, "base `URL` of the GitHub REST API, e.g. https://HOSTNAME/api/v3 for GitHub Enterprise Server")
+		from    = flag.String("from-csaf", "", "convert the CSAF `file` into request bodies of the GitHub API to create repository advisories instead")
+		submit  = flag.String("submit", "", "create the repository advisories converted with -from-csaf in `OWNER/REPO` instead of printing them")
+		service = flag.String("validator", "", "`URL` of a CSAF validation service, e.g. the csaf_validator_service of Secvisogram, which runs the mandatory tests of section 6.1 of the CSAF standard in addition to the built-in ones")
+		schemas = flag.String("schemas", "", "`directory` with the CSAF 2.1 JSON schema of OASIS and the schemas it references, e.g. the CVSS v4.0 schema of FIRST; CSAF 2.1 documents are not validated against the schema without it")
+		signKey = flag.String("signing-key", "", "ASCII-armored OpenPGP private key `file` the stored CSAF documents and OpenVEX exports are signed with (detached signature FILE.asc next to them)")
+		keyPass = flag.String("signing-passphrase", os.Getenv("SIGNING_KEY_PASSPHRASE"), "passphrase of the encrypted signing key (defaults to $SIGNING_KEY_PASSPHRASE)")
+		// Publisher flags override the publisher of the configuration file
+		publisher internal.Publisher
+		refs      []string
+		failed    bool
 	)
+	flag.StringVar(&publisher.Name, "publisher-name", "", "name of the publisher of the CSAF documents (default the GitHub user who published the GHSA)")
+	flag.StringVar(&publisher.Namespace, "publisher-namespace", "", "namespace `URL` of the publisher")
+	flag.StringVar(&publisher.Category, "publisher-category", "", "category of the publisher: coordinator, discoverer, other, translator, user or vendor")
+	flag.StringVar(&publisher.ContactDetails, "publisher-contact", "", "contact details of the publisher")
+	flag.StringVar(&publisher.IssuingAuthority, "publisher-issuing-authority", "", "issuing authority of the publisher")
 
 	// Check arguments
-	if len(os.Args) != 2 {
-		fmt.Printf("Usage: %s <GHSA URL>\n", os.Args[0])
+	flag.Usage = func() {
+		fmt.Printf("Usage: %s [flags] <GHSA URL>...\n", os.Args[0])
+		fmt.Printf("       %s [flags] -list <OWNER[/REPO]>\n", os.Args[0])
+		fmt.Printf("       %s [flags] -osv-db <DIR>\n", os.Args[0])
+		fmt.Printf("       %s [flags] -from-csaf <FILE> [-submit <OWNER/REPO>]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	downloader := internal.NewDownloader(internal.WithToken(*token), internal.WithBaseURLs(*apiURL, internal.DefaultWebURL))
+	if *from != "" {
+		if err := fromCSAF(ctx, *from, *submit, downloader); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if sources := countTrue(flag.NArg() > 0, *list != "", *osvDB != ""); sources != 1 {
+		flag.Usage()
 		os.Exit(1)
 	}
 
-	// Get GHSA
-	ghsaURL := os.Args[1]
-	ghsa, err = internal.DownloadGHSA(ghsaURL)
+	if *schemas != "" {
+		if err := internal.LoadSchemas(*schemas); err != nil {
+			fmt.Printf("Error loading schemas: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	cfg, err := loadConfig(*config, *version, *profile, &publisher)
 	if err != nil {
-		fmt.Printf("Error downloading GHSA: %v\n", err)
+		fmt.Printf("Error loading configuration: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Convert GHSA to CSAF
-	csafa, err = internal.ToCSAF(ghsa)
+	opts := internal.BulkOptions{
+		Workers: *workers,
+		Fetch:   downloader.Get,
+		// Global advisories are fetched with their EPSS score, which is dated with the time of the response
+		FetchGlobal: downloader.GetGlobal,
+		IsGlobal:    downloader.IsGlobal,
+	}
+	store := internal.NewStore(*out)
+	store.Force = *force
+	if *signKey != "" {
+		if store.SigningKey, err = internal.LoadSigningKey(*signKey, *keyPass); err != nil {
+			fmt.Printf("Error loading signing key: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	opts.Store = store.Save
+	opts.Export, err = getExporters(*formats, *cdx, store)
 	if err != nil {
-		fmt.Printf("Error converting GHSA to CSAF: %v\n", err)
+		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
+	opts.Convert = cfg.ConvertOptions()
+	if *lenient {
+		opts.Convert = append(opts.Convert, internal.WithLenient())
+	}
+	if *service != "" {
+		// Like the validator of gocsaf, the service runs the preset of the mandatory tests
+		remote, err := (&gocsaf.RemoteValidatorOptions{URL: *service}).Open()
+		if err != nil {
+			fmt.Printf("Error opening validator: %v\n", err)
+			os.Exit(1)
+		}
+		defer remote.Close()
+		opts.Validator = gocsaf.SynchronizedRemoteValidator(remote)
+	}
+	if *rps > 0 {
+		opts.Limiter = rate.NewLimiter(rate.Limit(*rps), 1)
+	}
 
-	// Store CSAF
-	err = internal.StoreCSAF(csafa)
-	if err != nil {
-		fmt.Printf("Error storing CSAF: %v\n", err)
+	refs = flag.Args()
+	if *list != "" {
+		// List advisories once and serve them from memory instead of fetching each of them again
+		owner, repo, _ := strings.Cut(*list, "/")
+		advisories, err := downloader.List(ctx, internal.ListQuery{Owner: owner, Repo: repo})
+		if err != nil {
+			fmt.Printf("Error listing GHSAs: %v\n", err)
+			os.Exit(1)
+		}
+		listed := make(map[string]*repository.Advisory, len(advisories))
+		for _, adv := range advisories {
+			refs = append(refs, adv.HTMLURL)
+			listed[adv.HTMLURL] = adv
+		}
+		opts.Fetch = func(_ context.Context, ref string) (*repository.Advisory, error) {
+			return listed[ref], nil
+		}
+	}
+
+	if *osvDB != "" {
+		// The OSV files are the references; errors of single files are reported per advisory
+		if refs, err = internal.AdvisoryDatabaseFiles(*osvDB); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts.FetchGlobal = func(_ context.Context, path string) (*global.Advisory, time.Time, error) {
+			adv, err := internal.LoadOSVAdvisory(path)
+			return adv, time.Time{}, err
+		}
+		opts.IsGlobal = nil
+	}
+
+	// Get, convert, validate and store GHSAs
+	results := internal.ConvertBulk(ctx, refs, opts)
+	for _, res := range results {
+		if res.Err != nil {
+			fmt.Printf("Error processing %s (%s): %v\n", res.Ref, res.Stage, res.Err)
+			failed = true
+			continue
+		}
+		if res.Warnings != nil {
+			fmt.Printf("Warning processing %s: %v\n", res.Ref, res.Warnings)
+		}
+		if res.Unvalidated != nil {
+			fmt.Printf("Warning processing %s: not validated: %v\n", res.Ref, res.Unvalidated)
+		}
+		fmt.Printf("Stored %s at %s\n", res.Ref, res.Path)
+		for _, path := range res.Exports {
+			fmt.Printf("Exported %s to %s\n", res.Ref, path)
+		}
+	}
+
+	if *report != "" {
+		if err = writeReport(*report, results); err != nil {
+			fmt.Printf("Error writing report: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *sbom != "" {
+		if *sbomOut == "" {
+			*sbomOut = strings.TrimSuffix(*sbom, ".json") + ".vex.json"
+		}
+		if err = mergeSBOM(*sbom, *sbomOut, results); err != nil {
+			fmt.Printf("Error merging SBOM: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Merged vulnerabilities into %s\n", *sbomOut)
+	}
+	if failed {
 		os.Exit(1)
 	}
 }
+
+// fromCSAF converts the CSAF advisory at path into request bodies to create repository advisories (see
+// internal.FromCSAF). If target (OWNER/REPO) is set, the advisories are created there, otherwise the request bodies
+// are printed.
+func fromCSAF(ctx context.Context, path string, target string, downloader *internal.Downloader) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	adv, err := csaf.Unmarshal(b)
+	if err != nil {
+		return fmt.Errorf("could not unmarshal CSAF advisory: %w", err)
+	}
+	reqs, err := internal.FromCSAF(adv)
+	if err != nil {
+		return err
+	}
+
+	if target == "" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reqs)
+	}
+	owner, repo, _ := strings.Cut(target, "/")
+	for _, req := range reqs {
+		created, err := downloader.Create(ctx, owner, repo, req)
+		if err != nil {
+			return fmt.Errorf("could not create advisory '%s': %w", req.Summary, err)
+		}
+		fmt.Printf("Created %s\n", created.HTMLURL)
+	}
+	return nil
+}
+
+// countTrue returns the number of conditions that are true.
+func countTrue(conditions ...bool) (n int) {
+	for _, c := range conditions {
+		if c {
+			n++
+		}
+	}
+	return n
+}
+
+// writeReport writes the results as JUnit XML report to path (see internal.WriteJUnitReport).
+func writeReport(path string, results []internal.BulkResult) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = internal.WriteJUnitReport(f, results); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// mergeSBOM merges the vulnerabilities of all successfully processed advisories into the CycloneDX SBOM at path and
+// writes the result to out (see internal.MergeCycloneDX).
+func mergeSBOM(path string, out string, results []internal.BulkResult) error {
+	var (
+		vulns []*cyclonedx.Vulnerability
+	)
+	for _, res := range results {
+		if res.Err != nil {
+			continue
+		}
+		vuln, err := internal.CycloneDXVulnerability(res.GHSA)
+		if err != nil {
+			return fmt.Errorf("could not convert %s to CycloneDX: %w", res.Ref, err)
+		}
+		vulns = append(vulns, vuln)
+	}
+
+	sbom, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	merged, err := internal.MergeCycloneDX(sbom, vulns)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, append(merged, '\n'), 0644)
+}
+
+// getExporters returns the exporters of the comma-separated formats, which write next to the CSAF documents of store.
+// CycloneDX BOMs are written in version cdxVersion.
+func getExporters(formats string, cdxVersion string, store *internal.Store) (exporters []internal.Exporter, err error) {
+	for _, format := range strings.Split(formats, ",") {
+		switch strings.ToLower(strings.TrimSpace(format)) {
+		case "":
+			continue
+		case "osv":
+			exporters = append(exporters, store.SaveOSV)
+		case "openvex":
+			exporters = append(exporters, store.SaveOpenVEX)
+		case "html":
+			exporters = append(exporters, store.SaveHTML)
+		case "markdown":
+			exporters = append(exporters, store.SaveMarkdown)
+		case "cyclonedx":
+			if cdxVersion != cyclonedx.SpecVersion15 && cdxVersion != cyclonedx.SpecVersion16 {
+				err = fmt.Errorf("unsupported CycloneDX version '%s'", cdxVersion)
+				return nil, err
+			}
+			exporters = append(exporters, func(ghsa *repository.Advisory, adv *csaf.Advisory, path string) (string, error) {
+				return store.SaveCycloneDX(ghsa, adv, path, cdxVersion)
+			})
+		default:
+			err = fmt.Errorf("unsupported format '%s'", format)
+			return nil, err
+		}
+	}
+	return exporters, nil
+}
+
+// loadConfig reads the configuration file at path (if any) and overrides its CSAF version, profile and publisher with
+// the flags that were set on the command line.
+func loadConfig(path string, version string, profile string, publisher *internal.Publisher) (cfg *internal.Config, err error) {
+	cfg = &internal.Config{}
+	if path != "" {
+		if cfg, err = internal.LoadConfig(path); err != nil {
+			return nil, err
+		}
+	}
+	if version != "" {
+		cfg.CSAFVersion = version
+	}
+	if profile != "" {
+		cfg.Profile = profile
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		if !strings.HasPrefix(f.Name, "publisher-") {
+			return
+		}
+		if cfg.Publisher == nil {
+			cfg.Publisher = &internal.Publisher{}
+		}
+		switch f.Name {
+		case "publisher-name":
+			cfg.Publisher.Name = publisher.Name
+		case "publisher-namespace":
+			cfg.Publisher.Namespace = publisher.Namespace
+		case "publisher-category":
+			cfg.Publisher.Category = publisher.Category
+		case "publisher-contact":
+			cfg.Publisher.ContactDetails = publisher.ContactDetails
+		case "publisher-issuing-authority":
+			cfg.Publisher.IssuingAuthority = publisher.IssuingAuthority
+		}
+	})
+	return cfg, cfg.Validate()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runMainEnv makes the test binary run main instead of the tests, so the CLI can be tested as a separate process.
+const runMainEnv = "GHSA_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs the CLI with args and returns its output.
+func runMain(t *testing.T, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), runMainEnv+"=1", "GITHUB_TOKEN=")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return string(out)
+}
+
+func TestMain_GlobalAdvisoryEPSS(t *testing.T) {
+	fetched := time.Date(2025, 5, 2, 9, 30, 0, 0, time.UTC)
+	example, err := os.ReadFile("../examples/global_GHSA/GHSA-cpj6-fhp6-mr6j.json")
+	require.NoError(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/advisories/GHSA-cpj6-fhp6-mr6j" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Date", fetched.Format(http.TimeFormat))
+		_, _ = w.Write(example)
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	out := runMain(t, "-api-url", srv.URL, "-csaf-version", "2.1", "-out", dir,
+		"https://github.com/advisories/GHSA-cpj6-fhp6-mr6j")
+	assert.Contains(t, out, "Stored https://github.com/advisories/GHSA-cpj6-fhp6-mr6j")
+
+	var stored []string
+	require.NoError(t, filepath.WalkDir(dir, func(path string, _ os.DirEntry, err error) error {
+		if err == nil && strings.HasSuffix(path, ".json") {
+			stored = append(stored, path)
+		}
+		return err
+	}))
+	require.Len(t, stored, 1)
+	b, err := os.ReadFile(stored[0])
+	require.NoError(t, err)
+
+	var doc struct {
+		Vulnerabilities []struct {
+			Metrics []struct {
+				Content struct {
+					EPSS *struct {
+						Probability string    `json:"probability"`
+						Percentile  string    `json:"percentile"`
+						Timestamp   time.Time `json:"timestamp"`
+					} `json:"epss"`
+				} `json:"content"`
+			} `json:"metrics"`
+		} `json:"vulnerabilities"`
+	}
+	require.NoError(t, json.Unmarshal(b, &doc))
+	require.Len(t, doc.Vulnerabilities, 1)
+	var found bool
+	for _, m := range doc.Vulnerabilities[0].Metrics {
+		if epss := m.Content.EPSS; epss != nil {
+			found = true
+			assert.Equal(t, "0.00022", epss.Probability)
+			assert.Equal(t, "0.04494", epss.Percentile)
+			assert.True(t, fetched.Equal(epss.Timestamp), epss.Timestamp)
+		}
+	}
+	assert.True(t, found, "no epss metric in %s", stored[0])
+}
@@ -8,16 +8,22 @@ require (
 	github.com/Intevation/jsonpath v0.2.1 // indirect
 	github.com/gocsaf/csaf/v3 v3.2.0
 	github.com/shopspring/decimal v1.4.0 // indirect
-	golang.org/x/time v0.12.0 // indirect
+	golang.org/x/time v0.12.0
 )
 
-require github.com/stretchr/testify v1.10.0
+require (
+	github.com/ProtonMail/go-crypto v1.1.2
+	github.com/pandatix/go-cvss v0.6.2
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
+	github.com/stretchr/testify v1.10.0
+)
 
 require (
+	github.com/cloudflare/circl v1.5.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1 // indirect
 	go.etcd.io/bbolt v1.4.1 // indirect
+	golang.org/x/crypto v0.29.0 // indirect
 	golang.org/x/sys v0.33.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
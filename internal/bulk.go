@@ -0,0 +1,253 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/global"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+	"golang.org/x/time/rate"
+)
+
+// Stage names a step of a bulk run.
+type Stage string
+
+const (
+	StageFetch    Stage = "fetch"
+	StageConvert  Stage = "convert"
+	StageValidate Stage = "validate"
+	StageStore    Stage = "store"
+	StageExport   Stage = "export"
+)
+
+// Exporter writes another format of an advisory, e.g. OSV (see Store.SaveOSV), and returns the path of the written
+// file. It gets the fetched GHSA, the converted advisory and the path the advisory was stored at, which is empty if
+// storing is skipped. An error returned together with a path is reported as warning, e.g. for content the format
+// cannot express.
+type Exporter func(ghsa *repository.Advisory, adv *csaf.Advisory, csafPath string) (path string, err error)
+
+// BulkOptions configures ConvertBulk.
+type BulkOptions struct {
+	// Workers is the number of advisories processed in parallel. Values below 1 result in a single worker.
+	Workers int
+	// Limiter is shared by all workers and bounds the rate of fetches, e.g. to stay within the GitHub API rate limit.
+	// Nil means no limit.
+	Limiter *rate.Limiter
+	// Fetch downloads the advisory of a reference. Defaults to Downloader.Get of a Downloader with default options.
+	Fetch func(ctx context.Context, ref string) (*repository.Advisory, error)
+	// FetchGlobal loads the global advisory of a reference, e.g. with Downloader.GetGlobal or the OSV files of an
+	// advisory database checkout with LoadOSVAdvisory. It returns the time the advisory was retrieved, which dates its
+	// EPSS score (see WithEPSSTimestamp), or the zero time if it is unknown. If set, it replaces Fetch for the
+	// references IsGlobal reports, and the advisories are converted like GlobalToCSAF.
+	FetchGlobal func(ctx context.Context, ref string) (adv *global.Advisory, retrievedAt time.Time, err error)
+	// IsGlobal reports whether a reference is fetched with FetchGlobal. Nil means all references.
+	IsGlobal func(ref string) bool
+	// Store saves a converted advisory and returns its path. Nil skips storing.
+	Store func(adv *csaf.Advisory) (path string, err error)
+	// Convert configures the conversion of every advisory. With WithLenient, best-effort advisories are validated and
+	// stored and the conversion errors are reported as warnings.
+	Convert []ConvertOption
+	// Export lists the exporters run after an advisory was stored.
+	Export []Exporter
+	// Validator additionally runs the tests of a remote validation service, e.g. the mandatory tests of section 6.1 of
+	// the CSAF standard that RunMandatoryTests does not cover. It is shared by all workers, see
+	// gocsaf.SynchronizedRemoteValidator. Nil runs only RunMandatoryTests.
+	Validator gocsaf.RemoteValidator
+}
+
+// BulkResult is the outcome of processing a single advisory in a bulk run.
+type BulkResult struct {
+	// Ref is the reference (e.g. URL) of the advisory as passed to ConvertBulk.
+	Ref string
+	// GHSA is the fetched advisory. It is nil if fetching failed.
+	GHSA *repository.Advisory
+	// Advisory is the converted advisory. It is nil if fetching or converting failed.
+	Advisory *csaf.Advisory
+	// Path is the location the advisory was stored at.
+	Path string
+	// Exports lists the paths written by the exporters.
+	Exports []string
+	// Stage is the stage that failed. It is empty if the advisory was processed successfully.
+	Stage Stage
+	// Err is the error of the failed stage.
+	Err error
+	// Warnings lists the errors of a lenient conversion that still produced an advisory.
+	Warnings error
+	// Unvalidated is ErrNoCSAF21Schema if the advisory was not validated against the CSAF 2.1 JSON schema because the
+	// schema is not available (see LoadSchemas).
+	Unvalidated error
+	// MandatoryTests lists the results of the mandatory tests (see RunMandatoryTests and BulkOptions.Validator). It is
+	// nil if the advisory failed before the tests were run.
+	MandatoryTests []MandatoryTestResult
+}
+
+// ConvertBulk fetches, converts, validates, stores and exports the advisories of all refs using a pool of workers.
+// Errors are collected per advisory and do not stop the run. The results are returned in the order of refs, so the
+// output does not depend on the number of workers. Advisories not processed before ctx is done fail in the fetch stage.
+func ConvertBulk(ctx context.Context, refs []string, opts BulkOptions) (results []BulkResult) {
+	var (
+		wg   sync.WaitGroup
+		jobs = make(chan int)
+	)
+
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	if opts.Fetch == nil {
+		opts.Fetch = NewDownloader().Get
+	}
+
+	// Every worker writes only to the result slots of its jobs, so no further synchronization is needed
+	results = make([]BulkResult, len(refs))
+	for range opts.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = processAdvisory(ctx, refs[i], &opts)
+			}
+		}()
+	}
+
+	for i := range refs {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			results[i] = BulkResult{Ref: refs[i], Stage: StageFetch, Err: ctx.Err()}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// processAdvisory runs all stages for a single advisory and stops at the first failing stage.
+func processAdvisory(ctx context.Context, ref string, opts *BulkOptions) (res BulkResult) {
+	var (
+		ghsa    *repository.Advisory
+		convert []ConvertOption
+		err     error
+	)
+	res.Ref = ref
+
+	// Fetch
+	if opts.Limiter != nil {
+		err = opts.Limiter.Wait(ctx)
+	} else {
+		err = ctx.Err()
+	}
+	if err == nil {
+		ghsa, convert, err = fetchAdvisory(ctx, ref, opts)
+	}
+	if err != nil {
+		res.Stage, res.Err = StageFetch, err
+		return
+	}
+	res.GHSA = ghsa
+
+	// Convert
+	res.Advisory, err = convertRecovered(ghsa, convert...)
+	if err != nil && res.Advisory != nil {
+		// Lenient conversion: continue with the best-effort advisory
+		res.Warnings, err = err, nil
+	}
+	if err != nil {
+		res.Stage, res.Err = StageConvert, err
+		return
+	}
+
+	// Validate
+	err = res.Advisory.Validate()
+	if err == nil {
+		err = ValidateCSAF(res.Advisory)
+	}
+	if errors.Is(err, ErrNoCSAF21Schema) {
+		res.Unvalidated, err = err, nil
+	}
+	if err == nil {
+		res.MandatoryTests, err = runMandatoryTests(res.Advisory, opts.Validator)
+	}
+	if err != nil {
+		res.Stage, res.Err = StageValidate, err
+		return
+	}
+
+	// Store
+	if opts.Store != nil {
+		res.Path, err = opts.Store(res.Advisory)
+		if err != nil {
+			res.Stage, res.Err = StageStore, err
+			return
+		}
+	}
+
+	// Export
+	for _, export := range opts.Export {
+		path, err := export(ghsa, res.Advisory, res.Path)
+		if err != nil && path != "" {
+			res.Warnings, err = errors.Join(res.Warnings, err), nil
+		}
+		if err != nil {
+			res.Stage, res.Err = StageExport, err
+			return
+		}
+		res.Exports = append(res.Exports, path)
+	}
+	return
+}
+
+// fetchAdvisory fetches the advisory of ref and returns it with the options of its conversion. Global advisories (see
+// BulkOptions.FetchGlobal) are mapped into the repository model and converted like GlobalToCSAF.
+func fetchAdvisory(ctx context.Context, ref string, opts *BulkOptions) (
+	ghsa *repository.Advisory, convert []ConvertOption, err error,
+) {
+	if opts.FetchGlobal == nil || (opts.IsGlobal != nil && !opts.IsGlobal(ref)) {
+		ghsa, err = opts.Fetch(ctx, ref)
+		return ghsa, opts.Convert, err
+	}
+	g, retrievedAt, err := opts.FetchGlobal(ctx, ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	convert = append(slices.Clip(opts.Convert), withGlobal(g))
+	if !retrievedAt.IsZero() {
+		convert = append(convert, WithEPSSTimestamp(retrievedAt))
+	}
+	return globalToRepository(g), convert, nil
+}
+
+// convertRecovered converts the advisory and turns a panic during conversion into an error, so a single faulty
+// advisory cannot take down the whole bulk run.
+func convertRecovered(ghsa *repository.Advisory, opts ...ConvertOption) (adv *csaf.Advisory, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			adv = nil
+			err = fmt.Errorf("conversion panicked: %v", r)
+		}
+	}()
+	return ToCSAF(ghsa, opts...)
+}
+
+// runMandatoryTests runs the mandatory tests and the tests of the validator, if any. If the validator fails, its error is
+// the violation of a test named reportCaseRemote. Returns a *MandatoryTestError listing the failed tests.
+func runMandatoryTests(adv *csaf.Advisory, validator gocsaf.RemoteValidator) (results []MandatoryTestResult, err error) {
+	results = RunMandatoryTests(adv)
+	if validator != nil {
+		remote, err := RunRemoteTests(validator, adv)
+		if err != nil {
+			remote = []MandatoryTestResult{{Name: reportCaseRemote, Violations: []string{err.Error()}}}
+		}
+		results = append(results, remote...)
+	}
+	if failed := failedTests(results); len(failed) > 0 {
+		err = &MandatoryTestError{Failed: failed}
+	}
+	return results, err
+}
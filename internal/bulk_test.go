@@ -0,0 +1,296 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/global"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestConvertBulk(t *testing.T) {
+	var (
+		refs []string
+	)
+	for i := range 20 {
+		refs = append(refs, strconv.Itoa(i))
+	}
+
+	// Every odd reference cannot be fetched
+	fetch := func(_ context.Context, ref string) (*repository.Advisory, error) {
+		i, _ := strconv.Atoi(ref)
+		if i%2 == 1 {
+			return nil, fmt.Errorf("advisory %s not found", ref)
+		}
+		return &repository.Advisory{GhsaID: "GHSA-" + ref}, nil
+	}
+
+	for _, workers := range []int{0, 1, 4, 32} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			var (
+				fetches atomic.Int32
+			)
+			results := ConvertBulk(context.Background(), refs, BulkOptions{
+				Workers: workers,
+				Limiter: rate.NewLimiter(rate.Inf, 1),
+				Fetch: func(ctx context.Context, ref string) (*repository.Advisory, error) {
+					fetches.Add(1)
+					return fetch(ctx, ref)
+				},
+			})
+
+			assert.Equal(t, int32(len(refs)), fetches.Load())
+			if !assert.Len(t, results, len(refs)) {
+				return
+			}
+			for i, res := range results {
+				assert.Equal(t, refs[i], res.Ref)
+				if i%2 == 1 {
+					assert.Equal(t, StageFetch, res.Stage)
+					assert.ErrorContains(t, res.Err, "not found")
+				} else {
+					assert.NotEqual(t, StageFetch, res.Stage)
+				}
+			}
+		})
+	}
+}
+
+// exampleFetch returns a fetch function serving a copy of the example advisory per reference, with the reference as
+// suffix of its GHSA ID.
+func exampleFetch(t *testing.T) func(context.Context, string) (*repository.Advisory, error) {
+	var (
+		adv repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &adv)
+	return func(_ context.Context, ref string) (*repository.Advisory, error) {
+		c := adv
+		c.GhsaID = "GHSA-mh63-6h87-" + ref
+		return &c, nil
+	}
+}
+
+func TestConvertBulk_Success(t *testing.T) {
+	var (
+		refs = []string{"0000", "0001", "0002"}
+		dir  = t.TempDir()
+	)
+	results := ConvertBulk(context.Background(), refs, BulkOptions{
+		Workers: 2,
+		Fetch:   exampleFetch(t),
+		Store:   NewStore(dir).Save,
+	})
+	if !assert.Len(t, results, len(refs)) {
+		return
+	}
+	for i, res := range results {
+		assert.Equal(t, refs[i], res.Ref)
+		assert.Empty(t, res.Stage)
+		assert.NoError(t, res.Err)
+		assert.NoError(t, res.Warnings)
+		if !assert.NotNil(t, res.Advisory) {
+			continue
+		}
+		assert.Equal(t, "GHSA-mh63-6h87-"+refs[i], string(*res.Advisory.Document.Tracking.ID))
+		assert.NoError(t, ValidateCSAF(res.Advisory))
+		assert.Len(t, res.MandatoryTests, len(mandatoryTests))
+		assert.Empty(t, failedTests(res.MandatoryTests))
+
+		// The stored file is the validated advisory
+		assert.True(t, strings.HasPrefix(res.Path, dir), res.Path)
+		stored, err := gocsaf.LoadAdvisory(res.Path)
+		if assert.NoError(t, err) {
+			assert.Equal(t, *res.Advisory.Document.Tracking.ID, *stored.Document.Tracking.ID)
+		}
+	}
+}
+
+func TestConvertBulk_RemoteValidator(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/validate" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"isValid": false, "tests": [
+			{"name": "csaf_2_0", "isValid": true},
+			{"name": "mandatoryTest_6_1_8", "isValid": false, "errors": [
+				{"instancePath": "/vulnerabilities/0/scores/0/cvss_v3", "message": "invalid CVSS"}
+			]}
+		]}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name string
+		url  string
+		want MandatoryTestResult
+	}{
+		{
+			name: "Failed test",
+			url:  srv.URL,
+			want: MandatoryTestResult{Name: "mandatoryTest_6_1_8",
+				Violations: []string{"/vulnerabilities/0/scores/0/cvss_v3: invalid CVSS"}},
+		},
+		{
+			name: "Unreachable",
+			url:  srv.URL + "/missing",
+			want: MandatoryTestResult{Name: reportCaseRemote,
+				Violations: []string{"remote validation failed: POST failed: 404 Not Found (404)"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator, err := (&gocsaf.RemoteValidatorOptions{URL: tt.url}).Open()
+			if !assert.NoError(t, err) {
+				return
+			}
+			t.Cleanup(func() { _ = validator.Close() })
+
+			results := ConvertBulk(context.Background(), []string{"0000"}, BulkOptions{
+				Fetch:     exampleFetch(t),
+				Validator: validator,
+			})
+			res := results[0]
+			assert.Equal(t, StageValidate, res.Stage)
+			var mandatoryErr *MandatoryTestError
+			if assert.ErrorAs(t, res.Err, &mandatoryErr) {
+				assert.Equal(t, []MandatoryTestResult{tt.want}, mandatoryErr.Failed)
+			}
+			// The local tests passed and are reported along with the tests of the service
+			assert.Contains(t, res.MandatoryTests, tt.want)
+			assert.Equal(t, mandatoryTests[0].name, res.MandatoryTests[0].Name)
+			assert.Empty(t, res.MandatoryTests[0].Violations)
+		})
+	}
+}
+
+func TestConvertBulk_Deterministic(t *testing.T) {
+	var (
+		refs []string
+	)
+	for i := range 16 {
+		refs = append(refs, fmt.Sprintf("%04d", i))
+	}
+	run := func(workers int) (out []string) {
+		dir := t.TempDir()
+		for _, res := range ConvertBulk(context.Background(), refs, BulkOptions{
+			Workers: workers,
+			Fetch:   exampleFetch(t),
+			Store:   NewStore(dir).Save,
+		}) {
+			assert.NoError(t, res.Err)
+			b, err := csaf.Marshal(res.Advisory)
+			assert.NoError(t, err)
+			out = append(out, res.Ref+" "+strings.TrimPrefix(res.Path, dir)+" "+string(b))
+		}
+		return out
+	}
+
+	assert.Equal(t, run(1), run(8))
+}
+
+func TestConvertBulk_Export(t *testing.T) {
+	var (
+		adv repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &adv)
+	fetch := func(context.Context, string) (*repository.Advisory, error) {
+		return &adv, nil
+	}
+	store := NewStore(t.TempDir())
+
+	results := ConvertBulk(context.Background(), []string{"a"}, BulkOptions{
+		Fetch:  fetch,
+		Store:  store.Save,
+		Export: []Exporter{store.SaveOSV},
+	})
+	if assert.Len(t, results, 1) && assert.NoError(t, results[0].Err) {
+		assert.Equal(t, []string{strings.TrimSuffix(results[0].Path, ".json") + ".osv.json"}, results[0].Exports)
+	}
+
+	failing := func(*repository.Advisory, *csaf.Advisory, string) (string, error) {
+		return "", errors.New("export failed")
+	}
+	results = ConvertBulk(context.Background(), []string{"a"}, BulkOptions{
+		Fetch:  fetch,
+		Export: []Exporter{failing},
+	})
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, StageExport, results[0].Stage)
+		assert.ErrorContains(t, results[0].Err, "export failed")
+	}
+
+	// Errors of exporters that still wrote a file are warnings
+	results = ConvertBulk(context.Background(), []string{"a"}, BulkOptions{
+		Fetch:  fetch,
+		Store:  store.Save,
+		Export: []Exporter{store.SaveOpenVEX},
+	})
+	if assert.Len(t, results, 1) && assert.NoError(t, results[0].Err) {
+		assert.Len(t, results[0].Exports, 1)
+		assert.ErrorContains(t, results[0].Warnings, "OpenVEX cannot express the versions")
+	}
+}
+
+func TestConvertBulk_AdvisoryDatabase(t *testing.T) {
+	refs, err := AdvisoryDatabaseFiles("../examples/osv_GHSA")
+	if !assert.NoError(t, err) {
+		return
+	}
+	refs = append(refs, "missing.json")
+
+	results := ConvertBulk(context.Background(), refs, BulkOptions{
+		FetchGlobal: func(_ context.Context, path string) (*global.Advisory, time.Time, error) {
+			adv, err := LoadOSVAdvisory(path)
+			return adv, time.Time{}, err
+		},
+	})
+	if !assert.Len(t, results, 2) {
+		return
+	}
+	if assert.NoError(t, results[0].Err) {
+		assert.Equal(t, "GHSA-cpj6-fhp6-mr6j", results[0].GHSA.GhsaID)
+		// Converted like GlobalToCSAF
+		assert.Equal(t, "https://github.com/advisories/GHSA-cpj6-fhp6-mr6j", *results[0].Advisory.Document.References[0].URL)
+	}
+	assert.Equal(t, StageFetch, results[1].Stage)
+	assert.Error(t, results[1].Err)
+}
+
+func TestConvertBulk_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := ConvertBulk(ctx, []string{"a", "b", "c"}, BulkOptions{
+		Workers: 2,
+		Fetch: func(context.Context, string) (*repository.Advisory, error) {
+			t.Error("Fetch must not be called for a canceled context")
+			return nil, nil
+		},
+	})
+	for _, res := range results {
+		assert.Equal(t, StageFetch, res.Stage)
+		assert.True(t, errors.Is(res.Err, context.Canceled))
+	}
+}
+
+func TestConvertRecovered(t *testing.T) {
+	// A nil advisory cannot be converted and must not panic
+	adv, err := convertRecovered(nil)
+	assert.Nil(t, adv)
+	assert.Error(t, err)
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Config is the configuration of the conversion, read from a JSON file. Example:
+//
+//	{
+//	  "csaf_version": "2.0",
+//	  "profile": "csaf_vex",
+//	  "publisher": {
+//	    "name": "Example PSIRT",
+//	    "namespace": "https://psirt.example.com",
+//	    "category": "vendor",
+//	    "contact_details": "psirt@example.com",
+//	    "issuing_authority": "Example PSIRT republishes GitHub Security Advisories affecting its products."
+//	  },
+//	  "distribution": {
+//	    "tlp": "WHITE",
+//	    "text": "Republished from the GitHub Advisory Database.",
+//	    "sources": {
+//	      "example-org/internal-tool": {"tlp": "AMBER", "text": "For customers of internal-tool only."}
+//	    },
+//	    "states": {
+//	      "draft": {"tlp": "RED"}
+//	    }
+//	  }
+//	}
+type Config struct {
+	// CSAFVersion is the CSAF version of the documents: 2.0 (default) or 2.1. Optional.
+	CSAFVersion string `json:"csaf_version,omitempty"`
+	// Profile is the document category of the CSAF profile the documents comply with: csaf_security_advisory or
+	// csaf_vex. Optional.
+	Profile string `json:"profile,omitempty"`
+	// Publisher replaces the GitHub user who published the GHSA as publisher of the CSAF documents. Optional.
+	Publisher *Publisher `json:"publisher,omitempty"`
+	// Distribution configures TLP label, TLP URL and distribution text globally, per source and per state. Optional.
+	Distribution *DistributionConfig `json:"distribution,omitempty"`
+}
+
+// LoadConfig reads the configuration from the JSON file at path. Unknown properties are rejected to catch typos.
+func LoadConfig(path string) (cfg *Config, err error) {
+	var (
+		b []byte
+	)
+	b, err = os.ReadFile(path)
+	if err != nil {
+		err = fmt.Errorf("could not read config: %v", err)
+		return nil, err
+	}
+
+	cfg = &Config{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(cfg); err != nil {
+		err = fmt.Errorf("could not parse config '%s': %v", path, err)
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks the configuration.
+func (cfg *Config) Validate() error {
+	var (
+		errs []error
+	)
+	if cfg.CSAFVersion != "" {
+		errs = append(errs, checkCSAFVersion(cfg.CSAFVersion))
+	}
+	errs = append(errs, checkProfile(cfg.Profile))
+	if cfg.Publisher != nil {
+		errs = append(errs, cfg.Publisher.Validate())
+	}
+	if cfg.Distribution != nil {
+		errs = append(errs, cfg.Distribution.Validate())
+	}
+	return errors.Join(errs...)
+}
+
+// ConvertOptions returns the options configuring the conversion accordingly.
+func (cfg *Config) ConvertOptions() (opts []ConvertOption) {
+	if cfg.CSAFVersion != "" {
+		opts = append(opts, WithCSAFVersion(cfg.CSAFVersion))
+	}
+	if cfg.Profile != "" {
+		opts = append(opts, WithProfile(cfg.Profile))
+	}
+	if cfg.Publisher != nil {
+		opts = append(opts, WithPublisher(*cfg.Publisher))
+	}
+	if cfg.Distribution != nil {
+		opts = append(opts, WithDistribution(*cfg.Distribution))
+	}
+	return
+}
@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    *Config
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:   "Happy path",
+			config: `{"publisher": {"name": "Example PSIRT", "namespace": "https://psirt.example.com", "category": "vendor"}}`,
+			want: &Config{Publisher: &Publisher{
+				Name:      "Example PSIRT",
+				Namespace: "https://psirt.example.com",
+				Category:  "vendor",
+			}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "Empty config",
+			config:  `{}`,
+			want:    &Config{},
+			wantErr: assert.NoError,
+		},
+		{
+			name:   "Err: Unknown property",
+			config: `{"publisher": {"name": "Example PSIRT", "namespaces": "https://psirt.example.com"}}`,
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorContains(t, err, `unknown field "namespaces"`)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			assert.NoError(t, os.WriteFile(path, []byte(tt.config), 0644))
+
+			got, err := LoadConfig(path)
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPublisher_Validate(t *testing.T) {
+	valid := Publisher{Name: "Example PSIRT", Namespace: "https://psirt.example.com", Category: "vendor"}
+	assert.NoError(t, valid.Validate())
+
+	invalid := Publisher{Namespace: "psirt.example.com", Category: "psirt"}
+	err := invalid.Validate()
+	assert.ErrorContains(t, err, "publisher name is missing")
+	assert.ErrorContains(t, err, "invalid publisher namespace 'psirt.example.com'")
+	assert.ErrorContains(t, err, "invalid publisher category 'psirt'")
+}
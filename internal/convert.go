@@ -0,0 +1,204 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/csaf-poc/ghsa/internal/utils"
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/global"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+)
+
+// Section names a part of the CSAF advisory built by the conversion.
+type Section string
+
+const (
+	SectionDocument        Section = "document"
+	SectionProductTree     Section = "product_tree"
+	SectionVulnerabilities Section = "vulnerabilities"
+)
+
+// SectionError is the error of converting a single section of the advisory.
+type SectionError struct {
+	Section Section
+	Err     error
+}
+
+func (e *SectionError) Error() string {
+	return fmt.Sprintf("could not extract csaf %s: %v", e.Section, e.Err)
+}
+
+func (e *SectionError) Unwrap() error {
+	return e.Err
+}
+
+// ConversionError lists the errors of all sections that could not be converted. Use errors.As to retrieve it and
+// errors.Is or errors.As to look for specific errors of the sections.
+type ConversionError struct {
+	Errors []*SectionError
+}
+
+func (e *ConversionError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *ConversionError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// add records err as error of section. Nil errors are ignored.
+func (e *ConversionError) add(section Section, err error) {
+	if err != nil {
+		e.Errors = append(e.Errors, &SectionError{Section: section, Err: err})
+	}
+}
+
+// converter holds the configuration of a conversion.
+type converter struct {
+	lenient      bool
+	publisher    *Publisher
+	distribution *DistributionConfig
+	// version is the CSAF version of the advisory, see WithCSAFVersion
+	version string
+	// profile is the document category of the CSAF profile the advisory complies with, see WithProfile
+	profile string
+	// epssTimestamp is the time the EPSS score was retrieved, see WithEPSSTimestamp
+	epssTimestamp time.Time
+	// global is the global advisory being converted, if any. It provides data not available in repository advisories.
+	global *global.Advisory
+}
+
+// ConvertOption configures the conversion of ToCSAF and GlobalToCSAF.
+type ConvertOption func(*converter)
+
+// WithLenient makes the conversion return a best-effort advisory even if sections could not be converted. The
+// *ConversionError is returned alongside the advisory and lists the problems as warnings. By default, the conversion is
+// strict and returns no advisory on any error.
+func WithLenient() ConvertOption {
+	return func(c *converter) {
+		c.lenient = true
+	}
+}
+
+// WithPublisher sets the publisher of the CSAF documents instead of the GitHub user who published the GHSA. The GitHub
+// user is acknowledged for publishing the GHSA instead.
+func WithPublisher(p Publisher) ConvertOption {
+	return func(c *converter) {
+		c.publisher = &p
+	}
+}
+
+// WithCSAFVersion sets the CSAF version of the advisory: csaf.Version20 (default) or csaf.Version21. CSAF 2.1 lists all
+// CWEs of the GHSA, while CSAF 2.0 allows a single CWE per vulnerability and lists the others in a note.
+func WithCSAFVersion(version string) ConvertOption {
+	return func(c *converter) {
+		c.version = version
+	}
+}
+
+// WithProfile makes the advisory comply with a CSAF profile (CSAF 2.0 section 4) instead of the base profile, whose
+// document category is "GitHub Security Advisory". Supported profiles: csaf.CategorySecurityAdvisory and
+// csaf.CategoryVEX.
+func WithProfile(category string) ConvertOption {
+	return func(c *converter) {
+		c.profile = category
+	}
+}
+
+// withGlobal sets the global advisory the converted repository advisory was derived from. See GlobalToCSAF.
+func withGlobal(g *global.Advisory) ConvertOption {
+	return func(c *converter) {
+		c.global = g
+	}
+}
+
+// newConverter creates a converter with the default configuration and applies opts.
+func newConverter(opts ...ConvertOption) *converter {
+	c := &converter{version: csaf.Version20}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// csaf21 reports whether the advisory is converted into CSAF 2.1.
+func (c *converter) csaf21() bool {
+	return c.version == csaf.Version21
+}
+
+// checkCSAFVersion checks that the CSAF version is supported.
+func checkCSAFVersion(version string) error {
+	if version != csaf.Version20 && version != csaf.Version21 {
+		return fmt.Errorf("unsupported CSAF version '%s'", version)
+	}
+	return nil
+}
+
+// checkProfile checks that the profile is supported. An empty profile is the base profile.
+func checkProfile(profile string) error {
+	switch profile {
+	case "", csaf.CategorySecurityAdvisory, csaf.CategoryVEX:
+		return nil
+	default:
+		return fmt.Errorf("unsupported profile '%s'", profile)
+	}
+}
+
+// Publisher is the publisher of the CSAF documents, e.g. a PSIRT republishing GHSAs. See CSAF 2.0 section 3.2.1.8.
+type Publisher struct {
+	// Name of the publisher (required)
+	Name string `json:"name"`
+	// Namespace is the URL of the publisher (required)
+	Namespace string `json:"namespace"`
+	// Category of the publisher: coordinator, discoverer, other, translator, user or vendor (required)
+	Category string `json:"category"`
+	// ContactDetails describes how to contact the publisher (optional)
+	ContactDetails string `json:"contact_details,omitempty"`
+	// IssuingAuthority describes the authority and the constraints of the publisher (optional)
+	IssuingAuthority string `json:"issuing_authority,omitempty"`
+}
+
+// Validate checks that all required fields are set and valid.
+func (p *Publisher) Validate() error {
+	var (
+		errs []error
+	)
+	if p.Name == "" {
+		errs = append(errs, errors.New("publisher name is missing"))
+	}
+	if !isURL(p.Namespace) {
+		errs = append(errs, fmt.Errorf("invalid publisher namespace '%s'", p.Namespace))
+	}
+	var category gocsaf.Category
+	if err := category.UnmarshalText([]byte(p.Category)); err != nil {
+		errs = append(errs, fmt.Errorf("invalid publisher category '%s'", p.Category))
+	}
+	return errors.Join(errs...)
+}
+
+// toCSAF returns the publisher as CSAF document publisher.
+func (p *Publisher) toCSAF() *gocsaf.DocumentPublisher {
+	dp := &gocsaf.DocumentPublisher{
+		Category:  utils.Ref(gocsaf.Category(p.Category)),
+		Name:      utils.Ref(p.Name),
+		Namespace: utils.Ref(p.Namespace),
+	}
+	if p.ContactDetails != "" {
+		dp.ContactDetails = utils.Ref(p.ContactDetails)
+	}
+	if p.IssuingAuthority != "" {
+		dp.IssuingAuthority = utils.Ref(p.IssuingAuthority)
+	}
+	return dp
+}
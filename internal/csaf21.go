@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/csaf-poc/ghsa/internal/utils"
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+)
+
+// cvss4Pattern matches CVSS v4.0 vector strings with all base metrics.
+var cvss4Pattern = regexp.MustCompile(`^CVSS:4[.]0/AV:[NALP]/AC:[LH]/AT:[NP]/PR:[NLH]/UI:[NPA]/VC:[HLN]/VI:[HLN]/VA:[HLN]/SC:[HLN]/SI:[HLN]/SA:[HLN]`)
+
+// errMissingCVSS4Score is returned by getCVSS4 for CVSS v4 vectors without base score.
+var errMissingCVSS4Score = errors.New("missing CVSS v4 base score of vector string")
+
+// cvss4NoImpactPattern matches CVSS v4.0 vector strings without any impact on the vulnerable or subsequent system.
+var cvss4NoImpactPattern = regexp.MustCompile(`/VC:N/VI:N/VA:N/SC:N/SI:N/SA:N(/|$)`)
+
+// getDocument21 returns the properties of the CSAF 2.1 document: the TLP 2.0 label and, for withdrawn advisories, the
+// document category of the withdrawn profile.
+func (c *converter) getDocument21(adv *repository.Advisory) *csaf.Document21 {
+	d := &csaf.Document21{
+		TLPLabel: csaf21TLPLabel(c.resolveDistribution(adv).TLP),
+	}
+	if getWithdrawnAt(adv) != "" {
+		d.Category = csaf.CategoryWithdrawn
+	}
+	return d
+}
+
+// csaf21TLPLabel converts a TLP label into a TLP 2.0 label as required by CSAF 2.1. TLP:WHITE is TLP:CLEAR in TLP 2.0.
+func csaf21TLPLabel(label string) string {
+	if label == gocsaf.TLPLabelWhite {
+		return TLPLabelClear
+	}
+	return label
+}
+
+// getWithdrawalNote returns the note explaining the withdrawal of the advisory or nil if it was not withdrawn. CSAF 2.1
+// documents of the withdrawn profile require the reasoning as description note.
+func (c *converter) getWithdrawalNote(adv *repository.Advisory) *gocsaf.Note {
+	withdrawnAt := getWithdrawnAt(adv)
+	if withdrawnAt == "" {
+		return nil
+	}
+	note := &gocsaf.Note{
+		NoteCategory: utils.Ref(gocsaf.CSAFNoteCategoryGeneral),
+		Text: utils.Ref(fmt.Sprintf("This advisory was withdrawn on %s. The vulnerability is no longer considered "+
+			"valid, so no product is listed as affected or fixed.", withdrawnAt)),
+		Title: utils.Ref("Withdrawn"),
+	}
+	if c.csaf21() {
+		note.NoteCategory = utils.Ref(gocsaf.CSAFNoteCategoryDescription)
+		note.Title = utils.Ref("Reasoning for Withdrawal")
+	}
+	return note
+}
+
+// getMetrics returns the CVSS v3 score (see getScores) and the CVSS v4 score of the advisory as CSAF 2.1 metric of the
+// affected products. Returns nil if the advisory has no score or affects no product.
+func getMetrics(adv *repository.Advisory, scores gocsaf.Scores, products []*product) (metrics []*csaf.Metric21, err error) {
+	var (
+		content = &csaf.MetricContent21{}
+	)
+	if len(scores) > 0 {
+		content.CVSS3 = scores[0].CVSS3
+	}
+	content.CVSS4, err = getCVSS4(adv)
+
+	affected := affectedProductIDs(products)
+	if len(affected) == 0 || (content.CVSS3 == nil && content.CVSS4 == nil) {
+		return nil, err
+	}
+	metrics = []*csaf.Metric21{{Content: content, Products: affected}}
+	return metrics, err
+}
+
+// getCVSS4 returns the CVSS v4 score of the advisory or nil if it has none.
+func getCVSS4(adv *repository.Advisory) (cvss *csaf.CVSS4, err error) {
+	score := adv.CVSSSeverities.CVSSv4
+	if score.VectorString == "" {
+		return nil, nil
+	}
+	if !cvss4Pattern.MatchString(score.VectorString) {
+		err = fmt.Errorf("invalid CVSS v4 vector string '%s'", score.VectorString)
+		return nil, err
+	}
+	if score.Score < 0 || score.Score > 10 {
+		err = fmt.Errorf("invalid CVSS v4 base score %v", score.Score)
+		return nil, err
+	}
+	// Only a vector without any impact scores 0. Other vectors without score (e.g. imported from OSV) are left out
+	// instead of publishing them as NONE.
+	if score.Score == 0 && !cvss4NoImpactPattern.MatchString(score.VectorString) {
+		err = fmt.Errorf("%w '%s'", errMissingCVSS4Score, score.VectorString)
+		return nil, err
+	}
+	cvss = &csaf.CVSS4{
+		Version:      "4.0",
+		VectorString: score.VectorString,
+		BaseScore:    score.Score,
+		// CVSS v4 uses the same qualitative severity rating scale as CVSS v3
+		BaseSeverity: string(cvss3Severity(score.Score)),
+	}
+	return cvss, nil
+}
+
+// affectedProductIDs returns the IDs of the affected products.
+func affectedProductIDs(products []*product) (ids []string) {
+	for _, p := range products {
+		if p.affected {
+			ids = append(ids, string(p.id))
+		}
+	}
+	return
+}
@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToCSAF_CSAF21Withdrawn(t *testing.T) {
+	var (
+		adv repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &adv)
+	adv.WithdrawnAt = "2025-04-01T00:00:00Z"
+
+	got, err := ToCSAF(&adv, WithCSAFVersion(csaf.Version21))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assertValidCSAF(t, got)
+	assert.Equal(t, csaf.CategoryWithdrawn, got.CSAF21.Document.Category)
+	assert.Nil(t, got.ProductTree)
+	assert.Nil(t, got.Vulnerabilities)
+
+	notes := got.Document.Notes
+	if assert.NotEmpty(t, notes) {
+		assert.Equal(t, "Reasoning for Withdrawal", *notes[len(notes)-1].Title)
+		assert.Equal(t, "description", string(*notes[len(notes)-1].NoteCategory))
+	}
+}
+
+func TestToCSAF_CSAF21TLP(t *testing.T) {
+	var (
+		adv repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &adv)
+
+	cfg := DistributionConfig{Distribution: Distribution{TLP: TLPLabelAmberStrict}}
+	got, err := ToCSAF(&adv, WithCSAFVersion(csaf.Version21), WithDistribution(cfg))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assertValidCSAF(t, got)
+	assert.Equal(t, TLPLabelAmberStrict, got.CSAF21.Document.TLPLabel)
+
+	// CSAF 2.0 cannot express TLP:AMBER+STRICT
+	_, err = ToCSAF(&adv, WithDistribution(cfg))
+	assert.ErrorContains(t, err, "not supported by CSAF 2.0")
+
+	got, err = ToCSAF(&adv, WithCSAFVersion(csaf.Version21))
+	if assert.NoError(t, err) {
+		assert.Equal(t, TLPLabelClear, got.CSAF21.Document.TLPLabel)
+	}
+}
+
+func TestGetCVSS4(t *testing.T) {
+	tests := []struct {
+		name    string
+		cvss    repository.CVSS
+		want    *csaf.CVSS4
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name: "Happy path",
+			cvss: repository.CVSS{
+				VectorString: "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N",
+				Score:        9.3,
+			},
+			want: &csaf.CVSS4{
+				Version:      "4.0",
+				VectorString: "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N",
+				BaseScore:    9.3,
+				BaseSeverity: "CRITICAL",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "No score",
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Err: CVSS v3 vector",
+			cvss: repository.CVSS{VectorString: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:L/A:H", Score: 8.2},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorContains(t, err, "invalid CVSS v4 vector string")
+			},
+		},
+		{
+			name: "No impact",
+			cvss: repository.CVSS{VectorString: "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:N/VI:N/VA:N/SC:N/SI:N/SA:N"},
+			want: &csaf.CVSS4{
+				Version:      "4.0",
+				VectorString: "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:N/VI:N/VA:N/SC:N/SI:N/SA:N",
+				BaseScore:    0,
+				BaseSeverity: "NONE",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Err: Missing score",
+			cvss: repository.CVSS{VectorString: "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N"},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, errMissingCVSS4Score)
+			},
+		},
+		{
+			name: "Err: Score out of range",
+			cvss: repository.CVSS{
+				VectorString: "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N",
+				Score:        11,
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorContains(t, err, "invalid CVSS v4 base score 11")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adv := &repository.Advisory{CVSSSeverities: repository.CVSSSeverities{CVSSv4: tt.cvss}}
+			got, err := getCVSS4(adv)
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCheckDocumentCategory(t *testing.T) {
+	tests := []struct {
+		category string
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{category: documentCategory, wantErr: assert.NoError},
+		{category: csaf.CategorySecurityAdvisory, wantErr: assert.NoError},
+		{category: csaf.CategoryWithdrawn, wantErr: assert.NoError},
+		{category: "Security Advisory", wantErr: assert.Error},
+		{category: "security-incident_response", wantErr: assert.Error},
+		{category: "csaf_custom", wantErr: assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.category, func(t *testing.T) {
+			tt.wantErr(t, checkDocumentCategory(tt.category))
+		})
+	}
+}
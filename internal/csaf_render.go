@@ -0,0 +1,544 @@
+package internal
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/csaf-poc/ghsa/internal/utils"
+	"github.com/csaf-poc/ghsa/models/csaf"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+)
+
+// templates holds the templates of the HTML and Markdown renderings of advisories.
+//
+//go:embed templates/*
+var templates embed.FS
+
+// noteHeadingLevel is the minimum level of the headings in notes rendered as HTML, below the headings of the notes.
+const noteHeadingLevel = 4
+
+// htmlTemplate and markdownTemplate render an advisoryView.
+var (
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("advisory.html.tmpl").Funcs(htmltemplate.FuncMap{
+		"markdown": func(s string) htmltemplate.HTML { return markdownToHTML(s, noteHeadingLevel) },
+	}).ParseFS(templates, "templates/advisory.html.tmpl"))
+	markdownTemplate = texttemplate.Must(texttemplate.New("advisory.md.tmpl").Funcs(texttemplate.FuncMap{
+		"markdown": sanitizeMarkdown,
+		"inline":   markdownInline,
+		"cell":     markdownCell,
+		"link":     markdownLink,
+		"autolink": markdownAutolink,
+		"join":     strings.Join,
+	}).ParseFS(templates, "templates/advisory.md.tmpl"))
+)
+
+// productStatusLabels are the headings of the product statuses in the order they are rendered.
+var productStatusLabels = []string{
+	"Known affected", "First affected", "Last affected", "Under investigation", "Known not affected", "Fixed",
+	"First fixed", "Recommended",
+}
+
+// advisoryView is the content of an advisory as it is rendered, resolved from the CSAF 2.0 and CSAF 2.1 properties.
+type advisoryView struct {
+	Title              string
+	TrackingID         string
+	Category           string
+	CSAFVersion        string
+	Status             string
+	Version            string
+	InitialReleaseDate string
+	CurrentReleaseDate string
+	Aliases            []string
+	Publisher          string
+	PublisherNamespace string
+	TLP                string
+	Distribution       string
+	AggregateSeverity  string
+	Notes              []noteView
+	Products           []productView
+	Vulnerabilities    []vulnerabilityView
+	Acknowledgments    []acknowledgmentView
+	References         []referenceView
+	Revisions          []revisionView
+	Generator          string
+}
+
+type noteView struct {
+	Title    string
+	Category string
+	Text     string
+}
+
+type productView struct {
+	ID   string
+	Name string
+	PURL string
+}
+
+type vulnerabilityView struct {
+	Title           string
+	CVE             string
+	IDs             []string
+	CWEs            []string
+	ReleaseDate     string
+	Notes           []noteView
+	Statuses        []statusView
+	Scores          []scoreView
+	Remediations    []remediationView
+	Threats         []threatView
+	Acknowledgments []acknowledgmentView
+	References      []referenceView
+}
+
+type statusView struct {
+	Label    string
+	Products []string
+}
+
+type scoreView struct {
+	Metric   string
+	Score    string
+	Severity string
+	Vector   string
+	Products []string
+}
+
+type remediationView struct {
+	Category string
+	Details  string
+	URL      string
+	Products []string
+}
+
+type threatView struct {
+	Category string
+	Details  string
+	Products []string
+}
+
+type acknowledgmentView struct {
+	Names        []string
+	Organization string
+	Summary      string
+	URLs         []string
+}
+
+type referenceView struct {
+	Category string
+	Summary  string
+	URL      string
+}
+
+type revisionView struct {
+	Number  string
+	Date    string
+	Summary string
+}
+
+// ToHTML renders the advisory as HTML page following the structure of the HTML rendering recommended by CSAF: the
+// document properties, the notes, the products of the product tree and, for each vulnerability, its product statuses,
+// scores, remediations and threats, followed by the acknowledgments, references and revision history. Notes are
+// Markdown, like the description of a GHSA, and are rendered safely (see markdownToHTML); all other content is escaped.
+func ToHTML(adv *csaf.Advisory) (page []byte, err error) {
+	view, err := getAdvisoryView(adv)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = htmlTemplate.Execute(&buf, view); err != nil {
+		err = fmt.Errorf("could not render advisory: %v", err)
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ToMarkdown renders the advisory as Markdown with the same structure as ToHTML. Notes are included as Markdown, so
+// notes like the description of a GHSA keep their formatting, but are sanitized like in ToHTML: raw HTML is escaped
+// and only http, https and mailto links are kept (see sanitizeMarkdown). The same applies to all other content.
+func ToMarkdown(adv *csaf.Advisory) (doc []byte, err error) {
+	view, err := getAdvisoryView(adv)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = markdownTemplate.Execute(&buf, view); err != nil {
+		err = fmt.Errorf("could not render advisory: %v", err)
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// getAdvisoryView resolves the content of the advisory for rendering.
+func getAdvisoryView(adv *csaf.Advisory) (view *advisoryView, err error) {
+	if adv == nil || adv.Document == nil || adv.Document.Tracking == nil || adv.Document.Tracking.ID == nil {
+		return nil, errors.New("advisory has no tracking ID")
+	}
+	d := adv.Document
+	t := d.Tracking
+
+	view = &advisoryView{
+		Title:              deref(d.Title),
+		TrackingID:         string(*t.ID),
+		CSAFVersion:        csaf.Version20,
+		InitialReleaseDate: deref(t.InitialReleaseDate),
+		CurrentReleaseDate: deref(t.CurrentReleaseDate),
+		Notes:              getNoteViews(d.Notes),
+		References:         getReferenceViews(d.References),
+	}
+	if d.Category != nil {
+		view.Category = string(*d.Category)
+	}
+	if t.Status != nil {
+		view.Status = string(*t.Status)
+	}
+	if t.Version != nil {
+		view.Version = string(*t.Version)
+	}
+	for _, alias := range t.Aliases {
+		if alias != nil {
+			view.Aliases = append(view.Aliases, *alias)
+		}
+	}
+	for _, r := range t.RevisionHistory {
+		if r != nil && r.Number != nil {
+			view.Revisions = append(view.Revisions, revisionView{Number: string(*r.Number), Date: deref(r.Date), Summary: deref(r.Summary)})
+		}
+	}
+	if g := t.Generator; g != nil && g.Engine != nil {
+		view.Generator = strings.TrimSpace(deref(g.Engine.Name) + " " + deref(g.Engine.Version))
+	}
+	if p := d.Publisher; p != nil {
+		view.Publisher = deref(p.Name)
+		view.PublisherNamespace = deref(p.Namespace)
+		if p.Category != nil {
+			view.Publisher += " (" + string(*p.Category) + ")"
+		}
+	}
+	if dist := d.Distribution; dist != nil {
+		view.Distribution = deref(dist.Text)
+		if dist.TLP != nil && dist.TLP.DocumentTLPLabel != nil {
+			view.TLP = string(*dist.TLP.DocumentTLPLabel)
+		}
+	}
+	if d.AggregateSeverity != nil {
+		view.AggregateSeverity = deref(d.AggregateSeverity.Text)
+	}
+	if d.Acknowledgements != nil {
+		view.Acknowledgments = getAcknowledgmentViews(*d.Acknowledgements)
+	}
+
+	// CSAF 2.1 replaces the category and TLP label
+	if adv.CSAF21 != nil {
+		view.CSAFVersion = csaf.Version21
+		if d21 := adv.CSAF21.Document; d21 != nil {
+			if d21.Category != "" {
+				view.Category = d21.Category
+			}
+			if d21.TLPLabel != "" {
+				view.TLP = d21.TLPLabel
+			}
+		}
+	}
+
+	names := make(map[gocsaf.ProductID]string)
+	view.Products = getProductViews(adv.ProductTree, names)
+	for i, v := range adv.Vulnerabilities {
+		var (
+			v21 *csaf.Vulnerability21
+		)
+		if v == nil {
+			continue
+		}
+		if adv.CSAF21 != nil {
+			// CSAF 2.1 replaces the CWE, scores and release date, see csaf.Vulnerability21
+			stripped := *v
+			stripped.CWE, stripped.Scores, stripped.ReleaseDate = nil, nil, nil
+			v = &stripped
+			if i < len(adv.CSAF21.Vulnerabilities) {
+				v21 = adv.CSAF21.Vulnerabilities[i]
+			}
+		}
+		view.Vulnerabilities = append(view.Vulnerabilities, getVulnerabilityView(v, v21, names))
+	}
+	return view, nil
+}
+
+// getProductViews lists the products of the product tree: the products of the branches, the full product names and
+// the products of relationships. names is filled with the names of the products by ID.
+func getProductViews(pt *csaf.ProductTree, names map[gocsaf.ProductID]string) (products []productView) {
+	var (
+		walk func(branches gocsaf.Branches)
+	)
+	add := func(p *gocsaf.FullProductName) {
+		if p == nil || p.ProductID == nil {
+			return
+		}
+		if _, ok := names[*p.ProductID]; ok {
+			return
+		}
+		view := productView{ID: string(*p.ProductID), Name: deref(p.Name)}
+		if h := p.ProductIdentificationHelper; h != nil && h.PURL != nil {
+			view.PURL = string(*h.PURL)
+		}
+		names[*p.ProductID] = view.Name
+		products = append(products, view)
+	}
+	walk = func(branches gocsaf.Branches) {
+		for _, b := range branches {
+			if b != nil {
+				add(b.Product)
+				walk(b.Branches)
+			}
+		}
+	}
+
+	if pt == nil {
+		return nil
+	}
+	walk(pt.Branches)
+	if pt.FullProductNames != nil {
+		for _, p := range *pt.FullProductNames {
+			add(p)
+		}
+	}
+	if pt.RelationShips != nil {
+		for _, r := range *pt.RelationShips {
+			if r != nil {
+				add(r.FullProductName)
+			}
+		}
+	}
+	return products
+}
+
+// getVulnerabilityView resolves the content of a vulnerability. v21 holds the CSAF 2.1 properties of the vulnerability
+// and may be nil.
+func getVulnerabilityView(v *gocsaf.Vulnerability, v21 *csaf.Vulnerability21, names map[gocsaf.ProductID]string) (
+	view vulnerabilityView) {
+	productNames := func(ids *gocsaf.Products) (list []string) {
+		if ids == nil {
+			return nil
+		}
+		for _, id := range *ids {
+			if id == nil {
+				continue
+			}
+			name, ok := names[*id]
+			if !ok || name == "" {
+				name = string(*id)
+			}
+			list = append(list, name)
+		}
+		return list
+	}
+
+	view = vulnerabilityView{
+		Title:       deref(v.Title),
+		ReleaseDate: deref(v.ReleaseDate),
+		Notes:       getNoteViews(v.Notes),
+		References:  getReferenceViews(v.References),
+	}
+	view.Acknowledgments = getAcknowledgmentViews(v.Acknowledgements)
+	if v.CVE != nil {
+		view.CVE = string(*v.CVE)
+	}
+	for _, id := range v.IDs {
+		if id != nil && id.Text != nil {
+			view.IDs = append(view.IDs, *id.Text)
+		}
+	}
+	if v.CWE != nil && v.CWE.ID != nil {
+		view.CWEs = append(view.CWEs, strings.TrimSuffix(string(*v.CWE.ID)+": "+deref(v.CWE.Name), ": "))
+	}
+
+	if s := v.ProductStatus; s != nil {
+		for i, ids := range []*gocsaf.Products{
+			s.KnownAffected, s.FirstAffected, s.LastAffected, s.UnderInvestigation, s.KnownNotAffected, s.Fixed,
+			s.FirstFixed, s.Recommended,
+		} {
+			if products := productNames(ids); len(products) > 0 {
+				view.Statuses = append(view.Statuses, statusView{Label: productStatusLabels[i], Products: products})
+			}
+		}
+	}
+
+	for _, s := range v.Scores {
+		if s == nil {
+			continue
+		}
+		if c := s.CVSS3; c != nil {
+			view.Scores = append(view.Scores, cvss3ScoreView(c, productNames(s.Products)))
+		}
+		if c := s.CVSS2; c != nil && c.VectorString != nil {
+			score := scoreView{Metric: "CVSS 2.0", Vector: string(*c.VectorString), Products: productNames(s.Products)}
+			if c.BaseScore != nil {
+				score.Score = strconv.FormatFloat(*c.BaseScore, 'f', 1, 64)
+			}
+			view.Scores = append(view.Scores, score)
+		}
+	}
+
+	for _, r := range v.Remediations {
+		if r == nil {
+			continue
+		}
+		remediation := remediationView{Details: deref(r.Details), URL: deref(r.URL), Products: productNames(r.ProductIds)}
+		if r.Category != nil {
+			remediation.Category = string(*r.Category)
+		}
+		view.Remediations = append(view.Remediations, remediation)
+	}
+	for _, t := range v.Threats {
+		if t == nil {
+			continue
+		}
+		threat := threatView{Details: deref(t.Details), Products: productNames(t.ProductIds)}
+		if t.Category != nil {
+			threat.Category = string(*t.Category)
+		}
+		view.Threats = append(view.Threats, threat)
+	}
+
+	if v21 != nil {
+		if v21.DisclosureDate != "" {
+			view.ReleaseDate = v21.DisclosureDate
+		}
+		for _, cwe := range v21.CWEs {
+			if cwe != nil {
+				view.CWEs = append(view.CWEs, cwe.ID+": "+cwe.Name)
+			}
+		}
+		for _, m := range v21.Metrics {
+			if m == nil || m.Content == nil {
+				continue
+			}
+			var ids gocsaf.Products
+			for _, id := range m.Products {
+				ids = append(ids, utils.Ref(gocsaf.ProductID(id)))
+			}
+			products := productNames(&ids)
+			if c := m.Content.CVSS3; c != nil {
+				view.Scores = append(view.Scores, cvss3ScoreView(c, products))
+			}
+			if c := m.Content.CVSS4; c != nil {
+				view.Scores = append(view.Scores, scoreView{
+					Metric:   "CVSS " + c.Version,
+					Score:    strconv.FormatFloat(c.BaseScore, 'f', 1, 64),
+					Severity: c.BaseSeverity,
+					Vector:   c.VectorString,
+					Products: products,
+				})
+			}
+			if e := m.Content.EPSS; e != nil {
+				view.Scores = append(view.Scores, scoreView{
+					Metric:   "EPSS",
+					Score:    e.Probability,
+					Severity: "Percentile " + e.Percentile,
+					Products: products,
+				})
+			}
+		}
+	}
+	return view
+}
+
+// cvss3ScoreView returns the view of a CVSS v3 score.
+func cvss3ScoreView(c *gocsaf.CVSS3, products []string) (score scoreView) {
+	score = scoreView{Metric: "CVSS 3", Products: products}
+	if c.Version != nil {
+		score.Metric = "CVSS " + string(*c.Version)
+	}
+	if c.VectorString != nil {
+		score.Vector = string(*c.VectorString)
+	}
+	if c.BaseScore != nil {
+		score.Score = strconv.FormatFloat(*c.BaseScore, 'f', 1, 64)
+	}
+	if c.BaseSeverity != nil {
+		score.Severity = string(*c.BaseSeverity)
+	}
+	return score
+}
+
+// getNoteViews returns the views of the notes.
+func getNoteViews(notes gocsaf.Notes) (views []noteView) {
+	for _, n := range notes {
+		if n == nil || n.Text == nil {
+			continue
+		}
+		view := noteView{Title: deref(n.Title), Text: strings.TrimSpace(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(*n.Text))}
+		if n.NoteCategory != nil {
+			view.Category = string(*n.NoteCategory)
+		}
+		if view.Title == "" {
+			view.Title = view.Category
+		}
+		views = append(views, view)
+	}
+	return views
+}
+
+// getReferenceViews returns the views of the references.
+func getReferenceViews(refs gocsaf.References) (views []referenceView) {
+	for _, r := range refs {
+		if r != nil && r.URL != nil {
+			views = append(views, referenceView{Category: deref(r.ReferenceCategory), Summary: deref(r.Summary), URL: *r.URL})
+		}
+	}
+	return views
+}
+
+// getAcknowledgmentViews returns the views of the acknowledgments.
+func getAcknowledgmentViews(acks gocsaf.Acknowledgements) (views []acknowledgmentView) {
+	for _, a := range acks {
+		if a == nil {
+			continue
+		}
+		view := acknowledgmentView{Organization: deref(a.Organization), Summary: deref(a.Summary)}
+		for _, name := range a.Names {
+			if name != nil {
+				view.Names = append(view.Names, *name)
+			}
+		}
+		for _, u := range a.URLs {
+			if u != nil {
+				view.URLs = append(view.URLs, *u)
+			}
+		}
+		views = append(views, view)
+	}
+	return views
+}
+
+// markdownInline sanitizes text for a single line of a Markdown document, e.g. a heading (see sanitizeInline).
+func markdownInline(s string) string {
+	return sanitizeInline(strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(s))
+}
+
+// markdownCell sanitizes text for a cell of a Markdown table.
+func markdownCell(s string) string {
+	return markdownInline(strings.ReplaceAll(s, "|", `\|`))
+}
+
+// markdownLink returns a Markdown link to u with the text, or the text followed by the URL as text if u is not an
+// http, https or mailto URL.
+func markdownLink(text string, u string) string {
+	if !isSafeURL(u) {
+		return markdownInline(text) + " (" + markdownInline(u) + ")"
+	}
+	return "[" + markdownInline(text) + "](" + mdDestinationEscaper.Replace(u) + ")"
+}
+
+// markdownAutolink returns an autolink to u, or u as text if it is not an http, https or mailto URL.
+func markdownAutolink(u string) string {
+	if !isSafeURL(u) || strings.ContainsAny(u, " \t\r\n<>") {
+		return markdownInline(u)
+	}
+	return "<" + u + ">"
+}
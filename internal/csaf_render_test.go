@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/csaf-poc/ghsa/internal/utils"
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToHTML(t *testing.T) {
+	var (
+		ghsa repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &ghsa)
+	ghsa.Description += "\n\n<script>alert(1)</script>"
+	adv, err := ToCSAF(&ghsa)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	b, err := ToHTML(adv)
+	if !assert.NoError(t, err) {
+		return
+	}
+	page := string(b)
+	assert.Contains(t, page, "<title>GHSA-mh63-6h87-95cp: Excessive memory allocation during header parsing</title>")
+	assert.Contains(t, page, `<p class="tlp">TLP:WHITE</p>`)
+	// The description is rendered from Markdown, but raw HTML is escaped
+	assert.Contains(t, page, "<h4>Summary</h4>")
+	assert.Contains(t, page, "<em>Authorization</em>")
+	assert.Contains(t, page, "&lt;script&gt;alert(1)&lt;/script&gt;")
+	assert.NotContains(t, page, "<script>")
+	// Product tree and product status
+	assert.Contains(t, page, "<tr><td>CSAFPID-0001</td><td>github.com/golang-jwt/jwt/v5 &lt;= 5.2.1</td><td>pkg:golang/github.com/golang-jwt/jwt/v5</td></tr>")
+	assert.Contains(t, page, "<tr><th>Fixed</th><td>github.com/golang-jwt/jwt/v5 5.2.2<br>github.com/golang-jwt/jwt/v4 4.5.2</td></tr>")
+	// Scores and remediations
+	assert.Contains(t, page, "<td>CVSS 3.1</td><td>7.5</td><td>HIGH</td><td>CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H</td>")
+	assert.Contains(t, page, "<td>vendor_fix</td><td>Upgrade github.com/golang-jwt/jwt/v5 to version 5.2.2 or later.</td>")
+
+	_, err = ToHTML(&csaf.Advisory{})
+	assert.ErrorContains(t, err, "no tracking ID")
+}
+
+func TestToMarkdown(t *testing.T) {
+	var (
+		ghsa repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &ghsa)
+	adv, err := ToCSAF(&ghsa, WithCSAFVersion(csaf.Version21))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	b, err := ToMarkdown(adv)
+	if !assert.NoError(t, err) {
+		return
+	}
+	doc := string(b)
+	assert.True(t, strings.HasPrefix(doc, "# GHSA-mh63-6h87-95cp: Excessive memory allocation during header parsing\n"))
+	assert.Contains(t, doc, "**TLP:CLEAR**")
+	assert.Contains(t, doc, "| CSAF version | 2.1 |")
+	// The description is kept as Markdown
+	assert.Contains(t, doc, "### Description\n\n### Summary\n\nFunction [`parse.ParseUnverified`]")
+	assert.Contains(t, doc, "| Known affected | github.com/golang-jwt/jwt/v5 <= 5.2.1, github.com/golang-jwt/jwt/v4 <= 4.5.1 |")
+	// CSAF 2.1 metrics replace the scores
+	assert.Equal(t, 1, strings.Count(doc, "| CVSS 3.1 | 7.5 | HIGH |"))
+	assert.Contains(t, doc, "\n- [NVD entry of CVE-2025-30204](https://nvd.nist.gov/vuln/detail/CVE-2025-30204) (external)")
+	assert.Contains(t, doc, "\n- jub0bs (https://api.github.com/users/jub0bs/orgs): Reported the vulnerability <https://github.com/jub0bs>")
+
+	// Untrusted content is sanitized like in the HTML page
+	ghsa.Summary = "<script>alert(1)</script>"
+	ghsa.Description = "[click](javascript:alert(1)) <img src=x onerror=alert(1)>"
+	adv, err = ToCSAF(&ghsa)
+	if !assert.NoError(t, err) {
+		return
+	}
+	adv.Document.References = append(adv.Document.References, &gocsaf.Reference{
+		Summary: utils.Ref("[x](javascript:alert(2))"),
+		URL:     utils.Ref("javascript:alert(2)"),
+	})
+	b, err = ToMarkdown(adv)
+	if !assert.NoError(t, err) {
+		return
+	}
+	doc = string(b)
+	assert.True(t, strings.HasPrefix(doc, "# GHSA-mh63-6h87-95cp: &lt;script>alert(1)&lt;/script>\n"))
+	assert.Contains(t, doc, "\n\nclick &lt;img src=x onerror=alert(1)>\n")
+	assert.NotContains(t, doc, "<script")
+	assert.NotContains(t, doc, "](javascript:")
+	assert.Contains(t, doc, "\n- x (javascript:alert(2))\n")
+}
+
+func TestMarkdownCell(t *testing.T) {
+	assert.Equal(t, `a \| b c <= 1.2 &lt;b> [x\]`, markdownCell("a | b\nc <= 1.2 <b> [x]"))
+	assert.Equal(t, `[x](https://example.com)`, markdownCell("[x](https://example.com)"))
+}
+
+func TestMarkdownLink(t *testing.T) {
+	assert.Equal(t, "[a *b*](https://example.com/%28x%29)", markdownLink("a *b*", "https://example.com/(x)"))
+	assert.Equal(t, "click (javascript:alert(1))", markdownLink("click", "javascript:alert(1)"))
+	assert.Equal(t, "<https://example.com>", markdownAutolink("https://example.com"))
+	assert.Equal(t, "javascript:alert(1)&lt;b>", markdownAutolink("javascript:alert(1)<b>"))
+}
+
+func TestStore_SaveHTML(t *testing.T) {
+	var (
+		ghsa repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &ghsa)
+	adv, err := ToCSAF(&ghsa)
+	if !assert.NoError(t, err) {
+		return
+	}
+	s := NewStore(t.TempDir())
+
+	path, err := s.SaveHTML(&ghsa, adv, "")
+	if assert.NoError(t, err) {
+		assert.Equal(t, filepath.Join(s.Dir, "white", "2025", "ghsa-mh63-6h87-95cp.html"), path)
+		assert.FileExists(t, path)
+	}
+	path, err = s.SaveMarkdown(&ghsa, adv, filepath.Join(s.Dir, "white", "2025", "ghsa-mh63-6h87-95cp.json"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, filepath.Join(s.Dir, "white", "2025", "ghsa-mh63-6h87-95cp.md"), path)
+		b, _ := os.ReadFile(path)
+		assert.True(t, strings.HasSuffix(string(b), "\n"))
+	}
+}
@@ -0,0 +1,456 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+)
+
+// ghsaEcosystems maps package URL types to the ecosystems of GHSA (the inverse of purlType).
+var ghsaEcosystems = map[string]string{
+	"golang":        "go",
+	"npm":           "npm",
+	"pypi":          "pip",
+	"maven":         "maven",
+	"nuget":         "nuget",
+	"gem":           "rubygems",
+	"cargo":         "rust",
+	"composer":      "composer",
+	"hex":           "erlang",
+	"pub":           "pub",
+	"swift":         "swift",
+	"githubactions": "actions",
+}
+
+// ghsaCreditTypes maps the summaries of acknowledgments (see creditTypeToSummary) to the credit types of GHSA.
+var ghsaCreditTypes = func() map[string]string {
+	types := make(map[string]string, len(creditTypeSummaries))
+	for typ, summary := range creditTypeSummaries {
+		types[summary] = typ
+	}
+	return types
+}()
+
+// validCreditTypes are the credit types accepted by the GitHub API.
+var validCreditTypes = []string{
+	"analyst", "finder", "reporter", "coordinator", "remediation_developer", "remediation_reviewer",
+	"remediation_verifier", "tool", "sponsor", "other",
+}
+
+// ghsaSeverityLevels are the severities accepted by the GitHub API.
+var ghsaSeverityLevels = []string{"critical", "high", "medium", "low"}
+
+// FromCSAF converts a CSAF advisory into the request bodies to create repository security advisories on GitHub, one
+// for each vulnerability of the advisory. The summary is the title of the vulnerability (or the document) and the
+// description its description note (or the description or summary note of the document). Each known affected product
+// with a package URL becomes a vulnerable package; version range products are converted back into GHSA ranges and the
+// lowest fixed product of the same package above a range becomes its patched version. The CVSS vector is taken from the first CVSS v3 or
+// v4 score, or, if there is none, the severity from the aggregate severity of the document. Acknowledgments are
+// credited to the GitHub users of their URLs.
+func FromCSAF(adv *csaf.Advisory) (reqs []*repository.CreateRequest, err error) {
+	if adv == nil || adv.Document == nil {
+		return nil, errors.New("advisory has no document")
+	}
+	if len(adv.Vulnerabilities) == 0 {
+		return nil, errors.New("advisory has no vulnerabilities")
+	}
+
+	products := getVEXProducts(adv.ProductTree)
+	for i, v := range adv.Vulnerabilities {
+		var (
+			v21 *csaf.Vulnerability21
+			req *repository.CreateRequest
+		)
+		if adv.CSAF21 != nil && i < len(adv.CSAF21.Vulnerabilities) {
+			v21 = adv.CSAF21.Vulnerabilities[i]
+		}
+		req, err = getCreateRequest(adv.Document, v, v21, products)
+		if err != nil {
+			err = fmt.Errorf("could not convert vulnerability %d: %w", i, err)
+			return nil, err
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+// getCreateRequest converts a vulnerability of the document into a request body. v21 holds the CSAF 2.1 properties of
+// the vulnerability and may be nil.
+func getCreateRequest(doc *csaf.Document, v *gocsaf.Vulnerability, v21 *csaf.Vulnerability21,
+	products map[gocsaf.ProductID]vexProduct) (req *repository.CreateRequest, err error) {
+	if v == nil {
+		return nil, errors.New("vulnerability is empty")
+	}
+
+	req = &repository.CreateRequest{
+		Summary:     deref(v.Title),
+		Description: getGHSADescription(doc, v),
+		CWEIDs:      getGHSACWEs(v, v21),
+		Credits:     getGHSACredits(doc, v),
+	}
+	if req.Summary == "" {
+		req.Summary = deref(doc.Title)
+	}
+	if req.Summary == "" {
+		return nil, errors.New("vulnerability has no title")
+	}
+	if req.Description == "" {
+		req.Description = req.Summary
+	}
+	if v.CVE != nil {
+		req.CVEID = string(*v.CVE)
+	}
+
+	req.Vulnerabilities, err = getGHSAVulnerabilities(v, products)
+	if err != nil {
+		return nil, err
+	}
+
+	// The API accepts either a CVSS vector or a severity
+	req.CVSSVectorString = getGHSAVector(v, v21)
+	if req.CVSSVectorString == "" && doc.AggregateSeverity != nil {
+		severity := strings.ToLower(deref(doc.AggregateSeverity.Text))
+		if severity == "moderate" {
+			severity = "medium"
+		}
+		if slices.Contains(ghsaSeverityLevels, severity) {
+			req.Severity = severity
+		}
+	}
+	return req, nil
+}
+
+// getGHSADescription returns the text of the description note of the vulnerability or, if it has none, of the
+// description or summary note of the document.
+func getGHSADescription(doc *csaf.Document, v *gocsaf.Vulnerability) string {
+	for _, notes := range []struct {
+		notes      gocsaf.Notes
+		categories []gocsaf.NoteCategory
+	}{
+		{v.Notes, []gocsaf.NoteCategory{gocsaf.CSAFNoteCategoryDescription}},
+		{doc.Notes, []gocsaf.NoteCategory{gocsaf.CSAFNoteCategoryDescription, gocsaf.CSAFNoteCategorySummary}},
+	} {
+		for _, category := range notes.categories {
+			for _, n := range notes.notes {
+				if n != nil && n.NoteCategory != nil && *n.NoteCategory == category && deref(n.Text) != "" {
+					return *n.Text
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// getGHSAVulnerabilities returns a vulnerable package for each known affected product of the vulnerability that has a
+// package URL. Version ranges of disjoint intervals become a vulnerable package per interval. The patched version of a
+// vulnerable package is the lowest version of the fixed products of the same package above its range.
+func getGHSAVulnerabilities(v *gocsaf.Vulnerability, products map[gocsaf.ProductID]vexProduct) (
+	vulns []repository.CreateVulnerability, err error) {
+	var (
+		// bases holds the package URL without version of each vulnerable package
+		bases []string
+	)
+	if v.ProductStatus == nil || v.ProductStatus.KnownAffected == nil {
+		return nil, errors.New("vulnerability has no known affected products")
+	}
+
+	// Versions of the fixed products by package URL without version
+	patched := make(map[string][]string)
+	if v.ProductStatus.Fixed != nil {
+		for _, id := range *v.ProductStatus.Fixed {
+			p, ok := products[*id]
+			if !ok || p.versionRange != "" {
+				continue
+			}
+			_, _, version, err := parsePURL(p.purl)
+			if err != nil || version == "" {
+				continue
+			}
+			base := purlBase(p.purl)
+			if !slices.Contains(patched[base], version) {
+				patched[base] = append(patched[base], version)
+			}
+		}
+	}
+
+	for _, id := range *v.ProductStatus.KnownAffected {
+		p, ok := products[*id]
+		if !ok {
+			continue
+		}
+		typ, name, version, err := parsePURL(p.purl)
+		if err != nil {
+			return nil, err
+		}
+		ranges := []string{""}
+		switch {
+		case p.versionRange != "":
+			if ranges, err = ghsaRanges(p.versionRange); err != nil {
+				return nil, err
+			}
+		case version != "":
+			ranges = []string{"= " + version}
+		}
+		for _, r := range ranges {
+			vuln := repository.CreateVulnerability{
+				Package:                repository.Package{Ecosystem: ghsaEcosystem(typ), Name: name},
+				VulnerableVersionRange: r,
+			}
+			if !slices.ContainsFunc(vulns, func(existing repository.CreateVulnerability) bool {
+				return existing.Package == vuln.Package && existing.VulnerableVersionRange == vuln.VulnerableVersionRange
+			}) {
+				vulns = append(vulns, vuln)
+				bases = append(bases, purlBase(p.purl))
+			}
+		}
+	}
+	if len(vulns) == 0 {
+		return nil, errors.New("vulnerability has no known affected product with package URL")
+	}
+
+	for i := range vulns {
+		only := len(slices.DeleteFunc(slices.Clone(bases), func(base string) bool { return base != bases[i] })) == 1
+		vulns[i].PatchedVersions = firstPatchedVersion(vulns[i].VulnerableVersionRange, patched[bases[i]], only)
+	}
+	return vulns, nil
+}
+
+// firstPatchedVersion returns the lowest of the versions above the upper bound of a GHSA version range, e.g. "4.5.2"
+// of "4.5.2, 5.2.2" for "<= 4.5.1". Ranges without upper bound have no patched version. Versions that cannot be
+// compared with the bound are only used if they are the single fixed version of a package with a single range (only).
+func firstPatchedVersion(ghsaRange string, versions []string, only bool) (first string) {
+	var (
+		op, bound string
+	)
+	for _, c := range strings.Split(ghsaRange, ",") {
+		if o, v, _ := strings.Cut(strings.TrimSpace(c), " "); o == "<" || o == "<=" || o == "=" {
+			op, bound = o, v
+		}
+	}
+	if bound == "" {
+		return ""
+	}
+
+	for _, version := range versions {
+		order, ok := compareVersions(version, bound)
+		switch {
+		case !ok:
+			if only && len(versions) == 1 {
+				return version
+			}
+			continue
+		case order < 0, order == 0 && op != "<":
+			continue
+		}
+		if first == "" {
+			first = version
+		} else if order, _ = compareVersions(version, first); order < 0 {
+			first = version
+		}
+	}
+	return first
+}
+
+// ghsaEcosystem returns the GHSA ecosystem of a package URL type. Unknown types belong to the ecosystem "other".
+func ghsaEcosystem(purlType string) string {
+	if ecosystem, ok := ghsaEcosystems[purlType]; ok {
+		return ecosystem
+	}
+	return "other"
+}
+
+// parsePURL returns the type, the package name in the notation of GHSA and the version of a package URL. Maven
+// namespaces are joined with the name by a colon (GROUP:ARTIFACT), all other namespaces by a slash.
+func parsePURL(purl string) (typ string, name string, version string, err error) {
+	rest, ok := strings.CutPrefix(purl, "pkg:")
+	if !ok {
+		err = fmt.Errorf("invalid package URL '%s'", purl)
+		return
+	}
+	rest, _, _ = strings.Cut(rest, "#")
+	rest, _, _ = strings.Cut(rest, "?")
+	if i := strings.LastIndex(rest, "@"); i > strings.LastIndex(rest, "/") {
+		rest, version = rest[:i], rest[i+1:]
+		if version, err = url.PathUnescape(version); err != nil {
+			err = fmt.Errorf("invalid package URL '%s': %v", purl, err)
+			return
+		}
+	}
+
+	segments := strings.Split(strings.Trim(rest, "/"), "/")
+	if len(segments) < 2 {
+		err = fmt.Errorf("invalid package URL '%s'", purl)
+		return
+	}
+	typ = strings.ToLower(segments[0])
+	segments = segments[1:]
+	for i, s := range segments {
+		if segments[i], err = url.PathUnescape(s); err != nil {
+			err = fmt.Errorf("invalid package URL '%s': %v", purl, err)
+			return
+		}
+	}
+
+	name = strings.Join(segments, "/")
+	if typ == "maven" && len(segments) > 1 {
+		name = strings.Join(segments[:len(segments)-1], "/") + ":" + segments[len(segments)-1]
+	}
+	return typ, name, version, nil
+}
+
+// ghsaRanges converts a vers range (e.g. "vers:npm/>=7.0|<=7.5.1") into GHSA version ranges (e.g. ">= 7.0, <= 7.5.1").
+// A GHSA range is a single interval, so vers ranges of several intervals or versions (e.g. "vers:npm/>=1|<1.5|>=2|<2.3")
+// are split into a GHSA range per interval. The vers range "*" of all versions is the empty GHSA range. Returns an error
+// for excluded versions (!=), which GHSA ranges cannot express. It is the inverse of versRange.
+func ghsaRanges(vers string) (ranges []string, err error) {
+	var (
+		constraints []string
+	)
+	flush := func() {
+		if len(constraints) > 0 {
+			ranges = append(ranges, strings.Join(constraints, ", "))
+			constraints = nil
+		}
+	}
+
+	_, spec, _ := strings.Cut(strings.TrimPrefix(vers, "vers:"), "/")
+	for _, c := range strings.Split(spec, "|") {
+		c = strings.TrimSpace(c)
+		if c == "" || c == "*" {
+			continue
+		}
+		op := "="
+		for _, prefix := range []string{">=", "<=", "!=", ">", "<", "="} {
+			if strings.HasPrefix(c, prefix) {
+				op, c = prefix, c[len(prefix):]
+				break
+			}
+		}
+		switch op {
+		case "!=":
+			err = fmt.Errorf("version range '%s' excludes version %s, which GHSA cannot express", vers, c)
+			return nil, err
+		case "=":
+			// A single version is an interval of its own
+			flush()
+			constraints = append(constraints, op+" "+c)
+			flush()
+		case ">=", ">":
+			// A lower bound starts a new interval
+			flush()
+			constraints = append(constraints, op+" "+c)
+		default:
+			// An upper bound ends the interval
+			constraints = append(constraints, op+" "+c)
+			flush()
+		}
+	}
+	flush()
+	if len(ranges) == 0 {
+		ranges = []string{""}
+	}
+	return ranges, nil
+}
+
+// getGHSAVector returns the vector of the first CVSS v3 score of the vulnerability or, if it has none, of the first
+// CVSS v4 metric of CSAF 2.1.
+func getGHSAVector(v *gocsaf.Vulnerability, v21 *csaf.Vulnerability21) string {
+	for _, s := range v.Scores {
+		if s != nil && s.CVSS3 != nil && s.CVSS3.VectorString != nil {
+			return string(*s.CVSS3.VectorString)
+		}
+	}
+	if v21 == nil {
+		return ""
+	}
+	for _, m := range v21.Metrics {
+		if m != nil && m.Content != nil && m.Content.CVSS3 != nil && m.Content.CVSS3.VectorString != nil {
+			return string(*m.Content.CVSS3.VectorString)
+		}
+	}
+	for _, m := range v21.Metrics {
+		if m != nil && m.Content != nil && m.Content.CVSS4 != nil {
+			return m.Content.CVSS4.VectorString
+		}
+	}
+	return ""
+}
+
+// getGHSACWEs returns the CWE IDs of the vulnerability, taken from the CWE of CSAF 2.0 or the CWEs of CSAF 2.1.
+func getGHSACWEs(v *gocsaf.Vulnerability, v21 *csaf.Vulnerability21) (ids []string) {
+	if v.CWE != nil && v.CWE.ID != nil {
+		ids = append(ids, string(*v.CWE.ID))
+	}
+	if v21 != nil {
+		for _, cwe := range v21.CWEs {
+			if cwe != nil && !slices.Contains(ids, cwe.ID) {
+				ids = append(ids, cwe.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// getGHSACredits credits the GitHub users of the acknowledgments of the document and the vulnerability. The login is
+// taken from the first URL of an acknowledgment that points to a GitHub profile (https://github.com/LOGIN);
+// acknowledgments without such URL and the acknowledgment of the publisher of the GHSA are skipped. The credit type is
+// derived from the summary of the acknowledgment and defaults to "other".
+func getGHSACredits(doc *csaf.Document, v *gocsaf.Vulnerability) (credits []repository.Credit) {
+	var (
+		acks gocsaf.Acknowledgements
+	)
+	if doc.Acknowledgements != nil {
+		acks = append(acks, *doc.Acknowledgements...)
+	}
+	acks = append(acks, v.Acknowledgements...)
+
+	for _, a := range acks {
+		if a == nil || deref(a.Summary) == publisherAcknowledgement {
+			continue
+		}
+		login := ""
+		for _, u := range a.URLs {
+			if login = githubLogin(deref(u)); login != "" {
+				break
+			}
+		}
+		if login == "" || slices.ContainsFunc(credits, func(c repository.Credit) bool { return c.Login == login }) {
+			continue
+		}
+		credits = append(credits, repository.Credit{Login: login, Type: ghsaCreditType(deref(a.Summary))})
+	}
+	return credits
+}
+
+// githubLogin returns the login of a GitHub profile URL (e.g. https://github.com/oxisto) or an empty string if the URL
+// is no profile URL.
+func githubLogin(profileURL string) string {
+	u, err := url.Parse(profileURL)
+	if err != nil || u.Scheme != "https" || u.Host != strings.TrimPrefix(DefaultWebURL, "https://") {
+		return ""
+	}
+	login := strings.Trim(u.Path, "/")
+	if login == "" || strings.Contains(login, "/") {
+		return ""
+	}
+	return login
+}
+
+// ghsaCreditType returns the credit type of the summary of an acknowledgment, which is either a phrase of
+// creditTypeToSummary or a credit type itself.
+func ghsaCreditType(summary string) string {
+	if typ, ok := ghsaCreditTypes[summary]; ok {
+		return typ
+	}
+	typ := strings.ToLower(summary)
+	if slices.Contains(validCreditTypes, typ) {
+		return typ
+	}
+	return "other"
+}
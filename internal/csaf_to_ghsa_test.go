@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/csaf-poc/ghsa/internal/utils"
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromCSAF(t *testing.T) {
+	var (
+		ghsa repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &ghsa)
+
+	for _, version := range []string{csaf.Version20, csaf.Version21} {
+		t.Run(version, func(t *testing.T) {
+			adv, err := ToCSAF(&ghsa, WithCSAFVersion(version), WithPublisher(Publisher{Name: "Example", Namespace: "https://example.com", Category: "vendor"}))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			// Convert the document as read from a file (see -from-csaf)
+			b, err := csaf.Marshal(adv)
+			if !assert.NoError(t, err) {
+				return
+			}
+			parsed, err := csaf.Unmarshal(b)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, adv.CSAF21, parsed.CSAF21)
+
+			reqs, err := FromCSAF(parsed)
+			if !assert.NoError(t, err) || !assert.Len(t, reqs, 1) {
+				return
+			}
+			req := reqs[0]
+			assert.Equal(t, ghsa.Summary, req.Summary)
+			assert.Equal(t, ghsa.Description, req.Description)
+			assert.Equal(t, ghsa.CveID, req.CVEID)
+			assert.Equal(t, ghsa.CWEIds, req.CWEIDs)
+			assert.Equal(t, ghsa.CVSSSeverities.CVSSv3.VectorString, req.CVSSVectorString)
+			assert.Empty(t, req.Severity)
+			assert.Equal(t, []repository.Credit{{Login: "jub0bs", Type: "reporter"}, {Login: "Web-E", Type: "reporter"}},
+				req.Credits)
+			assert.Equal(t, []repository.CreateVulnerability{
+				{
+					Package:                repository.Package{Ecosystem: "go", Name: "github.com/golang-jwt/jwt/v5"},
+					VulnerableVersionRange: "<= 5.2.1",
+					PatchedVersions:        "5.2.2",
+				},
+				{
+					Package:                repository.Package{Ecosystem: "go", Name: "github.com/golang-jwt/jwt/v4"},
+					VulnerableVersionRange: "<= 4.5.1",
+					PatchedVersions:        "4.5.2",
+				},
+			}, req.Vulnerabilities)
+		})
+	}
+}
+
+func TestFromCSAF_Severity(t *testing.T) {
+	var (
+		ghsa repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &ghsa)
+	ghsa.CVSS = repository.CVSS{}
+	ghsa.CVSSSeverities = repository.CVSSSeverities{}
+	ghsa.Severity = "moderate"
+	adv, err := ToCSAF(&ghsa)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, &gocsaf.AggregateSeverity{Text: utils.Ref("Moderate")}, adv.Document.AggregateSeverity)
+	reqs, err := FromCSAF(adv)
+	if assert.NoError(t, err) {
+		assert.Empty(t, reqs[0].CVSSVectorString)
+		assert.Equal(t, "medium", reqs[0].Severity)
+	}
+
+	// A vulnerability without affected package cannot be created
+	adv.Vulnerabilities[0].ProductStatus.KnownAffected = nil
+	_, err = FromCSAF(adv)
+	assert.ErrorContains(t, err, "vulnerability has no known affected products")
+
+	_, err = FromCSAF(&csaf.Advisory{})
+	assert.ErrorContains(t, err, "advisory has no document")
+}
+
+func TestParsePURL(t *testing.T) {
+	tests := []struct {
+		purl    string
+		typ     string
+		name    string
+		version string
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{purl: "pkg:golang/github.com/golang-jwt/jwt/v5@5.2.2", typ: "golang", name: "github.com/golang-jwt/jwt/v5", version: "5.2.2", wantErr: assert.NoError},
+		{purl: "pkg:npm/%40remix-run/router", typ: "npm", name: "@remix-run/router", wantErr: assert.NoError},
+		{purl: "pkg:maven/org.apache.logging.log4j/log4j-core@2.17.1?type=jar", typ: "maven", name: "org.apache.logging.log4j:log4j-core", version: "2.17.1", wantErr: assert.NoError},
+		{purl: "pkg:npm", wantErr: assert.Error},
+		{purl: "npm/react", wantErr: assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.purl, func(t *testing.T) {
+			typ, name, version, err := parsePURL(tt.purl)
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.typ, typ)
+			assert.Equal(t, tt.name, name)
+			assert.Equal(t, tt.version, version)
+		})
+	}
+}
+
+func TestGHSARanges(t *testing.T) {
+	tests := map[string][]string{
+		"vers:golang/<=5.2.1":        {"<= 5.2.1"},
+		"vers:npm/>=7.0|<=7.5.1":     {">= 7.0, <= 7.5.1"},
+		"vers:pypi/1.0":              {"= 1.0"},
+		"vers:npm/*":                 {""},
+		"vers:npm/>=1|<1.5|>=2|<2.3": {">= 1, < 1.5", ">= 2, < 2.3"},
+		"vers:npm/1.0|1.1|>=2":       {"= 1.0", "= 1.1", ">= 2"},
+		"vers:npm/<1|>=2":            {"< 1", ">= 2"},
+	}
+	for vers, want := range tests {
+		got, err := ghsaRanges(vers)
+		assert.NoError(t, err, vers)
+		assert.Equal(t, want, got, vers)
+		if len(want) == 1 && want[0] != "" {
+			assert.Equal(t, vers, versRange(ghsaEcosystem(vers[5:strings.Index(vers, "/")]), want[0]), want)
+		}
+	}
+
+	_, err := ghsaRanges("vers:npm/>=1|!=1.2|<2")
+	assert.ErrorContains(t, err, "excludes version 1.2")
+}
+
+func TestGHSAVulnerabilities(t *testing.T) {
+	var (
+		products = map[gocsaf.ProductID]vexProduct{
+			"range":  {purl: "pkg:npm/foo", versionRange: "vers:npm/>=1.0|<1.5|>=2.0|<=2.3"},
+			"fix1":   {purl: "pkg:npm/foo@1.5"},
+			"fix2":   {purl: "pkg:npm/foo@2.4"},
+			"fix3":   {purl: "pkg:npm/foo@2.5"},
+			"bar":    {purl: "pkg:npm/bar", versionRange: "vers:npm/<3.0"},
+			"barfix": {purl: "pkg:npm/bar@release-3"},
+			"baz":    {purl: "pkg:npm/baz", versionRange: "vers:npm/>=1.0"},
+			"bazfix": {purl: "pkg:npm/baz@0.9"},
+			"not":    {purl: "pkg:npm/qux", versionRange: "vers:npm/!=1.0"},
+		}
+		v = &gocsaf.Vulnerability{ProductStatus: &gocsaf.ProductStatus{
+			KnownAffected: &gocsaf.Products{utils.Ref[gocsaf.ProductID]("range"), utils.Ref[gocsaf.ProductID]("bar"),
+				utils.Ref[gocsaf.ProductID]("baz")},
+			Fixed: &gocsaf.Products{utils.Ref[gocsaf.ProductID]("fix3"), utils.Ref[gocsaf.ProductID]("fix2"),
+				utils.Ref[gocsaf.ProductID]("fix1"), utils.Ref[gocsaf.ProductID]("barfix"),
+				utils.Ref[gocsaf.ProductID]("bazfix")},
+		}}
+	)
+	vulns, err := getGHSAVulnerabilities(v, products)
+	assert.NoError(t, err)
+	assert.Equal(t, []repository.CreateVulnerability{
+		{Package: repository.Package{Ecosystem: "npm", Name: "foo"}, VulnerableVersionRange: ">= 1.0, < 1.5", PatchedVersions: "1.5"},
+		{Package: repository.Package{Ecosystem: "npm", Name: "foo"}, VulnerableVersionRange: ">= 2.0, <= 2.3", PatchedVersions: "2.4"},
+		// The only fixed version of a package with a single range is used even if it cannot be compared
+		{Package: repository.Package{Ecosystem: "npm", Name: "bar"}, VulnerableVersionRange: "< 3.0", PatchedVersions: "release-3"},
+		// A range without upper bound has no patched version
+		{Package: repository.Package{Ecosystem: "npm", Name: "baz"}, VulnerableVersionRange: ">= 1.0"},
+	}, vulns)
+
+	v.ProductStatus.KnownAffected = &gocsaf.Products{utils.Ref[gocsaf.ProductID]("not")}
+	_, err = getGHSAVulnerabilities(v, products)
+	assert.ErrorContains(t, err, "excludes version 1.0")
+}
+
+func TestGHSACreditType(t *testing.T) {
+	assert.Equal(t, "reporter", ghsaCreditType("Reported the vulnerability"))
+	assert.Equal(t, "remediation_developer", ghsaCreditType("Provided the fix"))
+	assert.Equal(t, "finder", ghsaCreditType("FINDER"))
+	assert.Equal(t, "other", ghsaCreditType("Helped a lot"))
+}
@@ -0,0 +1,299 @@
+package internal
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/openvex"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+)
+
+// uuidNamespaceURL is the name space for UUIDs derived from URLs (see RFC 9562, section 6.6).
+var uuidNamespaceURL = [16]byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+// openVEXJustifications are the justifications of OpenVEX, which have the same names as the flags of CSAF.
+var openVEXJustifications = []openvex.Justification{
+	openvex.JustificationComponentNotPresent,
+	openvex.JustificationVulnerableCodeNotPresent,
+	openvex.JustificationVulnerableCodeNotInExecutePath,
+	openvex.JustificationVulnerableCodeCannotBeControlledByAdversary,
+	openvex.JustificationInlineMitigationsAlreadyExist,
+}
+
+// vexProduct is a product of the product tree that can be identified in OpenVEX, i.e. it has a package URL.
+type vexProduct struct {
+	purl string
+	// versionRange is the vers range of products that are version ranges
+	versionRange string
+}
+
+// ToOpenVEX converts the product statuses of a CSAF advisory into an OpenVEX document. Each known affected, fixed,
+// known not affected and under investigation product of a vulnerability becomes a statement for the product, identified
+// by its package URL. OpenVEX has no version ranges and scanners match the package URL only, so products that are
+// version ranges are listed with their versioned package URLs if the range consists of single versions only. The
+// statements of other ranges are dropped, as are products with contradicting statuses. The action statement of
+// affected products is taken from their remediations and the justification of not affected products from their flags
+// or impact threats. The vulnerability is named by its CVE or, if it has none, by its first ID (e.g. the GHSA ID).
+// If statements were dropped, the document is returned together with an error listing them, which can be reported as
+// warnings.
+func ToOpenVEX(adv *csaf.Advisory) (doc *openvex.Document, err error) {
+	var (
+		warnings []error
+	)
+	if adv == nil || adv.Document == nil || adv.Document.Tracking == nil || adv.Document.Tracking.ID == nil {
+		return nil, errors.New("advisory has no tracking ID")
+	}
+	d := adv.Document
+
+	doc = &openvex.Document{
+		Context:     openvex.Context,
+		ID:          openVEXDocumentID(adv),
+		Timestamp:   deref(d.Tracking.InitialReleaseDate),
+		LastUpdated: deref(d.Tracking.CurrentReleaseDate),
+		Version:     1,
+	}
+	if d.Publisher != nil {
+		doc.Author = deref(d.Publisher.Name)
+		if d.Publisher.Category != nil {
+			doc.Role = string(*d.Publisher.Category)
+		}
+	}
+	if d.Tracking.Version != nil {
+		if n, err := strconv.Atoi(string(*d.Tracking.Version)); err == nil && n > 1 {
+			doc.Version = n
+		}
+	}
+	if g := d.Tracking.Generator; g != nil && g.Engine != nil {
+		doc.Tooling = strings.TrimSpace(deref(g.Engine.Name) + " " + deref(g.Engine.Version))
+	}
+
+	products := getVEXProducts(adv.ProductTree)
+	for _, v := range adv.Vulnerabilities {
+		statements, errs := getOpenVEXStatements(v, products)
+		doc.Statements = append(doc.Statements, statements...)
+		warnings = append(warnings, errs...)
+	}
+	if len(doc.Statements) == 0 {
+		warnings = append([]error{errors.New("advisory has no product status of a product with package URL")}, warnings...)
+		return nil, errors.Join(warnings...)
+	}
+	return doc, errors.Join(warnings...)
+}
+
+// openVEXDocumentID derives the IRI of the OpenVEX document from the publisher namespace and tracking ID of the advisory
+// as name-based UUID. The tracking version is left out, so every version of an advisory results in the same ID and
+// only the version of the OpenVEX document increases.
+func openVEXDocumentID(adv *csaf.Advisory) string {
+	var (
+		name = string(*adv.Document.Tracking.ID)
+	)
+	if p := adv.Document.Publisher; p != nil && p.Namespace != nil {
+		name = strings.TrimSuffix(*p.Namespace, "/") + "/" + name
+	}
+
+	h := sha1.Sum(append(uuidNamespaceURL[:], name...))
+	h[6] = h[6]&0x0f | 0x50 // version 5
+	h[8] = h[8]&0x3f | 0x80 // variant RFC 9562
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+}
+
+// getVEXProducts maps the IDs of the products of the product tree that have a package URL to the products.
+func getVEXProducts(pt *csaf.ProductTree) map[gocsaf.ProductID]vexProduct {
+	var (
+		products = make(map[gocsaf.ProductID]vexProduct)
+		walk     func(branches gocsaf.Branches)
+	)
+	add := func(p *gocsaf.FullProductName, versionRange string) {
+		if p == nil || p.ProductID == nil || p.ProductIdentificationHelper == nil ||
+			p.ProductIdentificationHelper.PURL == nil {
+			return
+		}
+		products[*p.ProductID] = vexProduct{purl: string(*p.ProductIdentificationHelper.PURL), versionRange: versionRange}
+	}
+	walk = func(branches gocsaf.Branches) {
+		for _, b := range branches {
+			versionRange := ""
+			if b.Category != nil && *b.Category == gocsaf.CSAFBranchCategoryProductVersionRange {
+				versionRange = deref(b.Name)
+			}
+			add(b.Product, versionRange)
+			walk(b.Branches)
+		}
+	}
+
+	if pt == nil {
+		return products
+	}
+	walk(pt.Branches)
+	if pt.FullProductNames != nil {
+		for _, p := range *pt.FullProductNames {
+			add(p, "")
+		}
+	}
+	return products
+}
+
+// getOpenVEXStatements returns a statement per product of the product status of the vulnerability. Products that are
+// version ranges get a statement per version if the range lists single versions only. The errors list the dropped
+// statements of other ranges and of products with contradicting statuses.
+func getOpenVEXStatements(v *gocsaf.Vulnerability, products map[gocsaf.ProductID]vexProduct) (
+	statements []openvex.Statement, errs []error,
+) {
+	var (
+		statuses = make(map[string]openvex.Status)
+	)
+	if v == nil || v.ProductStatus == nil {
+		return nil, nil
+	}
+	vuln := getOpenVEXVulnerability(v)
+	if vuln.Name == "" {
+		return nil, nil
+	}
+
+	add := func(ids *gocsaf.Products, status openvex.Status) {
+		if ids == nil {
+			return
+		}
+		for _, id := range *ids {
+			p, ok := products[*id]
+			if !ok {
+				continue
+			}
+			purls := []string{p.purl}
+			if p.versionRange != "" {
+				versions, ok := versRangeVersions(p.versionRange)
+				if !ok {
+					errs = append(errs, fmt.Errorf("%s: dropped %s statement of %s, OpenVEX cannot express the versions %s",
+						vuln.Name, status, p.purl, p.versionRange))
+					continue
+				}
+				purls = nil
+				for _, version := range versions {
+					purls = append(purls, purlBase(p.purl)+"@"+purlEscape(version))
+				}
+			}
+
+			for _, purl := range purls {
+				if other, ok := statuses[purl]; ok {
+					if other != status {
+						errs = append(errs, fmt.Errorf("%s: dropped %s statement of %s, which is also %s",
+							vuln.Name, status, purl, other))
+					}
+					continue
+				}
+				statuses[purl] = status
+				s := openvex.Statement{
+					Vulnerability: vuln,
+					Products:      []openvex.Product{{ID: purl, Identifiers: &openvex.Identifiers{PURL: purl}}},
+					Status:        status,
+				}
+				switch status {
+				case openvex.StatusAffected:
+					s.ActionStatement = getActionStatement(v, *id)
+				case openvex.StatusNotAffected:
+					s.Justification, s.ImpactStatement = getNotAffectedReason(v, *id)
+				}
+				statements = append(statements, s)
+			}
+		}
+	}
+
+	add(v.ProductStatus.KnownAffected, openvex.StatusAffected)
+	add(v.ProductStatus.Fixed, openvex.StatusFixed)
+	add(v.ProductStatus.KnownNotAffected, openvex.StatusNotAffected)
+	add(v.ProductStatus.UnderInvestigation, openvex.StatusUnderInvestigation)
+	return
+}
+
+// versRangeVersions returns the versions of a vers range that consists of single versions only, e.g.
+// "vers:npm/1.0.0|1.0.1". It reports false for ranges with comparators or wildcards.
+func versRangeVersions(vers string) (versions []string, ok bool) {
+	_, spec, _ := strings.Cut(strings.TrimPrefix(vers, "vers:"), "/")
+	for _, c := range strings.Split(spec, "|") {
+		c = strings.TrimPrefix(strings.TrimSpace(c), "=")
+		if c == "" || c == "*" || strings.ContainsAny(c[:1], "<>!") {
+			return nil, false
+		}
+		versions = append(versions, c)
+	}
+	return versions, len(versions) > 0
+}
+
+// getOpenVEXVulnerability names the vulnerability by its CVE or, if it has none, by its first ID. The other IDs become
+// aliases.
+func getOpenVEXVulnerability(v *gocsaf.Vulnerability) (vuln openvex.Vulnerability) {
+	var (
+		ids []string
+	)
+	if v.CVE != nil {
+		ids = append(ids, string(*v.CVE))
+	}
+	for _, id := range v.IDs {
+		if id != nil && id.Text != nil && !slices.Contains(ids, *id.Text) {
+			ids = append(ids, *id.Text)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	vuln.Name, vuln.Aliases = ids[0], ids[1:]
+	vuln.Description = deref(v.Title)
+	return
+}
+
+// getActionStatement returns the details of the remediations of the product, e.g. the version to update to.
+func getActionStatement(v *gocsaf.Vulnerability, id gocsaf.ProductID) string {
+	var (
+		details []string
+	)
+	for _, r := range v.Remediations {
+		if r != nil && r.Details != nil && containsProduct(r.ProductIds, id) {
+			details = append(details, *r.Details)
+		}
+	}
+	if len(details) == 0 {
+		return "No remediation is known."
+	}
+	return strings.Join(details, " ")
+}
+
+// getNotAffectedReason returns the justification of a not affected product from its flag or, if it has none, the
+// details of its impact threat as impact statement.
+func getNotAffectedReason(v *gocsaf.Vulnerability, id gocsaf.ProductID) (justification openvex.Justification, impact string) {
+	for _, f := range v.Flags {
+		if f == nil || f.Label == nil || !containsProduct(f.ProductIds, id) {
+			continue
+		}
+		if j := openvex.Justification(*f.Label); slices.Contains(openVEXJustifications, j) {
+			return j, ""
+		}
+	}
+	for _, t := range v.Threats {
+		if t != nil && t.Category != nil && *t.Category == gocsaf.CSAFThreatCategoryImpact &&
+			t.Details != nil && containsProduct(t.ProductIds, id) {
+			return "", *t.Details
+		}
+	}
+	return "", "The product is not affected according to the advisory."
+}
+
+// containsProduct reports whether the product IDs contain id.
+func containsProduct(ids *gocsaf.Products, id gocsaf.ProductID) bool {
+	if ids == nil {
+		return false
+	}
+	return slices.ContainsFunc(*ids, func(p *gocsaf.ProductID) bool { return p != nil && *p == id })
+}
+
+// deref returns the value of s or an empty string if s is nil.
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/csaf-poc/ghsa/internal/utils"
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/global"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	"github.com/csaf-poc/ghsa/models/openvex"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToOpenVEX(t *testing.T) {
+	var (
+		ghsa repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &ghsa)
+	adv, err := ToCSAF(&ghsa, WithProfile(csaf.CategoryVEX))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The vulnerable version ranges cannot be expressed, so their statements are dropped with a warning
+	doc, err := ToOpenVEX(adv)
+	assert.ErrorContains(t, err, "dropped affected statement of pkg:golang/github.com/golang-jwt/jwt/v5, OpenVEX cannot express the versions vers:golang/<=5.2.1")
+	if !assert.NotNil(t, doc) {
+		return
+	}
+	assert.Equal(t, openvex.Context, doc.Context)
+	assert.Regexp(t, regexp.MustCompile(`^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), doc.ID)
+	assert.Equal(t, *adv.Document.Publisher.Name, doc.Author)
+	assert.Equal(t, *adv.Document.Tracking.InitialReleaseDate, doc.Timestamp)
+
+	// Deterministic ID
+	again, _ := ToOpenVEX(adv)
+	assert.Equal(t, doc.ID, again.ID)
+
+	// Stable ID across revisions, only the version increases
+	revised := *adv.Document.Tracking
+	revised.Version = utils.Ref(gocsaf.RevisionNumber("3"))
+	adv.Document.Tracking = &revised
+	revision, _ := ToOpenVEX(adv)
+	if assert.NotNil(t, revision) {
+		assert.Equal(t, doc.ID, revision.ID)
+		assert.Equal(t, 3, revision.Version)
+		assert.Less(t, doc.Version, revision.Version)
+	}
+
+	wantVuln := openvex.Vulnerability{
+		Name:        "CVE-2025-30204",
+		Description: *adv.Vulnerabilities[0].Title,
+		Aliases:     []string{"GHSA-mh63-6h87-95cp"},
+	}
+	byStatus := make(map[openvex.Status][]openvex.Statement)
+	for _, s := range doc.Statements {
+		assert.Equal(t, wantVuln, s.Vulnerability)
+		assert.Empty(t, s.StatusNotes)
+		byStatus[s.Status] = append(byStatus[s.Status], s)
+	}
+	assert.Empty(t, byStatus[openvex.StatusAffected])
+	if assert.Len(t, byStatus[openvex.StatusFixed], 2) {
+		s := byStatus[openvex.StatusFixed][0]
+		assert.Equal(t, "pkg:golang/github.com/golang-jwt/jwt/v5@5.2.2", s.Products[0].ID)
+		assert.Empty(t, s.ActionStatement)
+	}
+}
+
+func TestToOpenVEX_Versions(t *testing.T) {
+	var (
+		g global.Advisory
+	)
+	readJSON(t, "../examples/global_GHSA/GHSA-cpj6-fhp6-mr6j.json", &g)
+	g.Vulnerabilities[0].VulnerableVersionRange = "= 7.5.0, = 7.5.1"
+	g.Vulnerabilities[0].FirstPatchedVersion = "7.5.1"
+	adv, err := GlobalToCSAF(&g)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	doc, err := ToOpenVEX(adv)
+	assert.ErrorContains(t, err, "dropped fixed statement of pkg:npm/react-router@7.5.1, which is also affected")
+	if !assert.NotNil(t, doc) || !assert.Len(t, doc.Statements, 2) {
+		return
+	}
+	for i, version := range []string{"7.5.0", "7.5.1"} {
+		s := doc.Statements[i]
+		assert.Equal(t, openvex.StatusAffected, s.Status)
+		assert.Equal(t, "pkg:npm/react-router@"+version, s.Products[0].ID)
+		assert.Equal(t, "Upgrade react-router to version 7.5.1 or later.", s.ActionStatement)
+	}
+}
+
+func TestVersRangeVersions(t *testing.T) {
+	tests := []struct {
+		vers string
+		want []string
+		ok   bool
+	}{
+		{vers: "vers:npm/1.0.0", want: []string{"1.0.0"}, ok: true},
+		{vers: "vers:npm/1.0.0|=1.0.1", want: []string{"1.0.0", "1.0.1"}, ok: true},
+		{vers: "vers:npm/>=7.0|<=7.5.1"},
+		{vers: "vers:npm/1.0.0|!=1.0.1"},
+		{vers: "vers:npm/*"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.vers, func(t *testing.T) {
+			got, ok := versRangeVersions(tt.vers)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestToOpenVEX_Withdrawn(t *testing.T) {
+	var (
+		ghsa repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &ghsa)
+	ghsa.WithdrawnAt = "2025-04-01T00:00:00Z"
+	adv, err := ToCSAF(&ghsa, WithProfile(csaf.CategoryVEX))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	doc, err := ToOpenVEX(adv)
+	assert.ErrorContains(t, err, "dropped not_affected statement")
+	if !assert.NotNil(t, doc) {
+		return
+	}
+	for _, s := range doc.Statements {
+		assert.Equal(t, openvex.StatusNotAffected, s.Status)
+		assert.Equal(t, openvex.JustificationVulnerableCodeNotPresent, s.Justification)
+		assert.Empty(t, s.ImpactStatement)
+	}
+
+	// CSAF 2.1 documents of withdrawn advisories have no products
+	adv, err = ToCSAF(&ghsa, WithCSAFVersion(csaf.Version21))
+	if assert.NoError(t, err) {
+		_, err = ToOpenVEX(adv)
+		assert.ErrorContains(t, err, "no product status")
+	}
+
+	_, err = ToOpenVEX(&csaf.Advisory{})
+	assert.ErrorContains(t, err, "no tracking ID")
+}
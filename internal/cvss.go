@@ -0,0 +1,42 @@
+package internal
+
+import (
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+	gocvss30 "github.com/pandatix/go-cvss/30"
+	gocvss31 "github.com/pandatix/go-cvss/31"
+	gocvss40 "github.com/pandatix/go-cvss/40"
+)
+
+// cvss3BaseScore computes the base score of a CVSS v3.0 or v3.1 vector string. ok is false if the vector string is
+// invalid. OSV records only carry the vector, so the score has to be computed before it can be published.
+func cvss3BaseScore(vector string) (score float64, ok bool) {
+	version, ok := cvss3Version(vector)
+	if !ok {
+		return 0, false
+	}
+	if version == gocsaf.CVSSVersion30 {
+		cvss, err := gocvss30.ParseVector(vector)
+		if err != nil {
+			return 0, false
+		}
+		return cvss.BaseScore(), true
+	}
+	cvss, err := gocvss31.ParseVector(vector)
+	if err != nil {
+		return 0, false
+	}
+	return cvss.BaseScore(), true
+}
+
+// cvss4BaseScore computes the base score of a CVSS v4.0 vector string (see cvss3BaseScore). ok is false if the vector
+// string is invalid.
+func cvss4BaseScore(vector string) (score float64, ok bool) {
+	if !cvss4Pattern.MatchString(vector) {
+		return 0, false
+	}
+	cvss, err := gocvss40.ParseVector(vector)
+	if err != nil {
+		return 0, false
+	}
+	return cvss.Score(), true
+}
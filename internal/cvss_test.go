@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCVSS3BaseScore(t *testing.T) {
+	tests := []struct {
+		name      string
+		vector    string
+		wantScore float64
+		wantOk    bool
+	}{
+		{name: "High", vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:L/A:H", wantScore: 8.2, wantOk: true},
+		{name: "Critical", vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", wantScore: 9.8, wantOk: true},
+		{name: "Changed scope", vector: "CVSS:3.1/AV:N/AC:L/PR:L/UI:R/S:C/C:L/I:L/A:N", wantScore: 5.4, wantOk: true},
+		{name: "Changed scope capped", vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", wantScore: 10, wantOk: true},
+		{name: "Low", vector: "CVSS:3.1/AV:P/AC:H/PR:H/UI:R/S:U/C:L/I:N/A:N", wantScore: 1.6, wantOk: true},
+		{name: "No impact", vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", wantScore: 0, wantOk: true},
+		{name: "CVSS 3.0", vector: "CVSS:3.0/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", wantScore: 7.8, wantOk: true},
+		{name: "Invalid", vector: "CVSS:3.1/AV:X", wantOk: false},
+		{name: "CVSS 4.0", vector: "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, ok := cvss3BaseScore(tt.vector)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantScore, score)
+		})
+	}
+}
+
+func TestCVSS4BaseScore(t *testing.T) {
+	tests := []struct {
+		name      string
+		vector    string
+		wantScore float64
+		wantOk    bool
+	}{
+		{name: "Critical", vector: "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", wantScore: 9.3, wantOk: true},
+		{name: "No impact", vector: "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:N/VI:N/VA:N/SC:N/SI:N/SA:N", wantScore: 0, wantOk: true},
+		{name: "Invalid", vector: "CVSS:4.0/AV:X", wantOk: false},
+		{name: "CVSS 3.1", vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, ok := cvss4BaseScore(tt.vector)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantScore, score)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package internal
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/csaf-poc/ghsa/internal/utils"
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+)
+
+// cweCatalogueVersion is the version of the CWE List the embedded catalogue data/cwe.csv was taken from.
+const cweCatalogueVersion = "4.16"
+
+//go:embed data/cwe.csv
+var cweCatalogueCSV []byte
+
+// cweCatalogue maps the CWE IDs of the embedded catalogue (e.g. CWE-79) to their names.
+var cweCatalogue = mustLoadCWECatalogue(cweCatalogueCSV)
+
+// loadCWECatalogue reads a CWE catalogue in the CSV format of the MITRE downloads. Only the columns "CWE-ID" (the
+// number of the CWE) and "Name" are used. Lines starting with # are comments.
+func loadCWECatalogue(r io.Reader) (catalogue map[string]string, err error) {
+	var (
+		idColumn, nameColumn int
+	)
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		err = fmt.Errorf("could not read CWE catalogue header: %v", err)
+		return nil, err
+	}
+	idColumn, nameColumn = slices.Index(header, "CWE-ID"), slices.Index(header, "Name")
+	if idColumn < 0 || nameColumn < 0 {
+		err = errors.New("CWE catalogue misses the columns CWE-ID and Name")
+		return nil, err
+	}
+
+	catalogue = make(map[string]string)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			err = fmt.Errorf("could not read CWE catalogue: %v", err)
+			return nil, err
+		}
+		if len(record) <= max(idColumn, nameColumn) {
+			line, _ := reader.FieldPos(0)
+			err = fmt.Errorf("line %d of CWE catalogue misses columns", line)
+			return nil, err
+		}
+		id := "CWE-" + strings.TrimPrefix(record[idColumn], "CWE-")
+		if !cwePattern.MatchString(id) || record[nameColumn] == "" {
+			line, _ := reader.FieldPos(0)
+			err = fmt.Errorf("line %d of CWE catalogue has an invalid entry", line)
+			return nil, err
+		}
+		catalogue[id] = record[nameColumn]
+	}
+	return catalogue, nil
+}
+
+// mustLoadCWECatalogue is like loadCWECatalogue but panics if the catalogue cannot be read.
+func mustLoadCWECatalogue(data []byte) map[string]string {
+	catalogue, err := loadCWECatalogue(bytes.NewReader(data))
+	if err != nil {
+		panic(err)
+	}
+	return catalogue
+}
+
+// weakness is a CWE of an advisory.
+type weakness struct {
+	id   string
+	name string
+	// known reports whether the CWE is part of the catalogue, i.e. its name is the official one
+	known bool
+}
+
+// getWeaknesses returns the CWEs of the advisory in the order listed by GitHub. Names are checked against the CWE
+// catalogue: the official name replaces the name given by GitHub, which may be outdated or missing (e.g. for advisories
+// imported from OSV). Names that differ from the catalogue and CWEs that are not part of it are returned as error, which
+// can be reported as warnings; the latter keep the name given by GitHub, if any. Invalid and duplicate IDs are skipped.
+func getWeaknesses(adv *repository.Advisory) (weaknesses []weakness, err error) {
+	var (
+		seen = make(map[string]bool)
+		errs []error
+	)
+	for _, cwe := range adv.CWEs {
+		if !cwePattern.MatchString(cwe.CWEID) || seen[cwe.CWEID] {
+			continue
+		}
+		w := weakness{id: cwe.CWEID, name: strings.TrimSpace(cwe.Name)}
+		if name, ok := cweCatalogue[cwe.CWEID]; ok {
+			if w.name != "" && !strings.EqualFold(w.name, name) {
+				errs = append(errs, fmt.Errorf("name '%s' of %s differs from the CWE List %s, using '%s'", cwe.Name,
+					cwe.CWEID, cweCatalogueVersion, name))
+			}
+			w.name, w.known = name, true
+		} else {
+			errs = append(errs, fmt.Errorf("%s is not part of the CWE List %s", cwe.CWEID, cweCatalogueVersion))
+		}
+		seen[cwe.CWEID] = true
+		weaknesses = append(weaknesses, w)
+	}
+	return weaknesses, errors.Join(errs...)
+}
+
+// primaryWeakness returns the index of the primary CWE for CSAF 2.0, which allows only a single CWE per vulnerability.
+// The policy is: the first CWE listed by GitHub that has a name, since CSAF requires it. Returns -1 if there is none.
+func primaryWeakness(weaknesses []weakness) int {
+	return slices.IndexFunc(weaknesses, func(w weakness) bool { return w.name != "" })
+}
+
+// getCWE returns the primary CWE of the advisory (see primaryWeakness) or nil if it has none. The other CWEs are
+// returned as rest, see getWeaknessNotes.
+func getCWE(weaknesses []weakness) (cwe *gocsaf.CWE, rest []weakness) {
+	i := primaryWeakness(weaknesses)
+	if i < 0 {
+		return nil, weaknesses
+	}
+	cwe = &gocsaf.CWE{
+		ID:   utils.Ref(gocsaf.WeaknessID(weaknesses[i].id)),
+		Name: utils.Ref(weaknesses[i].name),
+	}
+	return cwe, slices.Delete(slices.Clone(weaknesses), i, i+1)
+}
+
+// getCWEs returns the CWEs of the advisory for the cwes array of CSAF 2.1, which requires the version of the CWE List
+// the name was taken from. Only CWEs of the catalogue have one; the others are returned as rest, see getWeaknessNotes.
+func getCWEs(weaknesses []weakness) (cwes []*csaf.CWE21, rest []weakness) {
+	for _, w := range weaknesses {
+		if !w.known {
+			rest = append(rest, w)
+			continue
+		}
+		cwes = append(cwes, &csaf.CWE21{ID: w.id, Name: w.name, Version: cweCatalogueVersion})
+	}
+	return
+}
+
+// getWeaknessNotes returns a note listing the CWEs that could not be given as CWE of the vulnerability (see getCWE and
+// getCWEs), so the documents do not lose them. Returns nil if there are none.
+func getWeaknessNotes(rest []weakness) gocsaf.Notes {
+	var (
+		lines []string
+	)
+	for _, w := range rest {
+		line := "- " + w.id
+		if w.name != "" {
+			line += ": " + w.name
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return gocsaf.Notes{{
+		NoteCategory: utils.Ref(gocsaf.CSAFNoteCategoryOther),
+		Title:        utils.Ref("Additional weaknesses"),
+		Text:         utils.Ref("The vulnerability is also classified as:\n\n" + strings.Join(lines, "\n")),
+	}}
+}
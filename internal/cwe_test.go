@@ -0,0 +1,171 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadCWECatalogue(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    map[string]string
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name: "MITRE download",
+			csv: "CWE-ID,Name,Weakness Abstraction,Status\n" +
+				"79,Improper Neutralization of Input During Web Page Generation ('Cross-site Scripting'),Base,Stable\n" +
+				"\"1321\",\"Improperly Controlled Modification of Object Prototype Attributes ('Prototype Pollution')\",Variant,Incomplete\n",
+			want: map[string]string{
+				"CWE-79":   "Improper Neutralization of Input During Web Page Generation ('Cross-site Scripting')",
+				"CWE-1321": "Improperly Controlled Modification of Object Prototype Attributes ('Prototype Pollution')",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "Comments",
+			csv:     "# comment\nName,CWE-ID\nUse After Free,CWE-416\n",
+			want:    map[string]string{"CWE-416": "Use After Free"},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Err: Missing column",
+			csv:  "ID,Name\n79,Cross-site Scripting\n",
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorContains(t, err, "misses the columns")
+			},
+		},
+		{
+			name: "Err: Invalid ID",
+			csv:  "CWE-ID,Name\n79,Cross-site Scripting\nXSS,Cross-site Scripting\n",
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorContains(t, err, "line 3 of CWE catalogue has an invalid entry")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadCWECatalogue(strings.NewReader(tt.csv))
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCWECatalogue(t *testing.T) {
+	assert.NotEmpty(t, cweCatalogue)
+	assert.Equal(t, "Asymmetric Resource Consumption (Amplification)", cweCatalogue["CWE-405"])
+}
+
+func TestGetWeaknesses(t *testing.T) {
+	adv := &repository.Advisory{CWEs: []repository.CWE{
+		{CWEID: "CWE-99998"},
+		{CWEID: "CWE-99999", Name: "Unknown weakness"},
+		{CWEID: "CWE-400", Name: "Resource Exhaustion"},
+		{CWEID: "CWE-400", Name: "Uncontrolled Resource Consumption"},
+		{CWEID: "NVD-CWE-noinfo", Name: "Insufficient Information"},
+		{CWEID: "CWE-405"},
+	}}
+
+	weaknesses, err := getWeaknesses(adv)
+	assert.Equal(t, []weakness{
+		{id: "CWE-99998"},
+		{id: "CWE-99999", name: "Unknown weakness"},
+		{id: "CWE-400", name: "Uncontrolled Resource Consumption", known: true},
+		{id: "CWE-405", name: "Asymmetric Resource Consumption (Amplification)", known: true},
+	}, weaknesses)
+	assert.EqualError(t, err, "CWE-99998 is not part of the CWE List 4.16\n"+
+		"CWE-99999 is not part of the CWE List 4.16\n"+
+		"name 'Resource Exhaustion' of CWE-400 differs from the CWE List 4.16, using 'Uncontrolled Resource Consumption'")
+
+	// CSAF 2.0: the first CWE listed by GitHub with a name is the primary one, the others are noted
+	cwe, rest := getCWE(weaknesses)
+	if assert.NotNil(t, cwe) {
+		assert.Equal(t, "CWE-99999", string(*cwe.ID))
+	}
+	notes := getWeaknessNotes(rest)
+	if assert.Len(t, notes, 1) {
+		assert.Equal(t, "The vulnerability is also classified as:\n\n"+
+			"- CWE-99998\n"+
+			"- CWE-400: Uncontrolled Resource Consumption\n"+
+			"- CWE-405: Asymmetric Resource Consumption (Amplification)", *notes[0].Text)
+	}
+
+	// CSAF 2.1: the CWEs of the CWE List, the others are noted
+	cwes, rest := getCWEs(weaknesses)
+	assert.Equal(t, []*csaf.CWE21{
+		{ID: "CWE-400", Name: "Uncontrolled Resource Consumption", Version: cweCatalogueVersion},
+		{ID: "CWE-405", Name: "Asymmetric Resource Consumption (Amplification)", Version: cweCatalogueVersion},
+	}, cwes)
+	notes = getWeaknessNotes(rest)
+	if assert.Len(t, notes, 1) {
+		assert.Equal(t, "The vulnerability is also classified as:\n\n"+
+			"- CWE-99998\n"+
+			"- CWE-99999: Unknown weakness", *notes[0].Text)
+	}
+
+	// Matching names are no warning
+	_, err = getWeaknesses(&repository.Advisory{CWEs: []repository.CWE{
+		{CWEID: "CWE-405", Name: "Asymmetric Resource Consumption (Amplification)"},
+	}})
+	assert.NoError(t, err)
+
+	// Without names, there is no primary CWE
+	cwe, rest = getCWE(weaknesses[:1])
+	assert.Nil(t, cwe)
+	assert.Equal(t, weaknesses[:1], rest)
+	assert.Nil(t, getWeaknessNotes(nil))
+}
+
+func TestToCSAF_CSAF21(t *testing.T) {
+	var (
+		adv repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &adv)
+	adv.CWEs = append(adv.CWEs, repository.CWE{CWEID: "CWE-770"})
+
+	got, err := ToCSAF(&adv, WithCSAFVersion(csaf.Version21))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assertValidCSAF(t, got)
+
+	doc, err := csaf.ToDocument(got)
+	if !assert.NoError(t, err) {
+		return
+	}
+	root := doc.(map[string]any)
+	assert.Equal(t, "https://docs.oasis-open.org/csaf/csaf/v2.1/schema/csaf.json", root["$schema"])
+	assert.Equal(t, "2.1", root["document"].(map[string]any)["csaf_version"])
+
+	vuln := root["vulnerabilities"].([]any)[0].(map[string]any)
+	assert.NotContains(t, vuln, "cwe")
+	assert.Equal(t, []any{
+		map[string]any{"id": "CWE-405", "name": "Asymmetric Resource Consumption (Amplification)", "version": "4.16"},
+		map[string]any{"id": "CWE-770", "name": "Allocation of Resources Without Limits or Throttling", "version": "4.16"},
+	}, vuln["cwes"])
+	assert.NotContains(t, vuln, "notes")
+
+	// CWEs that are not part of the CWE List are reported and noted instead, e.g. of advisories imported from OSV
+	adv.CWEs = append(adv.CWEs, repository.CWE{CWEID: "CWE-99999"})
+	_, err = ToCSAF(&adv, WithCSAFVersion(csaf.Version21))
+	assert.ErrorContains(t, err, "CWE-99999 is not part of the CWE List 4.16")
+	got, err = ToCSAF(&adv, WithCSAFVersion(csaf.Version21), WithLenient())
+	assert.ErrorContains(t, err, "CWE-99999 is not part of the CWE List 4.16")
+	if !assert.NotNil(t, got) {
+		return
+	}
+	assertValidCSAF(t, got)
+	assert.Len(t, got.CSAF21.Vulnerabilities[0].CWEs, 2)
+	if assert.Len(t, got.Vulnerabilities[0].Notes, 1) {
+		assert.Equal(t, "The vulnerability is also classified as:\n\n- CWE-99999", *got.Vulnerabilities[0].Notes[0].Text)
+	}
+
+	_, err = ToCSAF(&adv, WithCSAFVersion("3.0"))
+	assert.ErrorContains(t, err, "unsupported CSAF version '3.0'")
+}
@@ -0,0 +1,214 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/csaf-poc/ghsa/models/cyclonedx"
+)
+
+// MergeCycloneDX adds the vulnerabilities (see CycloneDXVulnerability) to the CycloneDX SBOM sbom (version 1.5 or 1.6)
+// and returns the merged SBOM. The affects of the vulnerabilities are linked to the SBOM components with the same
+// package URL, ignoring version, qualifiers, subpath and percent-encoding. Components without bom-ref get their package
+// URL as bom-ref. Packages that are not part of the SBOM are dropped from the affects, and vulnerabilities without
+// affected component are skipped. The versions of the components are matched against the vulnerable version ranges:
+// if no component is in a range, the exploitable analysis is replaced by resolved if all components use a patched
+// version and dropped otherwise. Vulnerabilities already listed in the SBOM are replaced. All other content of the SBOM
+// is kept as is.
+func MergeCycloneDX(sbom []byte, vulns []*cyclonedx.Vulnerability) (merged []byte, err error) {
+	var (
+		doc        map[string]any
+		components = make(map[string][]sbomComponent)
+	)
+	if err = json.Unmarshal(sbom, &doc); err != nil {
+		err = fmt.Errorf("could not unmarshal SBOM: %v", err)
+		return nil, err
+	}
+	if doc["bomFormat"] != cyclonedx.BOMFormat {
+		return nil, fmt.Errorf("SBOM is not a CycloneDX BOM")
+	}
+	specVersion, _ := doc["specVersion"].(string)
+	if err = checkCycloneDXVersion(specVersion); err != nil {
+		return nil, err
+	}
+
+	if metadata, ok := doc["metadata"].(map[string]any); ok && metadata["component"] != nil {
+		collectComponents([]any{metadata["component"]}, components)
+	}
+	list, _ := doc["components"].([]any)
+	collectComponents(list, components)
+
+	existing, _ := doc["vulnerabilities"].([]any)
+	for _, vuln := range vulns {
+		linked := linkAffects(vuln, components)
+		if linked == nil {
+			continue
+		}
+		v, err := toJSONValue(linked)
+		if err != nil {
+			return nil, err
+		}
+		existing = replaceVulnerability(existing, linked.ID, v)
+	}
+	if len(existing) > 0 {
+		doc["vulnerabilities"] = existing
+	}
+
+	merged, err = json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		err = fmt.Errorf("could not marshal SBOM: %v", err)
+		return nil, err
+	}
+	return merged, nil
+}
+
+// sbomComponent is a component of an SBOM with package URL.
+type sbomComponent struct {
+	ref string
+	// version is the version of the package URL or, if it has none, of the component
+	version string
+}
+
+// collectComponents maps the normalized package URLs (see purlKey) of the generic JSON components and of their nested
+// components to the components.
+func collectComponents(list []any, components map[string][]sbomComponent) {
+	for _, c := range list {
+		component, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		if purl, _ := component["purl"].(string); purl != "" {
+			ref, _ := component["bom-ref"].(string)
+			if ref == "" {
+				ref = purl
+				component["bom-ref"] = ref
+			}
+			_, _, version, _ := parsePURL(purl)
+			if version == "" {
+				version, _ = component["version"].(string)
+			}
+			key := purlKey(purl)
+			components[key] = append(components[key], sbomComponent{ref: ref, version: version})
+		}
+		nested, _ := component["components"].([]any)
+		collectComponents(nested, components)
+	}
+}
+
+// purlBase strips the version, qualifiers and subpath of a package URL.
+func purlBase(purl string) string {
+	purl, _, _ = strings.Cut(purl, "#")
+	purl, _, _ = strings.Cut(purl, "?")
+	if i := strings.LastIndex(purl, "@"); i > strings.LastIndex(purl, "/") {
+		purl = purl[:i]
+	}
+	return purl
+}
+
+// purlKey normalizes a package URL for comparison: the version, qualifiers and subpath are stripped and the segments
+// are percent-decoded, so "pkg:npm/%40scope/name@1.0.0" and "pkg:npm/@scope/name" have the same key.
+func purlKey(purl string) string {
+	base := purlBase(purl)
+	segments := strings.Split(base, "/")
+	for i, segment := range segments {
+		if unescaped, err := url.PathUnescape(segment); err == nil {
+			segments[i] = unescaped
+		}
+	}
+	segments[0] = strings.ToLower(segments[0])
+	return strings.Join(segments, "/")
+}
+
+// linkAffects returns a copy of the vulnerability whose affects reference the SBOM components of the affected packages.
+// If no component is in a vulnerable version range, an exploitable analysis becomes resolved if all components use a
+// patched version and is dropped otherwise. Returns nil if no affected package is part of the SBOM.
+func linkAffects(vuln *cyclonedx.Vulnerability, components map[string][]sbomComponent) *cyclonedx.Vulnerability {
+	var (
+		affects                    []cyclonedx.Affect
+		affected, patched, unknown int
+	)
+	for _, a := range vuln.Affects {
+		for _, c := range components[purlKey(a.Ref)] {
+			switch componentStatus(c.version, a.Versions) {
+			case cyclonedx.AffectStatusAffected:
+				affected++
+			case cyclonedx.AffectStatusUnaffected:
+				patched++
+			default:
+				unknown++
+			}
+			affects = append(affects, cyclonedx.Affect{Ref: c.ref, Versions: a.Versions})
+		}
+	}
+	if len(affects) == 0 {
+		return nil
+	}
+	linked := *vuln
+	linked.Affects = affects
+	if affected == 0 && linked.Analysis != nil && linked.Analysis.State == cyclonedx.AnalysisStateExploitable {
+		linked.Analysis = nil
+		if unknown == 0 {
+			linked.Analysis = &cyclonedx.Analysis{
+				State:  cyclonedx.AnalysisStateResolved,
+				Detail: "The components use a patched version.",
+			}
+		}
+	}
+	return &linked
+}
+
+// componentStatus returns the status of a component version according to the versions of an affect: affected if it is
+// in a vulnerable version range and unaffected if it is not and is a patched version or later. Versions outside the
+// ranges below every patched version are unknown, since GitHub makes no statement about them, as are versions that
+// cannot be compared.
+func componentStatus(version string, versions []cyclonedx.AffectVersion) cyclonedx.AffectStatus {
+	var (
+		comparable = true
+		later      bool
+	)
+	if version == "" {
+		return cyclonedx.AffectStatusUnknown
+	}
+	for _, v := range versions {
+		switch {
+		case v.Range != "" && v.Status == cyclonedx.AffectStatusAffected:
+			in, ok := versContains(v.Range, version)
+			if ok && in {
+				return cyclonedx.AffectStatusAffected
+			}
+			comparable = comparable && ok
+		case v.Version != "" && v.Status == cyclonedx.AffectStatusUnaffected:
+			c, ok := compareVersions(version, v.Version)
+			later = later || (ok && c >= 0)
+		}
+	}
+	if comparable && later {
+		return cyclonedx.AffectStatusUnaffected
+	}
+	return cyclonedx.AffectStatusUnknown
+}
+
+// replaceVulnerability replaces the vulnerability with the given ID in the generic JSON array vulns or appends v if
+// there is none.
+func replaceVulnerability(vulns []any, id string, v any) []any {
+	for i, existing := range vulns {
+		if obj, ok := existing.(map[string]any); ok && obj["id"] == id {
+			vulns[i] = v
+			return vulns
+		}
+	}
+	return append(vulns, v)
+}
+
+// toJSONValue converts v into its generic JSON representation.
+func toJSONValue(v any) (value any, err error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		err = fmt.Errorf("could not marshal vulnerability: %v", err)
+		return nil, err
+	}
+	err = json.Unmarshal(b, &value)
+	return value, err
+}
@@ -0,0 +1,216 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/csaf-poc/ghsa/internal/utils"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+)
+
+// TLP labels of TLP 2.0, which are used by CSAF 2.1. CSAF 2.0 uses the labels of TLP 1.0 (gocsaf.TLPLabelWhite etc.).
+const (
+	TLPLabelClear       = "CLEAR"
+	TLPLabelAmberStrict = "AMBER+STRICT"
+)
+
+// tlpLabels lists all supported TLP labels of TLP 1.0 and TLP 2.0.
+var tlpLabels = []string{
+	gocsaf.TLPLabelWhite, TLPLabelClear, gocsaf.TLPLabelGreen, gocsaf.TLPLabelAmber, TLPLabelAmberStrict, gocsaf.TLPLabelRed,
+}
+
+// Distribution configures the distribution of CSAF documents (see CSAF 2.0 section 3.2.1.5). Empty fields are not
+// configured and inherit the value of a less specific configuration.
+type Distribution struct {
+	// TLP is the TLP label: WHITE, GREEN, AMBER or RED of TLP 1.0 or CLEAR, GREEN, AMBER, AMBER+STRICT or RED of TLP 2.0
+	TLP string `json:"tlp,omitempty"`
+	// URL is the URL of the TLP version used
+	URL string `json:"url,omitempty"`
+	// Text is the distribution text
+	Text string `json:"text,omitempty"`
+}
+
+// merge overrides the fields of d with the configured fields of other.
+func (d *Distribution) merge(other Distribution) {
+	if other.TLP != "" {
+		d.TLP = other.TLP
+	}
+	if other.URL != "" {
+		d.URL = other.URL
+	}
+	if other.Text != "" {
+		d.Text = other.Text
+	}
+}
+
+// Validate checks the TLP label and URL.
+func (d *Distribution) Validate() error {
+	var (
+		errs []error
+	)
+	if d.TLP != "" && !slices.Contains(tlpLabels, d.TLP) {
+		errs = append(errs, fmt.Errorf("invalid TLP label '%s'", d.TLP))
+	}
+	if d.URL != "" && !isURL(d.URL) {
+		errs = append(errs, fmt.Errorf("invalid TLP URL '%s'", d.URL))
+	}
+	return errors.Join(errs...)
+}
+
+// DistributionConfig configures the distribution globally, per source and per advisory state. More specific
+// configurations override less specific ones: global, organization, repository, state.
+//
+// Advisories that are not published keep the restrictive TLP label of their state (see advisoryStates) unless it is
+// configured for the state explicitly, so a global or source TLP label never discloses unpublished advisories.
+type DistributionConfig struct {
+	// Distribution is the global configuration
+	Distribution
+	// Sources configures the distribution per organization or user ("OWNER") and per repository ("OWNER/REPO")
+	Sources map[string]Distribution `json:"sources,omitempty"`
+	// States configures the distribution per advisory state (published, draft, triage, closed)
+	States map[string]Distribution `json:"states,omitempty"`
+}
+
+// Validate checks all configured distributions.
+func (cfg *DistributionConfig) Validate() error {
+	var (
+		errs []error
+	)
+	if err := cfg.Distribution.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	for source, d := range cfg.Sources {
+		owner, repo, hasRepo := strings.Cut(source, "/")
+		if owner == "" || (hasRepo && (repo == "" || strings.Contains(repo, "/"))) {
+			errs = append(errs, fmt.Errorf("invalid source '%s': expected OWNER or OWNER/REPO", source))
+		}
+		if err := d.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("source '%s': %w", source, err))
+		}
+	}
+	for state, d := range cfg.States {
+		if _, ok := advisoryStates[state]; !ok || state == "" {
+			errs = append(errs, fmt.Errorf("unknown state '%s'", state))
+		}
+		if err := d.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("state '%s': %w", state, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithDistribution configures the TLP label, TLP URL and distribution text of the CSAF documents. By default, published
+// advisories are TLP:WHITE and other advisories get the restrictive TLP label of their state.
+func WithDistribution(cfg DistributionConfig) ConvertOption {
+	return func(c *converter) {
+		c.distribution = &cfg
+	}
+}
+
+// resolveDistribution returns the distribution of the advisory according to the configuration.
+func (c *converter) resolveDistribution(adv *repository.Advisory) (d Distribution) {
+	state, _ := getAdvisoryState(adv)
+	cfg := c.distribution
+	if cfg == nil {
+		cfg = &DistributionConfig{}
+	}
+
+	d.merge(cfg.Distribution)
+	if owner, repo := c.getSource(adv); owner != "" {
+		d.merge(cfg.Sources[owner])
+		d.merge(cfg.Sources[owner+"/"+repo])
+	}
+	if !isPublished(adv) {
+		d.TLP = string(state.tlp)
+	}
+	d.merge(cfg.States[adv.State])
+	if d.TLP == "" {
+		d.TLP = string(state.tlp)
+	}
+	return d
+}
+
+// getDistribution returns the distribution of the CSAF document. See WithDistribution.
+func (c *converter) getDistribution(adv *repository.Advisory) (dist *gocsaf.DocumentDistribution, err error) {
+	d := c.resolveDistribution(adv)
+
+	label, err := csaf20TLPLabel(d.TLP)
+	if err != nil {
+		// Fall back to the most restrictive label
+		label = gocsaf.TLPLabelRed
+		if c.csaf21() {
+			// CSAF 2.1 supports all labels of TLP 2.0, see getDocument21
+			err = nil
+		}
+	}
+	dist = &gocsaf.DocumentDistribution{
+		TLP: &gocsaf.TLP{
+			DocumentTLPLabel: utils.Ref(label),
+		},
+	}
+	if d.URL != "" {
+		dist.TLP.URL = utils.Ref(d.URL)
+	}
+	if d.Text != "" {
+		dist.Text = utils.Ref(d.Text)
+	}
+	return dist, err
+}
+
+// csaf20TLPLabel converts a TLP label into a TLP 1.0 label as required by CSAF 2.0. TLP:CLEAR is the same as TLP:WHITE,
+// but TLP:AMBER+STRICT has no equivalent.
+func csaf20TLPLabel(label string) (gocsaf.TLPLabel, error) {
+	switch label {
+	case TLPLabelClear:
+		return gocsaf.TLPLabelWhite, nil
+	case gocsaf.TLPLabelWhite, gocsaf.TLPLabelGreen, gocsaf.TLPLabelAmber, gocsaf.TLPLabelRed:
+		return gocsaf.TLPLabel(label), nil
+	default:
+		return "", fmt.Errorf("TLP label '%s' is not supported by CSAF 2.0", label)
+	}
+}
+
+// getSource returns owner and repository of an advisory. For repository advisories, they are derived from the URL of the
+// advisory (WEB_URL/OWNER/REPO/security/advisories/GHSA_ID), for global advisories from the URL of the repository
+// advisory they were reviewed from or, if there is none, from their source code location (WEB_URL/OWNER/REPO). Both
+// are empty if the source is unknown.
+func (c *converter) getSource(adv *repository.Advisory) (owner string, repo string) {
+	if owner, repo = repositoryFromURL(adv.HTMLURL, true); owner != "" || c.global == nil {
+		return owner, repo
+	}
+	if owner, repo = repositoryFromURL(c.global.RepositoryAdvisoryURL, true); owner != "" {
+		return owner, repo
+	}
+	return repositoryFromURL(c.global.SourceCodeLocation, false)
+}
+
+// repositoryFromURL returns owner and repository of a URL of a repository advisory if advisory is set, either of its
+// page (WEB_URL/OWNER/REPO/security/advisories/GHSA_ID) or of the API (API_URL/repos/OWNER/REPO/security-advisories/
+// GHSA_ID), or else of a repository on GitHub (https://github.com/OWNER/REPO, optionally followed by a path, e.g.
+// /tree/main). Both are empty if the URL has another form.
+func repositoryFromURL(rawURL string, advisory bool) (owner string, repo string) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "", ""
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	n := len(parts)
+	if advisory {
+		switch {
+		case n >= 5 && parts[n-3] == "security" && parts[n-2] == "advisories":
+			return parts[n-5], parts[n-4]
+		case n >= 5 && parts[n-5] == "repos" && parts[n-2] == "security-advisories":
+			return parts[n-4], parts[n-3]
+		}
+		return "", ""
+	}
+	// Sources are GitHub owners, so repositories hosted elsewhere have no source
+	if !strings.EqualFold(u.Host, "github.com") || n < 2 || parts[0] == "" || parts[1] == "" {
+		return "", ""
+	}
+	return parts[0], strings.TrimSuffix(parts[1], ".git")
+}
@@ -0,0 +1,180 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/csaf-poc/ghsa/models/ghsa/global"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConverter_getDistribution(t *testing.T) {
+	var (
+		cfg = DistributionConfig{
+			Distribution: Distribution{TLP: "GREEN", URL: "https://www.first.org/tlp/v1/", Text: "global"},
+			Sources: map[string]Distribution{
+				"golang-jwt":     {Text: "org"},
+				"golang-jwt/jwt": {TLP: "CLEAR"},
+				"example/strict": {TLP: TLPLabelAmberStrict},
+			},
+			States: map[string]Distribution{
+				"triage": {TLP: "AMBER", Text: "triage"},
+			},
+		}
+		published = &repository.Advisory{
+			State:   "published",
+			HTMLURL: "https://github.com/golang-jwt/jwt/security/advisories/GHSA-mh63-6h87-95cp",
+		}
+	)
+
+	tests := []struct {
+		name     string
+		opts     []ConvertOption
+		adv      *repository.Advisory
+		wantTLP  gocsaf.TLPLabel
+		wantText string
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "Default published",
+			adv:     published,
+			wantTLP: gocsaf.TLPLabelWhite,
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "Default draft",
+			adv:     &repository.Advisory{State: "draft"},
+			wantTLP: gocsaf.TLPLabelAmber,
+			wantErr: assert.NoError,
+		},
+		{
+			name:     "Repository overrides organization and global",
+			opts:     []ConvertOption{WithDistribution(cfg)},
+			adv:      published,
+			wantTLP:  gocsaf.TLPLabelWhite,
+			wantText: "org",
+			wantErr:  assert.NoError,
+		},
+		{
+			name:     "Global for other sources",
+			opts:     []ConvertOption{WithDistribution(cfg)},
+			adv:      &repository.Advisory{State: "published", HTMLURL: "https://github.com/advisories/GHSA-cpj6-fhp6-mr6j"},
+			wantTLP:  gocsaf.TLPLabelGreen,
+			wantText: "global",
+			wantErr:  assert.NoError,
+		},
+		{
+			name: "Source of global advisories",
+			opts: []ConvertOption{WithDistribution(cfg), withGlobal(&global.Advisory{
+				SourceCodeLocation: "https://github.com/golang-jwt/jwt",
+			})},
+			adv:      &repository.Advisory{State: "published", HTMLURL: "https://github.com/advisories/GHSA-mh63-6h87-95cp"},
+			wantTLP:  gocsaf.TLPLabelWhite,
+			wantText: "org",
+			wantErr:  assert.NoError,
+		},
+		{
+			name:     "Drafts keep the restrictive label of their state",
+			opts:     []ConvertOption{WithDistribution(cfg)},
+			adv:      &repository.Advisory{State: "draft", HTMLURL: published.HTMLURL},
+			wantTLP:  gocsaf.TLPLabelAmber,
+			wantText: "org",
+			wantErr:  assert.NoError,
+		},
+		{
+			name:     "State configuration",
+			opts:     []ConvertOption{WithDistribution(cfg)},
+			adv:      &repository.Advisory{State: "triage", HTMLURL: published.HTMLURL},
+			wantTLP:  gocsaf.TLPLabelAmber,
+			wantText: "triage",
+			wantErr:  assert.NoError,
+		},
+		{
+			name:     "Err: AMBER+STRICT in CSAF 2.0",
+			opts:     []ConvertOption{WithDistribution(cfg)},
+			adv:      &repository.Advisory{State: "published", HTMLURL: "https://github.com/example/strict/security/advisories/GHSA-xxxx-xxxx-xxxx"},
+			wantTLP:  gocsaf.TLPLabelRed,
+			wantText: "global",
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorContains(t, err, "not supported by CSAF 2.0")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newConverter(tt.opts...).getDistribution(tt.adv)
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.wantTLP, *got.TLP.DocumentTLPLabel)
+			if tt.wantText == "" {
+				assert.Nil(t, got.Text)
+			} else if assert.NotNil(t, got.Text) {
+				assert.Equal(t, tt.wantText, *got.Text)
+			}
+		})
+	}
+}
+
+func TestConverter_getSource(t *testing.T) {
+	tests := []struct {
+		name      string
+		adv       *repository.Advisory
+		global    *global.Advisory
+		wantOwner string
+		wantRepo  string
+	}{
+		{
+			name:      "Repository advisory",
+			adv:       &repository.Advisory{HTMLURL: "https://github.com/golang-jwt/jwt/security/advisories/GHSA-mh63-6h87-95cp"},
+			wantOwner: "golang-jwt",
+			wantRepo:  "jwt",
+		},
+		{
+			name: "Repository advisory of a global advisory",
+			adv:  &repository.Advisory{HTMLURL: "https://github.com/advisories/GHSA-cpj6-fhp6-mr6j"},
+			global: &global.Advisory{
+				RepositoryAdvisoryURL: "https://api.github.com/repos/remix-run/react-router/security-advisories/GHSA-cpj6-fhp6-mr6j",
+				SourceCodeLocation:    "https://github.com/remix-run/other",
+			},
+			wantOwner: "remix-run",
+			wantRepo:  "react-router",
+		},
+		{
+			name:      "Source code location of a global advisory",
+			adv:       &repository.Advisory{HTMLURL: "https://github.com/advisories/GHSA-cpj6-fhp6-mr6j"},
+			global:    &global.Advisory{SourceCodeLocation: "https://github.com/remix-run/react-router.git"},
+			wantOwner: "remix-run",
+			wantRepo:  "react-router",
+		},
+		{
+			name:   "Unknown source",
+			adv:    &repository.Advisory{HTMLURL: "https://github.com/advisories/GHSA-cpj6-fhp6-mr6j"},
+			global: &global.Advisory{SourceCodeLocation: "https://example.com"},
+		},
+		{
+			name:   "Source code location outside of GitHub",
+			adv:    &repository.Advisory{HTMLURL: "https://github.com/advisories/GHSA-cpj6-fhp6-mr6j"},
+			global: &global.Advisory{SourceCodeLocation: "https://gitlab.com/remix-run/react-router"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner, repo := newConverter(withGlobal(tt.global)).getSource(tt.adv)
+			assert.Equal(t, tt.wantOwner, owner)
+			assert.Equal(t, tt.wantRepo, repo)
+		})
+	}
+}
+
+func TestDistributionConfig_Validate(t *testing.T) {
+	cfg := DistributionConfig{
+		Distribution: Distribution{TLP: "PURPLE"},
+		Sources:      map[string]Distribution{"a/b/c": {URL: "first.org"}},
+		States:       map[string]Distribution{"rejected": {}},
+	}
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "invalid TLP label 'PURPLE'")
+	assert.ErrorContains(t, err, "invalid source 'a/b/c'")
+	assert.ErrorContains(t, err, "source 'a/b/c': invalid TLP URL 'first.org'")
+	assert.ErrorContains(t, err, "unknown state 'rejected'")
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/csaf-poc/ghsa/internal/utils"
+	"github.com/csaf-poc/ghsa/models/csaf"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+)
+
+// epssSource is the source of the EPSS scores noted in CSAF 2.1 metrics.
+const epssSource = "https://www.first.org/epss/"
+
+// WithEPSSTimestamp sets the time the EPSS score of global advisories was retrieved, e.g. the time of the API request.
+// GitHub updates EPSS scores daily without updating the advisory, so the update time of the advisory does not date the
+// score and the EPSS score is omitted without this option.
+func WithEPSSTimestamp(t time.Time) ConvertOption {
+	return func(c *converter) {
+		c.epssTimestamp = t
+	}
+}
+
+// getEPSS returns the EPSS score of the global advisory being converted. Returns nil if there is none, e.g. for
+// repository advisories and advisories imported from OSV, or if the time it was retrieved is unknown (see
+// WithEPSSTimestamp).
+func (c *converter) getEPSS() (epss *csaf.EPSS, err error) {
+	if c.global == nil || (c.global.EPSP.Percentage == 0 && c.global.EPSP.Percentile == 0) {
+		return nil, nil
+	}
+	timestamp := formatGlobalTime(c.epssTimestamp)
+	if timestamp == "" {
+		return nil, nil
+	}
+
+	// GitHub calls the probability percentage, but it is a probability between 0 and 1 like the percentile
+	probability, ok := formatEPSS(c.global.EPSP.Percentage)
+	if !ok {
+		err = fmt.Errorf("invalid EPSS probability %v", c.global.EPSP.Percentage)
+		return nil, err
+	}
+	percentile, ok := formatEPSS(c.global.EPSP.Percentile)
+	if !ok {
+		err = fmt.Errorf("invalid EPSS percentile %v", c.global.EPSP.Percentile)
+		return nil, err
+	}
+	epss = &csaf.EPSS{Percentile: percentile, Probability: probability, Timestamp: timestamp}
+	return epss, nil
+}
+
+// formatEPSS formats a probability or percentile between 0 and 1 as decimal as required by CSAF 2.1, e.g. "0.5" or
+// "1.0". Reports false if the value is out of range.
+func formatEPSS(v float64) (s string, ok bool) {
+	switch {
+	case v < 0 || v > 1:
+		return "", false
+	case v == 0:
+		return "0.0", true
+	case v == 1:
+		return "1.0", true
+	default:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	}
+}
+
+// getEPSSMetrics returns the EPSS score as CSAF 2.1 metric of the affected products or nil if there is none.
+func getEPSSMetrics(epss *csaf.EPSS, products []*product) []*csaf.Metric21 {
+	affected := affectedProductIDs(products)
+	if epss == nil || len(affected) == 0 {
+		return nil
+	}
+	return []*csaf.Metric21{{
+		Content:  &csaf.MetricContent21{EPSS: epss},
+		Products: affected,
+		Source:   epssSource,
+	}}
+}
+
+// getEPSSThreats returns the EPSS score as threat of the affected products for CSAF 2.0, which has no metric for it.
+// Returns nil if there is none.
+func getEPSSThreats(epss *csaf.EPSS, products []*product) gocsaf.Threats {
+	var (
+		affected gocsaf.Products
+	)
+	for _, id := range affectedProductIDs(products) {
+		affected = append(affected, utils.Ref(gocsaf.ProductID(id)))
+	}
+	if epss == nil || len(affected) == 0 {
+		return nil
+	}
+	return gocsaf.Threats{{
+		Category: utils.Ref(gocsaf.CSAFThreatCategoryExploitStatus),
+		Date:     utils.Ref(epss.Timestamp),
+		Details: utils.Ref(fmt.Sprintf("EPSS probability of exploitation activity in the next 30 days: %s "+
+			"(percentile %s) according to %s", epss.Probability, epss.Percentile, epssSource)),
+		ProductIds: &affected,
+	}}
+}
@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/global"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConverter_getEPSS(t *testing.T) {
+	updatedAt := time.Date(2025, 4, 25, 14, 34, 18, 0, time.UTC)
+	retrievedAt := time.Date(2025, 5, 1, 8, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	tests := []struct {
+		name    string
+		global  *global.Advisory
+		opts    []ConvertOption
+		want    *csaf.EPSS
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:   "Retrieval time",
+			global: &global.Advisory{UpdatedAt: updatedAt, EPSP: global.EPSP{Percentage: 0.00022, Percentile: 0.04494}},
+			opts:   []ConvertOption{WithEPSSTimestamp(retrievedAt)},
+			want: &csaf.EPSS{
+				Percentile:  "0.04494",
+				Probability: "0.00022",
+				Timestamp:   "2025-05-01T06:00:00Z",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:   "Certain exploitation",
+			global: &global.Advisory{UpdatedAt: updatedAt, EPSP: global.EPSP{Percentage: 1, Percentile: 1}},
+			opts:   []ConvertOption{WithEPSSTimestamp(retrievedAt)},
+			want: &csaf.EPSS{
+				Percentile:  "1.0",
+				Probability: "1.0",
+				Timestamp:   "2025-05-01T06:00:00Z",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			// The update time of the advisory does not date the score
+			name:    "No retrieval time",
+			global:  &global.Advisory{UpdatedAt: updatedAt, EPSP: global.EPSP{Percentage: 0.00022, Percentile: 0.04494}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "No EPSS score",
+			global:  &global.Advisory{UpdatedAt: updatedAt},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "No time",
+			global:  &global.Advisory{EPSP: global.EPSP{Percentage: 0.5, Percentile: 0.5}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "Repository advisory",
+			wantErr: assert.NoError,
+		},
+		{
+			name:   "Err: Percentage instead of probability",
+			global: &global.Advisory{UpdatedAt: updatedAt, EPSP: global.EPSP{Percentage: 22, Percentile: 0.5}},
+			opts:   []ConvertOption{WithEPSSTimestamp(retrievedAt)},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorContains(t, err, "invalid EPSS probability 22")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newConverter(append(tt.opts, withGlobal(tt.global))...)
+			got, err := c.getEPSS()
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+)
+
+const (
+	// fakeRateLimitedOwner is an owner whose requests always exceed the rate limit.
+	fakeRateLimitedOwner = "rate-limited"
+	// fakeMalformedID is an advisory ID which is answered with malformed JSON.
+	fakeMalformedID = "GHSA-xxxx-xxxx-xxxx"
+	// fakePaginatedOrg is an organization with fakePaginatedCount advisories, served in pages of fakePageSize.
+	fakePaginatedOrg   = "paginated-org"
+	fakePaginatedCount = 5
+	fakePageSize       = 2
+)
+
+// fakeRateLimitReset is the time the rate limit of fakeRateLimitedOwner is reset.
+var fakeRateLimitReset = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fakeGlobalDate is the time the responses with global advisories are dated with.
+var fakeGlobalDate = time.Date(2025, 5, 2, 9, 30, 0, 0, time.UTC)
+
+// fakeGitHub is a stand-in for the GitHub REST API. It serves the repository and global advisories of the examples
+// folder, the latter dated with fakeGlobalDate, and simulates the error cases of the API:
+//   - unknown advisories, repositories and organizations result in 404 Not Found
+//   - all requests for the owner fakeRateLimitedOwner result in 403 Forbidden with exhausted rate limit headers
+//   - the advisory fakeMalformedID is answered with malformed JSON
+//   - the organization fakePaginatedOrg has fakePaginatedCount advisories which are served in pages of fakePageSize,
+//     linked to the next page on nextPageBase if it is set
+//   - created advisories are answered with 201 Created but not stored; requests without token result in 401
+//     Unauthorized and requests without summary in 422 Unprocessable Entity
+type fakeGitHub struct {
+	*httptest.Server
+
+	// advisories contains the raw advisories by "OWNER/REPO" in the order they are listed
+	advisories map[string][]json.RawMessage
+	// globals contains the raw global advisories by GHSA ID
+	globals map[string]json.RawMessage
+
+	// nextPageBase replaces the URL of the fake in the Link headers of paginated responses if it is not empty
+	nextPageBase string
+
+	mu sync.Mutex
+	// requests contains all received requests
+	requests []*http.Request
+}
+
+// newFakeGitHub starts a fakeGitHub which is closed at the end of the test.
+func newFakeGitHub(t *testing.T) *fakeGitHub {
+	t.Helper()
+
+	f := &fakeGitHub{advisories: make(map[string][]json.RawMessage), globals: make(map[string]json.RawMessage)}
+	f.loadExamples(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/{owner}/{repo}/security-advisories/{id}", f.getAdvisory)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/security-advisories", f.listRepository)
+	mux.HandleFunc("GET /orgs/{org}/security-advisories", f.listOrganization)
+	mux.HandleFunc("POST /repos/{owner}/{repo}/security-advisories", f.createAdvisory)
+	mux.HandleFunc("GET /advisories/{id}", f.getGlobalAdvisory)
+	f.Server = httptest.NewServer(f.record(mux))
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+// downloader returns a Downloader using the fake for both the API and the web interface.
+func (f *fakeGitHub) downloader(opts ...DownloaderOption) *Downloader {
+	opts = append([]DownloaderOption{WithHTTPClient(f.Client()), WithBaseURLs(f.URL, f.URL+"/web")}, opts...)
+	return NewDownloader(opts...)
+}
+
+// lastRequest returns the last request received by the fake.
+func (f *fakeGitHub) lastRequest() *http.Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.requests) == 0 {
+		return nil
+	}
+	return f.requests[len(f.requests)-1]
+}
+
+// loadExamples reads the repository and global advisories of the examples folder. Additionally, it creates the advisories of
+// fakePaginatedOrg as copies of the first example with distinct IDs.
+func (f *fakeGitHub) loadExamples(t *testing.T) {
+	t.Helper()
+
+	files, err := filepath.Glob("../examples/repository_GHSA/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("could not find repository examples: %v", err)
+	}
+
+	var first repository.Advisory
+	for i, file := range files {
+		var adv repository.Advisory
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("could not read example: %v", err)
+		}
+		if err = json.Unmarshal(b, &adv); err != nil {
+			t.Fatalf("could not unmarshal example '%s': %v", file, err)
+		}
+		if i == 0 {
+			first = adv
+		}
+
+		// HTML URL: https://github.com/OWNER/REPO/security/advisories/GHSA_ID
+		parts := strings.Split(strings.TrimPrefix(adv.HTMLURL, DefaultWebURL+"/"), "/")
+		key := parts[0] + "/" + parts[1]
+		f.advisories[key] = append(f.advisories[key], b)
+	}
+
+	globals, err := filepath.Glob("../examples/global_GHSA/*.json")
+	if err != nil || len(globals) == 0 {
+		t.Fatalf("could not find global examples: %v", err)
+	}
+	for _, file := range globals {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("could not read example: %v", err)
+		}
+		f.globals[strings.TrimSuffix(filepath.Base(file), ".json")] = b
+	}
+
+	for i := range fakePaginatedCount {
+		adv := first
+		adv.GhsaID = fmt.Sprintf("GHSA-page-0000-%04d", i)
+		adv.HTMLURL = fmt.Sprintf("%s/%s/repo/security/advisories/%s", DefaultWebURL, fakePaginatedOrg, adv.GhsaID)
+		b, _ := json.Marshal(adv)
+		f.advisories[fakePaginatedOrg+"/repo"] = append(f.advisories[fakePaginatedOrg+"/repo"], b)
+	}
+}
+
+// record stores every request before passing it on to next.
+func (f *fakeGitHub) record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.requests = append(f.requests, r)
+		f.mu.Unlock()
+
+		if strings.Contains(r.URL.Path, "/"+fakeRateLimitedOwner+"/") {
+			w.Header().Set("X-RateLimit-Limit", "60")
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(fakeRateLimitReset.Unix(), 10))
+			writeJSONError(w, http.StatusForbidden, "API rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (f *fakeGitHub) getAdvisory(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("id") == fakeMalformedID {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ghsa_id": "` + fakeMalformedID))
+		return
+	}
+
+	for _, raw := range f.advisories[r.PathValue("owner")+"/"+r.PathValue("repo")] {
+		var adv repository.Advisory
+		_ = json.Unmarshal(raw, &adv)
+		if adv.GhsaID == r.PathValue("id") {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(raw)
+			return
+		}
+	}
+	writeJSONError(w, http.StatusNotFound, "Not Found")
+}
+
+func (f *fakeGitHub) getGlobalAdvisory(w http.ResponseWriter, r *http.Request) {
+	raw, ok := f.globals[r.PathValue("id")]
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Date", fakeGlobalDate.Format(http.TimeFormat))
+	_, _ = w.Write(raw)
+}
+
+func (f *fakeGitHub) listRepository(w http.ResponseWriter, r *http.Request) {
+	advisories, ok := f.advisories[r.PathValue("owner")+"/"+r.PathValue("repo")]
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	f.writePage(w, r, advisories)
+}
+
+func (f *fakeGitHub) listOrganization(w http.ResponseWriter, r *http.Request) {
+	var (
+		advisories []json.RawMessage
+		found      bool
+	)
+	for key, advs := range f.advisories {
+		if strings.HasPrefix(key, r.PathValue("org")+"/") {
+			advisories = append(advisories, advs...)
+			found = true
+		}
+	}
+	if !found {
+		writeJSONError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	f.writePage(w, r, advisories)
+}
+
+func (f *fakeGitHub) createAdvisory(w http.ResponseWriter, r *http.Request) {
+	var (
+		req repository.CreateRequest
+	)
+	if r.Header.Get("Authorization") == "" {
+		writeJSONError(w, http.StatusUnauthorized, "Requires authentication")
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Summary == "" {
+		writeJSONError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+
+	adv := repository.Advisory{
+		GhsaID:      "GHSA-fake-0000-0001",
+		Summary:     req.Summary,
+		Description: req.Description,
+		CveID:       req.CVEID,
+		State:       "triage",
+		HTMLURL: fmt.Sprintf("%s/%s/%s/security/advisories/GHSA-fake-0000-0001", DefaultWebURL,
+			r.PathValue("owner"), r.PathValue("repo")),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(adv)
+}
+
+// writePage writes the requested page of advisories and a Link header pointing to the next page (if any).
+// The page size is limited to fakePageSize regardless of the requested size.
+func (f *fakeGitHub) writePage(w http.ResponseWriter, r *http.Request, advisories []json.RawMessage) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	start := min((page-1)*fakePageSize, len(advisories))
+	end := min(start+fakePageSize, len(advisories))
+
+	if end < len(advisories) {
+		next := *r.URL
+		q := next.Query()
+		q.Set("page", strconv.Itoa(page+1))
+		next.RawQuery = q.Encode()
+		base := f.URL
+		if f.nextPageBase != "" {
+			base = f.nextPageBase
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="next"`, base, next.RequestURI()))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(advisories[start:end])
+}
+
+// writeJSONError writes an error in the format of the GitHub API.
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"message":           message,
+		"documentation_url": "https://docs.github.com/rest",
+	})
+}
@@ -1,13 +1,18 @@
 package internal
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
+	ghsaglobal "github.com/csaf-poc/ghsa/models/ghsa/global"
 	ghsarepository "github.com/csaf-poc/ghsa/models/ghsa/repository"
 )
 
@@ -15,32 +20,259 @@ import (
 // Browser URL -> 	https://github.com/golang-jwt/jwt/security/advisories/GHSA-mh63-6h87-95cp
 const _ = "https://api.github.com/repos/OWNER/REPO/security-advisories/GHSA_ID"
 
+const (
+	// DefaultAPIURL is the base URL of the GitHub REST API.
+	DefaultAPIURL = "https://api.github.com"
+	// DefaultWebURL is the base URL of the GitHub web interface.
+	DefaultWebURL = "https://github.com"
+	// DefaultUserAgent is sent with every request unless configured otherwise.
+	DefaultUserAgent = "csaf-poc-ghsa"
+
+	// apiVersion is the version of the GitHub REST API the models are built for.
+	apiVersion = "2022-11-28"
+	// perPage is the page size used for listing advisories (maximum allowed by the GitHub API).
+	perPage = 100
+)
+
+// Downloader fetches GitHub Security Advisories from the GitHub REST API.
+// Use NewDownloader to create one.
+type Downloader struct {
+	client    *http.Client
+	apiURL    string
+	webURL    string
+	token     string
+	userAgent string
+	logger    *slog.Logger
+}
+
+// DownloaderOption configures a Downloader.
+type DownloaderOption func(d *Downloader)
+
+// WithHTTPClient sets the HTTP client used for all requests. Defaults to http.DefaultClient.
+func WithHTTPClient(client *http.Client) DownloaderOption {
+	return func(d *Downloader) {
+		d.client = client
+	}
+}
+
+// WithBaseURLs sets the base URLs of the REST API and the web interface, e.g. for GitHub Enterprise Server
+// (https://HOSTNAME/api/v3 and https://HOSTNAME) or for a test server.
+// Defaults to DefaultAPIURL and DefaultWebURL.
+func WithBaseURLs(apiURL string, webURL string) DownloaderOption {
+	return func(d *Downloader) {
+		d.apiURL = strings.TrimSuffix(apiURL, "/")
+		d.webURL = strings.TrimSuffix(webURL, "/")
+	}
+}
+
+// WithToken sets the token used to authenticate against the GitHub API. Authenticated requests have a higher rate
+// limit and are required to access unpublished repository advisories.
+func WithToken(token string) DownloaderOption {
+	return func(d *Downloader) {
+		d.token = token
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request. Defaults to DefaultUserAgent.
+func WithUserAgent(userAgent string) DownloaderOption {
+	return func(d *Downloader) {
+		d.userAgent = userAgent
+	}
+}
+
+// WithLogger sets the logger used for request logging. Defaults to slog.Default().
+func WithLogger(logger *slog.Logger) DownloaderOption {
+	return func(d *Downloader) {
+		d.logger = logger
+	}
+}
+
+// NewDownloader creates a Downloader with the given options.
+func NewDownloader(opts ...DownloaderOption) *Downloader {
+	d := &Downloader{
+		client:    http.DefaultClient,
+		apiURL:    DefaultAPIURL,
+		webURL:    DefaultWebURL,
+		userAgent: DefaultUserAgent,
+		logger:    slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
 // DownloadGHSA fetches a GitHub Security Advisory from the provided URL.
 // It handles both browser and API URL formats, normalizes them to the API format,
 // makes an HTTP GET request, and unmarshals the JSON response into an Advisory struct.
 // Returns the Advisory or an error if normalization, network request, or unmarshaling fails.
+// It uses a Downloader with default options; use NewDownloader for cancellation and further configuration.
 func DownloadGHSA(url string) (ghsa *ghsarepository.Advisory, err error) {
+	return NewDownloader().Get(context.Background(), url)
+}
+
+// Get fetches the repository advisory referenced by ref, which is either a browser or an API URL.
+// Returns the Advisory or an error if normalization, network request, or unmarshaling fails.
+// Errors can be inspected with errors.Is (ErrInvalidURL, ErrNotFound, ErrUnauthorized, ErrRateLimited) and errors.As
+// (*HTTPError, *RateLimitError).
+func (d *Downloader) Get(ctx context.Context, ref string) (ghsa *ghsarepository.Advisory, err error) {
 	// Normalize URL to standard API format (accepts both browser and API URLs)
-	url, err = normalizeGHSAURL(url)
+	apiURL, err := d.normalize(ref)
 	if err != nil {
-		err = fmt.Errorf("invalid URL: %v", err)
+		err = fmt.Errorf("%w: %v", ErrInvalidURL, err)
 		return nil, err
 	}
 
 	// Fetch the advisory from GitHub API
-	resp, err := http.Get(url)
-	if err != nil || resp.StatusCode != http.StatusOK {
-		err = fmt.Errorf("could not create request due to network error or status is not ok: error is '%v' and status code is '%s'", err, resp.Status)
+	body, _, err := d.get(ctx, apiURL)
+	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	// Read and unmarshal the response body
-	body, err := io.ReadAll(resp.Body)
+	err = json.Unmarshal(body, &ghsa)
+	if err != nil {
+		err = fmt.Errorf("could not unmarshal response body: %v", err)
+		return nil, err
+	}
+	return ghsa, nil
+}
+
+// GetGlobal fetches a global security advisory from the GitHub API. It accepts browser URLs
+// (WEB_URL/advisories/GHSA_ID) and API URLs (API_URL/advisories/GHSA_ID), see IsGlobal. retrievedAt is the time of the
+// response, which dates the EPSS score of the advisory (see WithEPSSTimestamp).
+func (d *Downloader) GetGlobal(ctx context.Context, ref string) (ghsa *ghsaglobal.Advisory, retrievedAt time.Time,
+	err error) {
+	apiURL, err := d.normalizeGlobal(ref)
+	if err != nil {
+		err = fmt.Errorf("%w: %v", ErrInvalidURL, err)
+		return nil, time.Time{}, err
+	}
+
+	body, resp, err := d.get(ctx, apiURL)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	// The Date header is the time the server generated the response; fall back to the time it was received
+	retrievedAt, err = http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		retrievedAt = time.Now()
+	}
+
+	err = json.Unmarshal(body, &ghsa)
+	if err != nil {
+		err = fmt.Errorf("could not unmarshal response body: %v", err)
+		return nil, time.Time{}, err
+	}
+	return ghsa, retrievedAt.UTC(), nil
+}
+
+// IsGlobal reports whether ref is the URL of a global security advisory, see GetGlobal.
+func (d *Downloader) IsGlobal(ref string) bool {
+	_, err := d.normalizeGlobal(ref)
+	return err == nil
+}
+
+// ListQuery selects the repository advisories returned by Downloader.List.
+type ListQuery struct {
+	// Owner is the organization (or user, if Repo is set) owning the advisories. Required.
+	Owner string
+	// Repo restricts the advisories to a single repository. If empty, the advisories of all repositories of the
+	// organization Owner are listed.
+	Repo string
+	// State filters the advisories by state (triage, draft, published or closed). If empty, all advisories accessible
+	// with the configured token are listed.
+	State string
+}
+
+// List fetches all repository advisories matching the query. It follows the pagination of the GitHub API until all
+// pages are read.
+func (d *Downloader) List(ctx context.Context, query ListQuery) (advisories []*ghsarepository.Advisory, err error) {
+	var (
+		next string
+	)
+	if query.Owner == "" {
+		err = fmt.Errorf("invalid query: owner is missing")
+		return nil, err
+	}
+
+	// First page
+	params := url.Values{}
+	params.Set("per_page", fmt.Sprint(perPage))
+	if query.State != "" {
+		params.Set("state", query.State)
+	}
+	if query.Repo != "" {
+		next = fmt.Sprintf("%s/repos/%s/%s/security-advisories?%s",
+			d.apiURL, url.PathEscape(query.Owner), url.PathEscape(query.Repo), params.Encode())
+	} else {
+		next = fmt.Sprintf("%s/orgs/%s/security-advisories?%s",
+			d.apiURL, url.PathEscape(query.Owner), params.Encode())
+	}
+
+	for next != "" {
+		var (
+			page []*ghsarepository.Advisory
+			body []byte
+			resp *http.Response
+		)
+		body, resp, err = d.get(ctx, next)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(body, &page)
+		if err != nil {
+			err = fmt.Errorf("could not unmarshal response body: %v", err)
+			return nil, err
+		}
+		advisories = append(advisories, page...)
+		next = nextPageURL(resp.Header.Get("Link"))
+		if err = d.checkNextPage(next); err != nil {
+			return nil, err
+		}
+	}
+	return advisories, nil
+}
+
+// checkNextPage checks that the URL of the next page (if any) has the scheme and host of the API base URL. The token
+// is sent with the request for the next page, so it must not be followed to other hosts named by the Link header.
+func (d *Downloader) checkNextPage(next string) error {
+	if next == "" {
+		return nil
+	}
+	u, err := url.Parse(next)
+	if err != nil {
+		return fmt.Errorf("invalid next page URL: %v", err)
+	}
+	api, err := url.Parse(d.apiURL)
+	if err != nil {
+		return fmt.Errorf("invalid API base URL: %v", err)
+	}
+	if u.Scheme != api.Scheme || u.Host != api.Host {
+		return fmt.Errorf("refusing to follow next page URL %s outside of the API base URL %s", next, d.apiURL)
+	}
+	return nil
+}
+
+// Create creates a repository security advisory in the repository OWNER/REPO from the request body (see FromCSAF) and
+// returns the created advisory. Creating advisories requires a token with write access to the repository.
+func (d *Downloader) Create(ctx context.Context, owner string, repo string, req *ghsarepository.CreateRequest) (
+	ghsa *ghsarepository.Advisory, err error) {
+	if owner == "" || repo == "" {
+		err = fmt.Errorf("invalid repository: owner or repository is missing")
+		return nil, err
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		err = fmt.Errorf("could not marshal request body: %v", err)
+		return nil, err
+	}
+
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/security-advisories", d.apiURL, url.PathEscape(owner), url.PathEscape(repo))
+	body, _, err := d.do(ctx, http.MethodPost, apiURL, payload, http.StatusCreated)
 	if err != nil {
-		err = fmt.Errorf("could not read response body: %v", err)
 		return nil, err
 	}
+
 	err = json.Unmarshal(body, &ghsa)
 	if err != nil {
 		err = fmt.Errorf("could not unmarshal response body: %v", err)
@@ -49,13 +281,89 @@ func DownloadGHSA(url string) (ghsa *ghsarepository.Advisory, err error) {
 	return ghsa, nil
 }
 
-// normalizeGHSAURL converts a GitHub Security Advisory URL to the standard API format.
-// It accepts both browser URLs (github.com/OWNER/REPO/security/advisories/GHSA_ID)
-// and API URLs (api.github.com/repos/OWNER/REPO/security-advisories/GHSA_ID),
-// returning the normalized API URL format.
+// get sends an authenticated GET request to the GitHub API and returns the body of a successful response.
+func (d *Downloader) get(ctx context.Context, apiURL string) (body []byte, resp *http.Response, err error) {
+	return d.do(ctx, http.MethodGet, apiURL, nil, http.StatusOK)
+}
+
+// do sends an authenticated request with the JSON payload (if any) to the GitHub API and returns the body of the
+// response if it has the expected status code.
+func (d *Downloader) do(ctx context.Context, method string, apiURL string, payload []byte, status int) (
+	body []byte, resp *http.Response, err error) {
+	var (
+		reqBody io.Reader
+	)
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, reqBody)
+	if err != nil {
+		err = fmt.Errorf("could not create request: %v", err)
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", apiVersion)
+	req.Header.Set("User-Agent", d.userAgent)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if d.token != "" {
+		req.Header.Set("Authorization", "Bearer "+d.token)
+	}
+
+	d.logger.DebugContext(ctx, "Requesting GitHub API", slog.String("method", method), slog.String("url", apiURL))
+	resp, err = d.client.Do(req)
+	if err != nil {
+		err = fmt.Errorf("could not send request due to network error: %w", err)
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	// Read the response body
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		err = fmt.Errorf("could not read response body: %v", err)
+		return nil, nil, err
+	}
+	if resp.StatusCode != status {
+		d.logger.DebugContext(ctx, "GitHub API request failed", slog.String("url", apiURL), slog.Int("status", resp.StatusCode))
+		err = newResponseError(apiURL, resp, body, time.Now())
+		return nil, nil, err
+	}
+	return body, resp, nil
+}
+
+// nextPageURL extracts the URL of the next page from a Link header
+// (e.g. `<https://api.github.com/...&page=2>; rel="next", <https://api.github.com/...&page=5>; rel="last"`).
+// Returns an empty string if there is no next page.
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		segments := strings.Split(part, ";")
+		if len(segments) < 2 {
+			continue
+		}
+		for _, param := range segments[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(segments[0]), "<>")
+			}
+		}
+	}
+	return ""
+}
+
+// normalizeGHSAURL converts a GitHub Security Advisory URL to the standard API format using the default base URLs.
+// See Downloader.normalize.
 func normalizeGHSAURL(ghsaURL string) (apiURL string, err error) {
+	return NewDownloader().normalize(ghsaURL)
+}
+
+// normalize converts a GitHub Security Advisory URL to the standard API format.
+// It accepts both browser URLs (WEB_URL/OWNER/REPO/security/advisories/GHSA_ID)
+// and API URLs (API_URL/repos/OWNER/REPO/security-advisories/GHSA_ID),
+// returning the normalized API URL format.
+func (d *Downloader) normalize(ghsaURL string) (apiURL string, err error) {
 	var (
-		u *url.URL
+		u, api, web *url.URL
 	)
 
 	u, err = url.Parse(ghsaURL)
@@ -63,25 +371,75 @@ func normalizeGHSAURL(ghsaURL string) (apiURL string, err error) {
 		err = fmt.Errorf("invalid URL: %w", err)
 		return
 	}
-
-	// Split URL into parts
-	parts := strings.Split(u.Path, "/")
-
-	// Check for browser format (https://github.com/OWNER/REPO/security/advisories/GHSA_ID)
-	if u.Host == "github.com" && len(parts) == 6 && parts[3] == "security" && parts[4] == "advisories" {
-		apiURL = fmt.Sprintf("https://api.github.com/repos/%s/%s/security-advisories/%s", parts[1], parts[2], parts[5])
+	api, err = url.Parse(d.apiURL)
+	if err != nil {
+		err = fmt.Errorf("invalid API base URL: %w", err)
 		return
 	}
-
-	// Check for API format (api.github.com/repos/OWNER/REPO/security-advisories/GHSA_ID)
-	if u.Host == "api.github.com" && len(parts) == 6 && parts[1] == "repos" && parts[4] == "security-advisories" {
-		// ghsaURL is already in the correct format
-		apiURL = ghsaURL
+	web, err = url.Parse(d.webURL)
+	if err != nil {
+		err = fmt.Errorf("invalid web base URL: %w", err)
 		return
 	}
 
+	// Check for API format (API_URL/repos/OWNER/REPO/security-advisories/GHSA_ID). The API path prefix is checked
+	// first because GitHub Enterprise Server serves the API and the web interface on the same host.
+	if u.Host == api.Host && strings.HasPrefix(u.Path, api.Path+"/") {
+		parts := strings.Split(strings.TrimPrefix(u.Path, api.Path), "/")
+		if len(parts) == 6 && parts[1] == "repos" && parts[4] == "security-advisories" {
+			if apiURL, ok := d.advisoryURL(parts[2], parts[3], parts[5]); ok {
+				return apiURL, nil
+			}
+		}
+	}
+
+	// Check for browser format (WEB_URL/OWNER/REPO/security/advisories/GHSA_ID)
+	if u.Host == web.Host && strings.HasPrefix(u.Path, web.Path+"/") {
+		parts := strings.Split(strings.TrimPrefix(u.Path, web.Path), "/")
+		if len(parts) == 6 && parts[3] == "security" && parts[4] == "advisories" {
+			if apiURL, ok := d.advisoryURL(parts[1], parts[2], parts[5]); ok {
+				return apiURL, nil
+			}
+		}
+	}
+
 	// Unsupported URL format
-	err = fmt.Errorf("unsupported URL: %s. Expected `%s` or `%s`", ghsaURL, "https://github.com/OWNER/REPO/security/advisories/GHSA_ID", "https://api.github.com/repos/OWNER/REPO/security-advisories/GHSA_ID")
+	err = fmt.Errorf("unsupported URL: %s. Expected `%s` or `%s`", ghsaURL,
+		d.webURL+"/OWNER/REPO/security/advisories/GHSA_ID", d.apiURL+"/repos/OWNER/REPO/security-advisories/GHSA_ID")
+	return
+}
+
+// advisoryURL returns the API URL of the repository advisory with the decoded path segments escaped again. ok is false
+// if a segment is empty.
+func (d *Downloader) advisoryURL(owner string, repo string, id string) (apiURL string, ok bool) {
+	if owner == "" || repo == "" || id == "" {
+		return "", false
+	}
+	return fmt.Sprintf("%s/repos/%s/%s/security-advisories/%s", d.apiURL, url.PathEscape(owner), url.PathEscape(repo),
+		url.PathEscape(id)), true
+}
+
+// normalizeGlobal converts the URL of a global security advisory to the API format. It accepts browser URLs
+// (WEB_URL/advisories/GHSA_ID) and API URLs (API_URL/advisories/GHSA_ID).
+func (d *Downloader) normalizeGlobal(ghsaURL string) (apiURL string, err error) {
+	u, err := url.Parse(ghsaURL)
+	if err != nil {
+		err = fmt.Errorf("invalid URL: %w", err)
+		return
+	}
+	for _, base := range []string{d.apiURL, d.webURL} {
+		b, err := url.Parse(base)
+		if err != nil {
+			return "", fmt.Errorf("invalid base URL: %w", err)
+		}
+		parts := strings.Split(strings.TrimPrefix(u.Path, b.Path), "/")
+		if u.Host == b.Host && strings.HasPrefix(u.Path, b.Path+"/") && len(parts) == 3 && parts[1] == "advisories" &&
+			strings.HasPrefix(parts[2], "GHSA-") {
+			return fmt.Sprintf("%s/advisories/%s", d.apiURL, parts[2]), nil
+		}
+	}
+	err = fmt.Errorf("unsupported URL: %s. Expected `%s` or `%s`", ghsaURL, d.webURL+"/advisories/GHSA_ID",
+		d.apiURL+"/advisories/GHSA_ID")
 	return
 }
 
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Errors of the Downloader. Use errors.Is to check for them.
+var (
+	// ErrInvalidURL means the advisory reference is not a supported GitHub Security Advisory URL.
+	ErrInvalidURL = errors.New("invalid URL")
+	// ErrNotFound means the advisory (or the repository or organization) does not exist or is not visible with the
+	// credentials used.
+	ErrNotFound = errors.New("not found")
+	// ErrUnauthorized means the credentials are missing, invalid or lack the required permissions.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrRateLimited means the GitHub API rate limit is exceeded. See RateLimitError for the time to retry.
+	ErrRateLimited = errors.New("rate limit exceeded")
+)
+
+// HTTPError is returned if the GitHub API responds with an unexpected status code. Use errors.As to access the
+// response.
+type HTTPError struct {
+	// URL is the requested URL.
+	URL string
+	// StatusCode is the HTTP status code of the response (e.g. 404).
+	StatusCode int
+	// Status is the HTTP status of the response (e.g. "404 Not Found").
+	Status string
+	// Body is the body of the response.
+	Body []byte
+}
+
+func (e *HTTPError) Error() string {
+	var (
+		msg struct {
+			Message string `json:"message"`
+		}
+	)
+	// GitHub explains errors in the message property of the response
+	if json.Unmarshal(e.Body, &msg) == nil && msg.Message != "" {
+		return fmt.Sprintf("status is not ok: status code is '%s': %s", e.Status, msg.Message)
+	}
+	return fmt.Sprintf("status is not ok: status code is '%s'", e.Status)
+}
+
+// Is reports whether the status code corresponds to ErrNotFound or ErrUnauthorized.
+func (e *HTTPError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	default:
+		return false
+	}
+}
+
+// RateLimitError is returned if a request exceeded the primary or a secondary rate limit of the GitHub API. It matches
+// ErrRateLimited, but neither ErrUnauthorized nor *HTTPError, although GitHub may respond with 403 Forbidden.
+type RateLimitError struct {
+	HTTPError
+	// Reset is the time the rate limit is reset, so requests can be retried. It is zero if GitHub did not provide it.
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Reset.IsZero() {
+		return fmt.Sprintf("%v: %s", ErrRateLimited, e.HTTPError.Error())
+	}
+	return fmt.Sprintf("%v until %s: %s", ErrRateLimited, e.Reset.Format(time.RFC3339), e.HTTPError.Error())
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// newResponseError returns the error for the response with an unexpected status code: a *RateLimitError if a rate
+// limit was exceeded and an *HTTPError otherwise.
+// See https://docs.github.com/en/rest/using-the-rest-api/rate-limits-for-the-rest-api#exceeding-the-rate-limit
+func newResponseError(apiURL string, resp *http.Response, body []byte, now time.Time) error {
+	var (
+		httpErr = HTTPError{URL: apiURL, StatusCode: resp.StatusCode, Status: resp.Status, Body: body}
+	)
+
+	retryAfter := resp.Header.Get("Retry-After")
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	if resp.StatusCode != http.StatusTooManyRequests &&
+		!(resp.StatusCode == http.StatusForbidden && (remaining == "0" || retryAfter != "")) {
+		return &httpErr
+	}
+
+	rateErr := &RateLimitError{HTTPError: httpErr}
+	if seconds, err := strconv.ParseInt(retryAfter, 10, 64); err == nil {
+		// Secondary rate limits tell how long to wait
+		rateErr.Reset = now.Add(time.Duration(seconds) * time.Second)
+	} else if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rateErr.Reset = time.Unix(reset, 0).UTC()
+	}
+	return rateErr
+}
@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewResponseError(t *testing.T) {
+	var (
+		now = time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	)
+
+	tests := []struct {
+		name        string
+		statusCode  int
+		header      http.Header
+		wantIs      error
+		wantIsNot   error
+		wantReset   time.Time
+		wantRateErr bool
+	}{
+		{
+			name:       "not found",
+			statusCode: http.StatusNotFound,
+			wantIs:     ErrNotFound,
+			wantIsNot:  ErrRateLimited,
+		},
+		{
+			name:       "unauthorized",
+			statusCode: http.StatusUnauthorized,
+			wantIs:     ErrUnauthorized,
+			wantIsNot:  ErrNotFound,
+		},
+		{
+			name:       "forbidden",
+			statusCode: http.StatusForbidden,
+			header:     http.Header{"X-Ratelimit-Remaining": {"42"}},
+			wantIs:     ErrUnauthorized,
+			wantIsNot:  ErrRateLimited,
+		},
+		{
+			name:        "primary rate limit",
+			statusCode:  http.StatusForbidden,
+			header:      http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"1743512400"}},
+			wantIs:      ErrRateLimited,
+			wantIsNot:   ErrUnauthorized,
+			wantReset:   time.Date(2025, 4, 1, 13, 0, 0, 0, time.UTC),
+			wantRateErr: true,
+		},
+		{
+			name:        "secondary rate limit",
+			statusCode:  http.StatusTooManyRequests,
+			header:      http.Header{"Retry-After": {"60"}},
+			wantIs:      ErrRateLimited,
+			wantIsNot:   ErrUnauthorized,
+			wantReset:   now.Add(time.Minute),
+			wantRateErr: true,
+		},
+		{
+			name:       "server error",
+			statusCode: http.StatusBadGateway,
+			wantIsNot:  ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				httpErr *HTTPError
+				rateErr *RateLimitError
+			)
+			resp := &http.Response{
+				StatusCode: tt.statusCode,
+				Status:     http.StatusText(tt.statusCode),
+				Header:     tt.header,
+			}
+			if resp.Header == nil {
+				resp.Header = http.Header{}
+			}
+
+			err := newResponseError("https://api.github.com/", resp, []byte(`{"message":"message"}`), now)
+			if tt.wantIs != nil {
+				assert.ErrorIs(t, err, tt.wantIs)
+			}
+			assert.False(t, errors.Is(err, tt.wantIsNot))
+			assert.ErrorContains(t, err, "message")
+
+			if tt.wantRateErr {
+				if assert.ErrorAs(t, err, &rateErr) {
+					assert.Equal(t, tt.wantReset, rateErr.Reset)
+					assert.Equal(t, tt.statusCode, rateErr.StatusCode)
+				}
+			} else if assert.ErrorAs(t, err, &httpErr) {
+				assert.Equal(t, tt.statusCode, httpErr.StatusCode)
+			}
+		})
+	}
+}
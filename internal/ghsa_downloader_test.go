@@ -1,13 +1,25 @@
 package internal
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	ghsarepository "github.com/csaf-poc/ghsa/models/ghsa/repository"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestDownloadGHSA(t *testing.T) {
+func TestDownloader_Get(t *testing.T) {
+	var (
+		fake = newFakeGitHub(t)
+	)
+
 	type args struct {
 		url string
 	}
@@ -18,22 +30,22 @@ func TestDownloadGHSA(t *testing.T) {
 		wantErr  assert.ErrorAssertionFunc
 	}{
 		{
-			name: "Happy path: Valid GHSA URL",
+			name: "Happy path: Valid GHSA browser URL",
 			args: args{
-				url: "https://github.com/esm-dev/esm.sh/security/advisories/GHSA-h3mw-4f23-gwpw",
+				url: fake.URL + "/web/golang-jwt/jwt/security/advisories/GHSA-mh63-6h87-95cp",
 			},
 			wantGhsa: func(t assert.TestingT, got interface{}, want ...interface{}) bool {
 				gotGhsa, ok := got.(*ghsarepository.Advisory)
 				if !ok {
-					t.Errorf("DownloadGHSA() got = %v, want *ghsarepository.Advisory", got)
+					t.Errorf("Get() got = %v, want *ghsarepository.Advisory", got)
 					return false
 				}
 				if wantID := "GHSA-mh63-6h87-95cp"; gotGhsa.GhsaID != wantID {
-					t.Errorf("DownloadGHSA() gotGhsa.GhsaID = '%v', want.GhsaID '%v'", gotGhsa.GhsaID, wantID)
+					t.Errorf("Get() gotGhsa.GhsaID = '%v', want.GhsaID '%v'", gotGhsa.GhsaID, wantID)
 					return false
 				}
 				if wantCveId := "CVE-2025-30204"; gotGhsa.CveID != wantCveId {
-					t.Errorf("DownloadGHSA() gotGhsa.CveID = '%v', want.CveID '%v'", gotGhsa.CveID, wantCveId)
+					t.Errorf("Get() gotGhsa.CveID = '%v', want.CveID '%v'", gotGhsa.CveID, wantCveId)
 					return false
 				}
 				return true
@@ -41,53 +53,234 @@ func TestDownloadGHSA(t *testing.T) {
 			wantErr: assert.NoError,
 		},
 		{
-			name: "Err: Check URL fails",
+			name: "Happy path: Valid GHSA API URL",
 			args: args{
-				url: "https://api.gitlb.com/repos/golang-jwt/jwt/security-advisories/GHSA-mh63-6h87-95cp",
+				url: fake.URL + "/repos/golang-jwt/jwt/security-advisories/GHSA-mh63-6h87-95cp",
 			},
 			wantGhsa: func(t assert.TestingT, got interface{}, want ...interface{}) bool {
 				gotGhsa, ok := got.(*ghsarepository.Advisory)
-				if !ok {
-					t.Errorf("DownloadGHSA() got = %v, want *ghsarepository.Advisory", got)
-					return false
-				}
-				return gotGhsa == nil
+				return ok && assert.Equal(t, "GHSA-mh63-6h87-95cp", gotGhsa.GhsaID)
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Err: Check URL fails",
+			args: args{
+				url: "https://api.gitlb.com/repos/golang-jwt/jwt/security-advisories/GHSA-mh63-6h87-95cp",
 			},
+			wantGhsa: assertNilAdvisory,
 			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
-				return assert.ErrorContains(t, err, "unsupported URL")
+				return assert.ErrorIs(t, err, ErrInvalidURL) && assert.ErrorContains(t, err, "unsupported URL")
 			},
 		},
 		{
-			name: "Err: Get URL fails",
+			name: "Err: Advisory not found",
 			args: args{
-				url: "https://api.github.com/repos/golang-jwt/jwt/security-advisories/This-Is-Not-A-GHSA",
+				url: fake.URL + "/repos/golang-jwt/jwt/security-advisories/This-Is-Not-A-GHSA",
 			},
-			wantGhsa: func(t assert.TestingT, got interface{}, want ...interface{}) bool {
-				gotGhsa, ok := got.(*ghsarepository.Advisory)
-				if !ok {
-					t.Errorf("DownloadGHSA() got = %v, want *ghsarepository.Advisory", got)
-					return false
-				}
-				return gotGhsa == nil
+			wantGhsa: assertNilAdvisory,
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				var httpErr *HTTPError
+				return assert.ErrorIs(t, err, ErrNotFound) &&
+					assert.ErrorAs(t, err, &httpErr) &&
+					assert.Equal(t, http.StatusNotFound, httpErr.StatusCode) &&
+					assert.Contains(t, string(httpErr.Body), `"message":"Not Found"`) &&
+					assert.ErrorContains(t, err, "404 Not Found")
+			},
+		},
+		{
+			name: "Err: Rate limit exceeded",
+			args: args{
+				url: fake.URL + "/repos/" + fakeRateLimitedOwner + "/jwt/security-advisories/GHSA-mh63-6h87-95cp",
+			},
+			wantGhsa: assertNilAdvisory,
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				var rateErr *RateLimitError
+				return assert.ErrorIs(t, err, ErrRateLimited) &&
+					assert.NotErrorIs(t, err, ErrUnauthorized) &&
+					assert.ErrorAs(t, err, &rateErr) &&
+					assert.Equal(t, fakeRateLimitReset, rateErr.Reset) &&
+					assert.ErrorContains(t, err, "403 Forbidden")
 			},
+		},
+		{
+			name: "Err: Malformed JSON",
+			args: args{
+				url: fake.URL + "/repos/golang-jwt/jwt/security-advisories/" + fakeMalformedID,
+			},
+			wantGhsa: assertNilAdvisory,
 			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
-				return assert.ErrorContains(t, err, "404 Not Found")
+				return assert.ErrorContains(t, err, "could not unmarshal response body")
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotGhsa, err := DownloadGHSA(tt.args.url)
+			gotGhsa, err := fake.downloader().Get(context.Background(), tt.args.url)
 			if !tt.wantErr(t, err) {
-				t.Error("Testing DownloadGHSA(): tt.wantErr() didn't run as expected") // TODO: Check error case
+				t.Error("Testing Get(): tt.wantErr() didn't run as expected")
 			}
 			if !tt.wantGhsa(t, gotGhsa) {
-				t.Error("Testing DownloadGHSA(): tt.wantGhsa() didn't run as expected") // TODO: Check error case
+				t.Error("Testing Get(): tt.wantGhsa() didn't run as expected")
+			}
+		})
+	}
+}
+
+func TestDownloader_Get_Headers(t *testing.T) {
+	var (
+		fake = newFakeGitHub(t)
+	)
+
+	_, err := fake.downloader(WithToken("secret"), WithUserAgent("test-agent")).
+		Get(context.Background(), fake.URL+"/repos/golang-jwt/jwt/security-advisories/GHSA-mh63-6h87-95cp")
+	assert.NoError(t, err)
+
+	req := fake.lastRequest()
+	if assert.NotNil(t, req) {
+		assert.Equal(t, "Bearer secret", req.Header.Get("Authorization"))
+		assert.Equal(t, "test-agent", req.Header.Get("User-Agent"))
+		assert.Equal(t, "application/vnd.github+json", req.Header.Get("Accept"))
+		assert.Equal(t, apiVersion, req.Header.Get("X-GitHub-Api-Version"))
+	}
+}
+
+func TestDownloader_GetGlobal(t *testing.T) {
+	var (
+		fake = newFakeGitHub(t)
+		d    = fake.downloader()
+	)
+
+	for _, ref := range []string{
+		fake.URL + "/web/advisories/GHSA-cpj6-fhp6-mr6j",
+		fake.URL + "/advisories/GHSA-cpj6-fhp6-mr6j",
+	} {
+		assert.True(t, d.IsGlobal(ref), ref)
+		got, retrievedAt, err := d.GetGlobal(context.Background(), ref)
+		if assert.NoError(t, err, ref) {
+			assert.Equal(t, "GHSA-cpj6-fhp6-mr6j", got.ID)
+			assert.Equal(t, 0.00022, got.EPSP.Percentage)
+			assert.Equal(t, fakeGlobalDate, retrievedAt)
+		}
+	}
+
+	_, _, err := d.GetGlobal(context.Background(), fake.URL+"/advisories/GHSA-0000-0000-0000")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	for _, ref := range []string{
+		fake.URL + "/web/golang-jwt/jwt/security/advisories/GHSA-mh63-6h87-95cp",
+		fake.URL + "/web/advisories",
+		fake.URL + "/web/advisories/GHSA-cpj6-fhp6-mr6j/dependabot",
+		"https://example.com/advisories/GHSA-cpj6-fhp6-mr6j",
+	} {
+		assert.False(t, d.IsGlobal(ref), ref)
+		_, _, err = d.GetGlobal(context.Background(), ref)
+		assert.ErrorIs(t, err, ErrInvalidURL, ref)
+	}
+}
+
+func TestDownloader_List(t *testing.T) {
+	var (
+		fake = newFakeGitHub(t)
+	)
+
+	tests := []struct {
+		name    string
+		query   ListQuery
+		wantIDs []string
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "Happy path: Repository",
+			query:   ListQuery{Owner: "golang-jwt", Repo: "jwt"},
+			wantIDs: []string{"GHSA-mh63-6h87-95cp"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:  "Happy path: Organization with several pages",
+			query: ListQuery{Owner: fakePaginatedOrg, State: "published"},
+			wantIDs: []string{
+				"GHSA-page-0000-0000", "GHSA-page-0000-0001", "GHSA-page-0000-0002", "GHSA-page-0000-0003",
+				"GHSA-page-0000-0004",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:  "Err: No owner",
+			query: ListQuery{Repo: "jwt"},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorContains(t, err, "owner is missing")
+			},
+		},
+		{
+			name:  "Err: Unknown organization",
+			query: ListQuery{Owner: "unknown"},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrNotFound)
+			},
+		},
+		{
+			name:  "Err: Rate limit exceeded",
+			query: ListQuery{Owner: fakeRateLimitedOwner},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrRateLimited)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fake.downloader().List(context.Background(), tt.query)
+			tt.wantErr(t, err)
+
+			var gotIDs []string
+			for _, adv := range got {
+				gotIDs = append(gotIDs, adv.GhsaID)
 			}
+			assert.Equal(t, tt.wantIDs, gotIDs)
+		})
+	}
+}
+
+func TestDownloader_ListForeignNextPage(t *testing.T) {
+	var (
+		fake            = newFakeGitHub(t)
+		foreignRequests atomic.Int32
+	)
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		foreignRequests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("[]"))
+	}))
+	t.Cleanup(foreign.Close)
+
+	tests := []struct {
+		name string
+		base string
+	}{
+		{name: "Foreign host", base: foreign.URL},
+		{name: "Foreign scheme", base: "https://" + strings.TrimPrefix(fake.URL, "http://")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake.nextPageBase = tt.base
+			got, err := fake.downloader(WithToken("secret")).List(context.Background(), ListQuery{Owner: fakePaginatedOrg})
+			assert.ErrorContains(t, err, "refusing to follow next page URL "+tt.base)
+			assert.Nil(t, got)
+			assert.Zero(t, foreignRequests.Load())
 		})
 	}
 }
 
+// assertNilAdvisory asserts that got is a nil *ghsarepository.Advisory.
+func assertNilAdvisory(t assert.TestingT, got interface{}, _ ...interface{}) bool {
+	gotGhsa, ok := got.(*ghsarepository.Advisory)
+	if !ok {
+		t.Errorf("got = %v, want *ghsarepository.Advisory", got)
+		return false
+	}
+	return gotGhsa == nil
+}
+
 func TestCheckURL(t *testing.T) {
 	type args struct {
 		urlStr string
@@ -134,6 +327,24 @@ func TestCheckURL(t *testing.T) {
 				return assert.Contains(t, err.Error(), "unsupported URL")
 			},
 		},
+		{
+			name: "Empty owner",
+			args: args{
+				urlStr: "https://github.com//jwt/security/advisories/GHSA-mh63-6h87-95cp",
+			},
+			want: "",
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.Contains(t, err.Error(), "unsupported URL")
+			},
+		},
+		{
+			name: "Escaped path segment",
+			args: args{
+				urlStr: "https://github.com/golang-jwt/%00/security/advisories/GHSA-mh63-6h87-95cp",
+			},
+			want:    "https://api.github.com/repos/golang-jwt/%00/security-advisories/GHSA-mh63-6h87-95cp",
+			wantErr: assert.NoError,
+		},
 		{
 			name: "Invalid URL (parsing error)",
 			args: args{
@@ -155,3 +366,169 @@ func TestCheckURL(t *testing.T) {
 		})
 	}
 }
+
+func TestDownloader_normalize(t *testing.T) {
+	type fields struct {
+		opts []DownloaderOption
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		urlStr  string
+		want    string
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "GitHub Enterprise Server: API URL",
+			fields:  fields{opts: []DownloaderOption{WithBaseURLs("https://ghe.example.com/api/v3", "https://ghe.example.com")}},
+			urlStr:  "https://ghe.example.com/api/v3/repos/owner/repo/security-advisories/GHSA-mh63-6h87-95cp",
+			want:    "https://ghe.example.com/api/v3/repos/owner/repo/security-advisories/GHSA-mh63-6h87-95cp",
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "GitHub Enterprise Server: Browser URL",
+			fields:  fields{opts: []DownloaderOption{WithBaseURLs("https://ghe.example.com/api/v3/", "https://ghe.example.com/")}},
+			urlStr:  "https://ghe.example.com/owner/repo/security/advisories/GHSA-mh63-6h87-95cp",
+			want:    "https://ghe.example.com/api/v3/repos/owner/repo/security-advisories/GHSA-mh63-6h87-95cp",
+			wantErr: assert.NoError,
+		},
+		{
+			name:   "GitHub Enterprise Server: github.com URL",
+			fields: fields{opts: []DownloaderOption{WithBaseURLs("https://ghe.example.com/api/v3", "https://ghe.example.com")}},
+			urlStr: "https://github.com/golang-jwt/jwt/security/advisories/GHSA-mh63-6h87-95cp",
+			want:   "",
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorContains(t, err, "https://ghe.example.com/OWNER/REPO/security/advisories/GHSA_ID")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewDownloader(tt.fields.opts...).normalize(tt.urlStr)
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDownloader_Get_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	got, err := NewDownloader().Get(ctx, "https://github.com/golang-jwt/jwt/security/advisories/GHSA-mh63-6h87-95cp")
+	assert.Nil(t, got)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDownloader_Create(t *testing.T) {
+	var (
+		fake = newFakeGitHub(t)
+		req  = &ghsarepository.CreateRequest{
+			Summary:     "Excessive memory allocation during header parsing",
+			Description: "Excessive memory allocation",
+			Vulnerabilities: []ghsarepository.CreateVulnerability{{
+				Package:                ghsarepository.Package{Ecosystem: "go", Name: "github.com/golang-jwt/jwt/v5"},
+				VulnerableVersionRange: "<= 5.2.1",
+			}},
+		}
+	)
+
+	got, err := fake.downloader(WithToken("secret")).Create(context.Background(), "golang-jwt", "jwt", req)
+	if assert.NoError(t, err) {
+		assert.Equal(t, req.Summary, got.Summary)
+		assert.Equal(t, "triage", got.State)
+	}
+	last := fake.lastRequest()
+	if assert.NotNil(t, last) {
+		assert.Equal(t, http.MethodPost, last.Method)
+		assert.Equal(t, "/repos/golang-jwt/jwt/security-advisories", last.URL.Path)
+		assert.Equal(t, "application/json", last.Header.Get("Content-Type"))
+	}
+
+	_, err = fake.downloader().Create(context.Background(), "golang-jwt", "jwt", req)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	_, err = fake.downloader(WithToken("secret")).Create(context.Background(), "golang-jwt", "jwt",
+		&ghsarepository.CreateRequest{})
+	var httpErr *HTTPError
+	if assert.ErrorAs(t, err, &httpErr) {
+		assert.Equal(t, http.StatusUnprocessableEntity, httpErr.StatusCode)
+	}
+	_, err = fake.downloader().Create(context.Background(), "golang-jwt", "", req)
+	assert.ErrorContains(t, err, "owner or repository is missing")
+}
+
+func TestNextPageURL(t *testing.T) {
+	tests := []struct {
+		name string
+		link string
+		want string
+	}{
+		{
+			name: "Next and last page",
+			link: `<https://api.github.com/orgs/o/security-advisories?page=2>; rel="next", <https://api.github.com/orgs/o/security-advisories?page=5>; rel="last"`,
+			want: "https://api.github.com/orgs/o/security-advisories?page=2",
+		},
+		{
+			name: "Last page",
+			link: `<https://api.github.com/orgs/o/security-advisories?page=4>; rel="prev", <https://api.github.com/orgs/o/security-advisories?page=1>; rel="first"`,
+			want: "",
+		},
+		{
+			name: "No link header",
+			link: "",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, nextPageURL(tt.link))
+		})
+	}
+}
+
+func FuzzNormalizeGHSAURL(f *testing.F) {
+	addExampleURLs(f, "../examples/repository_GHSA/*.json")
+	addExampleURLs(f, "../examples/global_GHSA/*.json")
+	f.Add(":")
+	f.Fuzz(func(t *testing.T, urlStr string) {
+		got, err := normalizeGHSAURL(urlStr)
+		if err != nil {
+			return
+		}
+
+		// Normalization must be idempotent
+		again, err := normalizeGHSAURL(got)
+		if err != nil {
+			t.Fatalf("normalizeGHSAURL(%q) = %q is not accepted: %v", urlStr, got, err)
+		}
+		if again != got {
+			t.Fatalf("normalizeGHSAURL() is not idempotent: %q -> %q -> %q", urlStr, got, again)
+		}
+	})
+}
+
+// addExampleURLs adds the API and web URLs (url and html_url) of all advisories matching pattern as seeds to the fuzz
+// corpus.
+func addExampleURLs(f *testing.F, pattern string) {
+	f.Helper()
+
+	files, _ := filepath.Glob(pattern)
+	if len(files) == 0 {
+		f.Fatalf("no examples match '%s'", pattern)
+	}
+	for _, file := range files {
+		var adv struct {
+			URL     string `json:"url"`
+			HTMLURL string `json:"html_url"`
+		}
+		b, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatalf("could not read '%s': %v", file, err)
+		}
+		if err = json.Unmarshal(b, &adv); err != nil {
+			f.Fatalf("could not unmarshal '%s': %v", file, err)
+		}
+		f.Add(adv.URL)
+		f.Add(adv.HTMLURL)
+	}
+}
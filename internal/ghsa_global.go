@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/global"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+)
+
+// globalPublisher is used as publisher of global advisories, which are curated by GitHub in the GitHub Advisory
+// Database and carry no publisher of their own.
+var globalPublisher = repository.User{
+	Login:   "GitHub",
+	HTMLURL: "https://github.com",
+}
+
+// GlobalToCSAF converts a global GitHub Security Advisory into a CSAF advisory.
+// See ToCSAF.
+func GlobalToCSAF(g *global.Advisory, opts ...ConvertOption) (csafadvisory *csaf.Advisory, err error) {
+	if g == nil {
+		return ToCSAF(nil, opts...)
+	}
+	return ToCSAF(globalToRepository(g), append(opts, withGlobal(g))...)
+}
+
+// globalToRepository maps a global advisory onto the repository advisory model, which is the input of the conversion.
+// Fields only available for repository advisories (e.g. collaborators) stay empty.
+func globalToRepository(g *global.Advisory) (adv *repository.Advisory) {
+	if g == nil {
+		return nil
+	}
+
+	adv = &repository.Advisory{
+		GhsaID:      g.ID,
+		CveID:       g.CveID,
+		URL:         g.URL,
+		HTMLURL:     g.HTMLURL,
+		Summary:     g.Summary,
+		Description: g.Description,
+		Severity:    g.Severity,
+		Publisher:   globalPublisher,
+		State:       "published",
+		UpdatedAt:   formatGlobalTime(g.UpdatedAt),
+		PublishedAt: formatGlobalTime(g.PublishedAt),
+		CVSS:        globalCVSSToRepository(g.CVSS),
+		CVSSSeverities: repository.CVSSSeverities{
+			CVSSv3: globalCVSSToRepository(g.CVSSSeverities.CVSSv3),
+			CVSSv4: globalCVSSToRepository(g.CVSSSeverities.CVSSv4),
+		},
+	}
+	if g.WithdrawnAt != nil {
+		adv.WithdrawnAt = formatGlobalTime(*g.WithdrawnAt)
+	}
+
+	for _, id := range g.Identifiers {
+		adv.Identifiers = append(adv.Identifiers, repository.Identifier{Value: id.Value, Type: repository.IdentifierType(id.Type)})
+	}
+
+	for _, v := range g.Vulnerabilities {
+		adv.Vulnerabilities = append(adv.Vulnerabilities, repository.Vulnerability{
+			Package:                repository.Package(v.Package),
+			VulnerableVersionRange: v.VulnerableVersionRange,
+			PatchedVersions:        v.FirstPatchedVersion,
+			VulnerableFunctions:    v.VulnerableFunctions,
+		})
+	}
+
+	for _, cwe := range g.CWEs {
+		adv.CWEs = append(adv.CWEs, repository.CWE(cwe))
+		adv.CWEIds = append(adv.CWEIds, cwe.CWEID)
+	}
+
+	for _, c := range g.Credits {
+		adv.Credits = append(adv.Credits, repository.Credit{Login: c.User.Login, Type: c.Type})
+		adv.CreditsDetailed = append(adv.CreditsDetailed, repository.CreditDetailed{
+			User: repository.User{
+				Login:            c.User.Login,
+				ID:               int64(c.User.ID),
+				NodeID:           c.User.NodeID,
+				AvatarURL:        c.User.AvatarURL,
+				URL:              c.User.URL,
+				HTMLURL:          c.User.HTMLURL,
+				OrganizationsURL: c.User.OrganizationsURL,
+				Type:             c.User.Type,
+				SiteAdmin:        c.User.SiteAdmin,
+			},
+			Type:  c.Type,
+			State: "accepted",
+		})
+	}
+	return adv
+}
+
+// globalCVSSToRepository converts a CVSS score of a global advisory.
+func globalCVSSToRepository(cvss global.CVSS) repository.CVSS {
+	return repository.CVSS{VectorString: cvss.VectorString, Score: cvss.Score}
+}
+
+// formatGlobalTime formats a timestamp of a global advisory the way the GitHub API formats timestamps of repository
+// advisories (ISO 8601 in UTC). The zero time results in an empty string.
+func formatGlobalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -1,8 +1,15 @@
 package internal
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/csaf-poc/ghsa/internal/utils"
 	"github.com/csaf-poc/ghsa/models/csaf"
@@ -12,64 +19,577 @@ import (
 
 const documentCategory = "GitHub Security Advisory"
 
-func ToCSAF(a *repository.Advisory) (csafadvisory *csaf.Advisory, err error) {
+// vulnerabilityIDSystemName is the name of the system issuing the GHSA IDs.
+const vulnerabilityIDSystemName = "GitHub Security Advisory"
+
+// generatorEngine is the name of the engine noted as generator of the CSAF documents.
+const generatorEngine = "csaf-poc/ghsa"
+
+// publisherAcknowledgement is the summary of the acknowledgment of the GitHub user who published the GHSA.
+const publisherAcknowledgement = "Published the GitHub Security Advisory"
+
+// Version is the version of the converter noted as generator engine version of the CSAF documents.
+// It can be set at build time with -ldflags "-X github.com/csaf-poc/ghsa/internal.Version=VERSION".
+var Version = "devel"
+
+var (
+	cvePattern  = regexp.MustCompile(`^CVE-[0-9]{4}-[0-9]{4,}$`)
+	cwePattern  = regexp.MustCompile(`^CWE-[1-9]\d{0,5}$`)
+	ghsaPattern = regexp.MustCompile(`^GHSA(-[0-9a-z]{4}){3}$`)
+	// timestampPattern is the ISO 8601 date-time format required by CSAF. time.Parse alone is more lenient, e.g. it
+	// accepts single digit hours.
+	timestampPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})$`)
+)
+
+// advisoryState is the tracking status and TLP label of CSAF documents converted from advisories in a GHSA state.
+type advisoryState struct {
+	status gocsaf.TrackingStatus
+	tlp    gocsaf.TLPLabel
+}
+
+// advisoryStates maps the states of repository advisories. Advisories that are not published are only visible to
+// maintainers and collaborators of the repository (and the reporter), so they become drafts with a restrictive TLP
+// label: reports awaiting triage and closed (rejected) advisories are TLP:RED, drafts worked on with collaborators are
+// TLP:AMBER.
+var advisoryStates = map[string]advisoryState{
+	// Global advisories and older exports carry no state, but are published
+	"":          {status: gocsaf.CSAFTrackingStatusFinal, tlp: gocsaf.TLPLabelWhite},
+	"published": {status: gocsaf.CSAFTrackingStatusFinal, tlp: gocsaf.TLPLabelWhite},
+	"draft":     {status: gocsaf.CSAFTrackingStatusDraft, tlp: gocsaf.TLPLabelAmber},
+	"triage":    {status: gocsaf.CSAFTrackingStatusDraft, tlp: gocsaf.TLPLabelRed},
+	"closed":    {status: gocsaf.CSAFTrackingStatusDraft, tlp: gocsaf.TLPLabelRed},
+}
+
+// getAdvisoryState returns the tracking status and TLP label for the state of the advisory. Unknown states are treated
+// like drafts awaiting triage and ok is false.
+func getAdvisoryState(adv *repository.Advisory) (state advisoryState, ok bool) {
+	state, ok = advisoryStates[adv.State]
+	if !ok {
+		state = advisoryStates["triage"]
+	}
+	return
+}
+
+// isPublished reports whether the advisory is published, i.e. it is not a draft.
+func isPublished(adv *repository.Advisory) bool {
+	state, _ := getAdvisoryState(adv)
+	return state.status != gocsaf.CSAFTrackingStatusDraft
+}
+
+// ToCSAF converts a repository GitHub Security Advisory into a CSAF advisory.
+// Errors of the sections (document, product tree and vulnerabilities) are collected and returned as
+// *ConversionError. In the default strict mode no advisory is returned if any section fails. See WithLenient for
+// returning a best-effort advisory instead.
+func ToCSAF(a *repository.Advisory, opts ...ConvertOption) (csafadvisory *csaf.Advisory, err error) {
 	var (
-		d  *csaf.Document
-		pt *csaf.ProductTree
-		v  csaf.Vulnerabilities
+		c       = newConverter(opts...)
+		convErr = &ConversionError{}
+		d       *csaf.Document
+		pt      *csaf.ProductTree
+		v       csaf.Vulnerabilities
+		v21     []*csaf.Vulnerability21
 	)
 
-	d, err = getDocument(a)
-	if err != nil {
-		err = fmt.Errorf("could not extract csaf document: %v", err)
+	if a == nil {
+		err = errors.New("no advisory")
+		return nil, err
 	}
-	pt, err = getProductTree(a)
-	if err != nil {
-		err = fmt.Errorf("could not extract csaf product tree: %v", err)
+	if err = errors.Join(checkCSAFVersion(c.version), checkProfile(c.profile)); err != nil {
+		return nil, err
 	}
-	v, err = getVulnerabilities(a)
-	if err != nil {
-		err = fmt.Errorf("could not extract csaf vulnerabilities: %v", err)
+
+	d, err = c.getDocument(a)
+	convErr.add(SectionDocument, err)
+	pt, err = c.getProductTree(a)
+	convErr.add(SectionProductTree, err)
+	v, v21, err = c.getVulnerabilities(a)
+	convErr.add(SectionVulnerabilities, err)
+
+	if len(convErr.Errors) > 0 {
+		err = convErr
+		if !c.lenient {
+			return nil, err
+		}
+	} else {
+		err = nil
 	}
 
-	csafadvisory = &csaf.Advisory{
+	csafadvisory = &csaf.Advisory{Advisory: gocsaf.Advisory{
 		Document:        d,
 		ProductTree:     pt,
 		Vulnerabilities: v,
+	}}
+	if c.csaf21() {
+		csafadvisory.CSAF21 = &csaf.CSAF21{Document: c.getDocument21(a), Vulnerabilities: v21}
+		if getWithdrawnAt(a) != "" {
+			// Documents of the withdrawn profile must not list products or vulnerabilities
+			csafadvisory.ProductTree, csafadvisory.Vulnerabilities, csafadvisory.CSAF21.Vulnerabilities = nil, nil, nil
+		}
 	}
 	return
 }
 
+// checkDocument checks that the advisory provides the fields the required fields of the CSAF document are derived
+// from. Returns all problems found.
+func checkDocument(adv *repository.Advisory) error {
+	var (
+		errs []error
+	)
+	if !ghsaPattern.MatchString(adv.GhsaID) {
+		errs = append(errs, fmt.Errorf("invalid GHSA ID '%s'", adv.GhsaID))
+	}
+	if adv.Summary == "" {
+		errs = append(errs, errors.New("summary is missing"))
+	}
+	if _, ok := getAdvisoryState(adv); !ok {
+		errs = append(errs, fmt.Errorf("unknown state '%s'", adv.State))
+	}
+	if !isTimestamp(*getInitialReleaseDate(adv)) {
+		errs = append(errs, fmt.Errorf("invalid release date '%s'", *getInitialReleaseDate(adv)))
+	}
+	if adv.UpdatedAt != "" && !isTimestamp(adv.UpdatedAt) {
+		errs = append(errs, fmt.Errorf("invalid update date '%s'", adv.UpdatedAt))
+	}
+	if withdrawnAt := getWithdrawnAt(adv); withdrawnAt != "" && !isTimestamp(withdrawnAt) {
+		errs = append(errs, fmt.Errorf("invalid withdrawal date '%s'", withdrawnAt))
+	}
+	return errors.Join(errs...)
+}
+
+// checkPublisher checks the configured publisher or, if none is configured, the GitHub user who published the GHSA.
+func (c *converter) checkPublisher(adv *repository.Advisory) error {
+	var (
+		errs []error
+	)
+	if c.publisher != nil {
+		return c.publisher.Validate()
+	}
+	if adv.Publisher.Login == "" {
+		errs = append(errs, errors.New("publisher is missing"))
+	}
+	if !isURL(adv.Publisher.HTMLURL) {
+		errs = append(errs, fmt.Errorf("invalid publisher URL '%s'", adv.Publisher.HTMLURL))
+	}
+	return errors.Join(errs...)
+}
+
+// isURL reports whether s is an absolute URL.
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.IsAbs()
+}
+
+// isTimestamp reports whether s is a valid ISO 8601 date-time.
+func isTimestamp(s string) bool {
+	if !timestampPattern.MatchString(s) {
+		return false
+	}
+	_, err := time.Parse(time.RFC3339, s)
+	return err == nil
+}
+
 // TODO(lebogg): Test it
 // TODO(lebogg): Check if all required (sub) fields are set!
 // TODO(lebogg): For names we currently use login names because these are mandatory while names arent. BUT logins can change so maybe we should combine it with id (number)?
 // TODO(lebogg): Currently, we only provide the document but we do not provide the vulnerabilities -> return advisory
-func getDocument(adv *repository.Advisory) (doc *csaf.Document, err error) {
+func (c *converter) getDocument(adv *repository.Advisory) (doc *csaf.Document, err error) {
+	distribution, distributionErr := c.getDistribution(adv)
+	notes := append(getNotes(adv), c.getProfileNotes()...)
+	if note := c.getWithdrawalNote(adv); note != nil {
+		notes = append(notes, note)
+	}
+
 	doc = &csaf.Document{
-		Acknowledgements:  getAcknowledgements(adv),
-		AggregateSeverity: nil,           // n/a in GHSA
-		Category:          getCategory(), // required
-		CSAFVersion:       getVersion(),  // required
-		Distribution:      getDistribution(),
+		Acknowledgements:  c.getAcknowledgements(adv),
+		AggregateSeverity: getAggregateSeverity(adv),
+		Category:          c.getCategory(), // required
+		CSAFVersion:       getVersion(),    // required
+		Distribution:      distribution,
 		Lang:              getLang(adv), // no language info in GHSA, default to "en"
-		Notes:             getNotes(adv),
-		Publisher:         getPublisher(&adv.Publisher), // required
-		References:        nil,                          // TODO(lebogg): Implement (optional)
-		SourceLang:        nil,                          // TODO(lebogg): Implement (optional)
-		Title:             getTitle(adv),                // required
-		Tracking:          getTracking(adv),             // required
+		Notes:             notes,
+		Publisher:         c.getPublisher(adv), // required
+		References:        c.getReferences(adv),
+		SourceLang:        nil,              // TODO(lebogg): Implement (optional)
+		Title:             getTitle(adv),    // required
+		Tracking:          getTracking(adv), // required
 	}
+	err = errors.Join(checkDocument(adv), checkDocumentCategory(string(*doc.Category)), c.checkPublisher(adv),
+		distributionErr)
 	return
 }
 
-// TODO(lebogg): Implement
-func getProductTree(_ *repository.Advisory) (*csaf.ProductTree, error) {
-	panic("TODO")
+// product is a product derived from a GHSA vulnerability: either its vulnerable version range or one of its patched
+// versions.
+type product struct {
+	id  gocsaf.ProductID
+	pkg repository.Package
+	// version is the vulnerable version range (if affected) or the patched version (if not affected)
+	version  string
+	affected bool
 }
 
-// TODO(lebogg): Implement
-func getVulnerabilities(_ *repository.Advisory) (csaf.Vulnerabilities, error) {
-	panic("TODO")
+// getProducts derives the products of all GHSA vulnerabilities. Each vulnerable version range and each patched version
+// of a package becomes one product. Product IDs are assigned in order of appearance, so the result is deterministic.
+// The second return value maps the index of a GHSA vulnerability to the products derived from it.
+func getProducts(adv *repository.Advisory) (products []*product, byVulnerability [][]*product) {
+	var (
+		seen = make(map[string]*product)
+	)
+
+	add := func(pkg repository.Package, version string, affected bool) *product {
+		key := fmt.Sprintf("%s|%s|%s|%t", pkg.Ecosystem, pkg.Name, version, affected)
+		if p, ok := seen[key]; ok {
+			return p
+		}
+		p := &product{
+			id:       gocsaf.ProductID(fmt.Sprintf("CSAFPID-%04d", len(products)+1)),
+			pkg:      pkg,
+			version:  version,
+			affected: affected,
+		}
+		seen[key] = p
+		products = append(products, p)
+		return p
+	}
+
+	byVulnerability = make([][]*product, len(adv.Vulnerabilities))
+	for i, v := range adv.Vulnerabilities {
+		if v.Package.Name == "" {
+			continue
+		}
+		byVulnerability[i] = append(byVulnerability[i], add(v.Package, v.VulnerableVersionRange, true))
+		for _, patched := range getPatchedVersions(&v) {
+			byVulnerability[i] = append(byVulnerability[i], add(v.Package, patched, false))
+		}
+	}
+	return
+}
+
+// getPatchedVersions splits the patched versions of a GHSA vulnerability (e.g. "1.2.3, 2.0.1").
+func getPatchedVersions(v *repository.Vulnerability) (versions []string) {
+	for _, version := range strings.Split(v.PatchedVersions, ",") {
+		if version = strings.TrimSpace(version); version != "" {
+			versions = append(versions, version)
+		}
+	}
+	return
+}
+
+// getProductTree builds the product tree out of the products of the GHSA vulnerabilities.
+// Each package becomes a product_name branch. Its vulnerable version ranges become product_version_range branches (named as vers range) and its patched versions become product_version branches.
+// Returns nil if the advisory has no products.
+func (c *converter) getProductTree(adv *repository.Advisory) (pt *csaf.ProductTree, err error) {
+	var (
+		branches = make(map[repository.Package]*gocsaf.Branch)
+		roots    gocsaf.Branches
+	)
+
+	for i, v := range adv.Vulnerabilities {
+		if v.Package.Name == "" {
+			err = errors.Join(err, fmt.Errorf("vulnerability %d has no package name", i+1))
+		}
+	}
+
+	products, _ := getProducts(adv)
+	err = errors.Join(err, c.checkProducts(products))
+	if len(products) == 0 {
+		return nil, err
+	}
+
+	for _, p := range products {
+		root, ok := branches[p.pkg]
+		if !ok {
+			root = &gocsaf.Branch{
+				Category: utils.Ref(gocsaf.CSAFBranchCategoryProductName),
+				Name:     utils.Ref(p.pkg.Name),
+			}
+			branches[p.pkg] = root
+			roots = append(roots, root)
+		}
+
+		branch := &gocsaf.Branch{
+			Category: utils.Ref(gocsaf.CSAFBranchCategoryProductVersion),
+			Name:     utils.Ref(p.version),
+			Product: &gocsaf.FullProductName{
+				Name:      utils.Ref(p.pkg.Name + " " + p.version),
+				ProductID: utils.Ref(p.id),
+				ProductIdentificationHelper: &gocsaf.ProductIdentificationHelper{
+					PURL: utils.Ref(gocsaf.PURL(packageURL(p.pkg.Ecosystem, p.pkg.Name, p.version))),
+				},
+			},
+		}
+		if p.affected {
+			// Ranges are identified by the package URL without version
+			branch.Category = utils.Ref(gocsaf.CSAFBranchCategoryProductVersionRange)
+			branch.Name = utils.Ref(versRange(p.pkg.Ecosystem, p.version))
+			branch.Product.ProductIdentificationHelper.PURL = utils.Ref(gocsaf.PURL(packageURL(p.pkg.Ecosystem, p.pkg.Name, "")))
+		}
+		root.Branches = append(root.Branches, branch)
+	}
+
+	pt = &csaf.ProductTree{Branches: roots}
+	return pt, err
+}
+
+// getVulnerabilities converts the advisory into a single CSAF vulnerability, since a GHSA describes exactly one
+// vulnerability affecting one or more packages. The properties of CSAF 2.1 are returned separately as v21.
+func (c *converter) getVulnerabilities(adv *repository.Advisory) (
+	vulns csaf.Vulnerabilities, v21 []*csaf.Vulnerability21, err error,
+) {
+	products, byVulnerability := getProducts(adv)
+	scores, scoresErr := getScores(adv, products)
+	epss, epssErr := c.getEPSS()
+	weaknesses, weaknessesErr := getWeaknesses(adv)
+	err = errors.Join(scoresErr, epssErr, weaknessesErr)
+
+	v := &gocsaf.Vulnerability{
+		CVE:           getCVE(adv),
+		IDs:           getVulnerabilityIDs(adv),
+		ProductStatus: getProductStatus(products),
+		ReleaseDate:   getReleaseDate(adv),
+		References:    c.getVulnerabilityReferences(),
+		Remediations:  c.getRemediations(adv, byVulnerability),
+		Scores:        scores,
+		Title:         getTitle(adv),
+	}
+	if getWithdrawnAt(adv) != "" {
+		// The vulnerability was retracted, so consumers must not act on product statuses, remediations or scores
+		v.ProductStatus, v.Remediations, v.Scores, epss = nil, nil, nil, nil
+	}
+	ext := &csaf.Vulnerability21{}
+	if c.csaf21() {
+		var (
+			metricsErr error
+			rest       []weakness
+		)
+		ext.CWEs, rest = getCWEs(weaknesses)
+		v.Notes = getWeaknessNotes(rest)
+		ext.DisclosureDate = adv.PublishedAt
+		ext.Metrics, metricsErr = getMetrics(adv, v.Scores, products)
+		ext.Metrics = append(ext.Metrics, getEPSSMetrics(epss, products)...)
+		err = errors.Join(err, metricsErr)
+	} else {
+		var rest []weakness
+		v.CWE, rest = getCWE(weaknesses)
+		v.Notes = getWeaknessNotes(rest)
+		v.Threats = getEPSSThreats(epss, products)
+	}
+	if c.profile != "" {
+		addProfile(v, adv, products)
+	}
+	vulns = csaf.Vulnerabilities{v}
+	v21 = []*csaf.Vulnerability21{ext}
+	return vulns, v21, err
+}
+
+// getCVE returns the CVE ID of the advisory or nil if it has none.
+func getCVE(adv *repository.Advisory) *gocsaf.CVE {
+	if !cvePattern.MatchString(adv.CveID) {
+		return nil
+	}
+	return utils.Ref(gocsaf.CVE(adv.CveID))
+}
+
+// getVulnerabilityIDs returns the GHSA ID as vulnerability ID.
+func getVulnerabilityIDs(adv *repository.Advisory) gocsaf.VulnerabilityIDs {
+	if adv.GhsaID == "" {
+		return nil
+	}
+	return gocsaf.VulnerabilityIDs{{
+		SystemName: utils.Ref(vulnerabilityIDSystemName),
+		Text:       utils.Ref(adv.GhsaID),
+	}}
+}
+
+// getProductStatus lists the vulnerable version ranges as known affected and the patched versions as fixed.
+func getProductStatus(products []*product) *gocsaf.ProductStatus {
+	var (
+		affected, fixed gocsaf.Products
+	)
+	if len(products) == 0 {
+		return nil
+	}
+
+	for _, p := range products {
+		if p.affected {
+			affected = append(affected, utils.Ref(p.id))
+		} else {
+			fixed = append(fixed, utils.Ref(p.id))
+		}
+	}
+
+	status := &gocsaf.ProductStatus{}
+	if len(affected) > 0 {
+		status.KnownAffected = &affected
+	}
+	if len(fixed) > 0 {
+		status.Fixed = &fixed
+	}
+	return status
+}
+
+// getReleaseDate returns the publication date of the advisory or nil if it was not published.
+func getReleaseDate(adv *repository.Advisory) *string {
+	if adv.PublishedAt == "" {
+		return nil
+	}
+	return &adv.PublishedAt
+}
+
+// getRemediations creates one remediation per GHSA vulnerability for its vulnerable version range: a vendor fix if
+// patched versions exist and none_available otherwise. Vendor fixes link to the fix of their package (e.g. the fix
+// commit, see getFixURL) if it is known.
+func (c *converter) getRemediations(adv *repository.Advisory, byVulnerability [][]*product) (remediations gocsaf.Remediations) {
+	var (
+		packages []repository.Package
+	)
+	for _, v := range adv.Vulnerabilities {
+		if !slices.Contains(packages, v.Package) {
+			packages = append(packages, v.Package)
+		}
+	}
+
+	for i, products := range byVulnerability {
+		if len(products) == 0 {
+			continue
+		}
+		// The first product of a vulnerability is its vulnerable version range
+		affected := gocsaf.Products{utils.Ref(products[0].id)}
+
+		var fix *string
+		if u := c.getFixURL(products[0].pkg, len(packages) == 1); u != "" {
+			fix = &u
+		}
+
+		patched := getPatchedVersions(&adv.Vulnerabilities[i])
+		switch len(patched) {
+		case 0:
+			remediations = append(remediations, &gocsaf.Remediation{
+				Category:   utils.Ref(gocsaf.CSAFRemediationCategoryNoneAvailable),
+				Details:    utils.Ref("No patched version is available."),
+				ProductIds: &affected,
+			})
+		case 1:
+			remediations = append(remediations, &gocsaf.Remediation{
+				Category:   utils.Ref(gocsaf.CSAFRemediationCategoryVendorFix),
+				Details:    utils.Ref(fmt.Sprintf("Upgrade %s to version %s or later.", products[0].pkg.Name, patched[0])),
+				ProductIds: &affected,
+				URL:        fix,
+			})
+		default:
+			remediations = append(remediations, &gocsaf.Remediation{
+				Category: utils.Ref(gocsaf.CSAFRemediationCategoryVendorFix),
+				Details: utils.Ref(fmt.Sprintf("Upgrade %s to one of the patched versions %s.",
+					products[0].pkg.Name, strings.Join(patched, ", "))),
+				ProductIds: &affected,
+				URL:        fix,
+			})
+		}
+	}
+	return
+}
+
+// getScores converts the CVSS v3 score of the advisory. It applies to all affected products.
+// CVSS v4 cannot be expressed in CSAF 2.0 and is omitted. An invalid CVSS v3 score is omitted and reported as error.
+func getScores(adv *repository.Advisory, products []*product) (scores gocsaf.Scores, err error) {
+	var (
+		affected gocsaf.Products
+		cvss     = adv.CVSSSeverities.CVSSv3
+	)
+
+	// Older advisories only provide the cvss field
+	if cvss.VectorString == "" {
+		cvss = adv.CVSS
+	}
+	if cvss.VectorString == "" {
+		return nil, nil
+	}
+	version, ok := cvss3Version(cvss.VectorString)
+	if !ok {
+		err = fmt.Errorf("invalid CVSS v3 vector string '%s'", cvss.VectorString)
+		return nil, err
+	}
+	if cvss.Score < 0 || cvss.Score > 10 {
+		err = fmt.Errorf("invalid CVSS v3 base score %v", cvss.Score)
+		return nil, err
+	}
+
+	for _, p := range products {
+		if p.affected {
+			affected = append(affected, utils.Ref(p.id))
+		}
+	}
+	if len(affected) == 0 {
+		return nil, nil
+	}
+
+	scores = gocsaf.Scores{{
+		CVSS3: &gocsaf.CVSS3{
+			Version:      utils.Ref(version),
+			VectorString: utils.Ref(gocsaf.CVSS3VectorString(cvss.VectorString)),
+			BaseScore:    utils.Ref(cvss.Score),
+			BaseSeverity: utils.Ref(cvss3Severity(cvss.Score)),
+		},
+		Products: &affected,
+	}}
+	return scores, nil
+}
+
+// cvss3Version returns the CVSS version of a CVSS v3 vector string. ok is false if the vector string is invalid.
+func cvss3Version(vector string) (version gocsaf.CVSSVersion3, ok bool) {
+	var (
+		vs gocsaf.CVSS3VectorString
+	)
+	if vs.UnmarshalText([]byte(vector)) != nil {
+		return "", false
+	}
+
+	switch {
+	case strings.HasPrefix(vector, "CVSS:3.1/"):
+		return gocsaf.CVSSVersion31, true
+	case strings.HasPrefix(vector, "CVSS:3.0/"):
+		return gocsaf.CVSSVersion30, true
+	default:
+		return "", false
+	}
+}
+
+// cvss3Severity returns the qualitative severity rating of a CVSS v3 base score.
+func cvss3Severity(score float64) gocsaf.CVSS3Severity {
+	switch {
+	case score >= 9.0:
+		return gocsaf.CVSS3SeverityCritical
+	case score >= 7.0:
+		return gocsaf.CVSS3SeverityHigh
+	case score >= 4.0:
+		return gocsaf.CVSS3SeverityMedium
+	case score > 0:
+		return gocsaf.CVSS3SeverityLow
+	default:
+		return gocsaf.CVSS3SeverityNone
+	}
+}
+
+// getAcknowledgements returns the acknowledgments of the GHSA credits. If a publisher is configured, the GitHub user
+// who published the GHSA is acknowledged as well.
+func (c *converter) getAcknowledgements(adv *repository.Advisory) *gocsaf.Acknowledgements {
+	ack := getAcknowledgements(adv)
+	if c.publisher == nil || adv.Publisher.Login == "" {
+		return ack
+	}
+
+	if ack == nil {
+		ack = &gocsaf.Acknowledgements{}
+	}
+	publisher := &gocsaf.Acknowledgement{
+		Names:   []*string{utils.Ref(adv.Publisher.Login)},
+		Summary: utils.Ref(publisherAcknowledgement),
+	}
+	if isURL(adv.Publisher.HTMLURL) {
+		publisher.URLs = []*string{utils.Ref(adv.Publisher.HTMLURL)}
+	}
+	*ack = append(*ack, publisher)
+	return ack
 }
 
 // getAcknowledgements converts GHSA detailed credits into CSAF acknowledgments.
@@ -91,44 +611,54 @@ func getAcknowledgements(adv *repository.Advisory) *gocsaf.Acknowledgements {
 
 	// Add credited users
 	for _, credit := range adv.CreditsDetailed {
-		ack = append(ack, &gocsaf.Acknowledgement{
+		if credit.User.Login == "" {
+			continue
+		}
+		a := &gocsaf.Acknowledgement{
 			// We use the login as a name because it is required and the full name may be empty
-			Names:        []*string{&credit.User.Login},
-			Organization: &credit.User.OrganizationsURL,
+			Names: []*string{utils.Ref(credit.User.Login)},
 			// Use credit type as summary if available
 			Summary: creditTypeToSummary(credit.Type),
-			URLs:    []*string{&credit.User.HTMLURL},
-		})
+		}
+		if credit.User.OrganizationsURL != "" {
+			a.Organization = utils.Ref(credit.User.OrganizationsURL)
+		}
+		if isURL(credit.User.HTMLURL) {
+			a.URLs = []*string{utils.Ref(credit.User.HTMLURL)}
+		}
+		ack = append(ack, a)
+	}
+	if len(ack) == 0 {
+		return nil
 	}
 	return &ack
 }
 
+// creditTypeSummaries maps the credit types of GHSA to human-readable role descriptions.
+var creditTypeSummaries = map[string]string{
+	"analyst":               "Analyzed impact",
+	"finder":                "Found the vulnerability",
+	"reporter":              "Reported the vulnerability",
+	"coordinator":           "Coordinated disclosure",
+	"remediation_developer": "Provided the fix",
+	"remediation_reviewer":  "Reviewed the fix",
+	"remediation_verifier":  "Verified the fix",
+	"tool":                  "Provided a tool that found the vulnerability",
+	"sponsor":               "Sponsored the work",
+}
+
 // creditTypeToSummary returns a *string with a human-readable role description.
 // Nil is returned if no credit type is provided.
 func creditTypeToSummary(creditType string) (summary *string) {
-	var (
-		phrase string
-	)
-
 	// If no credit type is provided, return nil
 	if creditType == "" {
 		summary = nil
 		return
 	}
 
-	// Map credit type to human-readable phrase
-	switch creditType {
-	case "REPORTER", "FINDER":
-		phrase = "Reported the vulnerability"
-	case "ANALYZER":
-		phrase = "Analyzed impact"
-	case "FIXER":
-		phrase = "Provided the fix"
-	case "REVIEWER":
-		phrase = "Reviewed the fix"
-	case "COORDINATOR":
-		phrase = "Coordinated disclosure"
-	default:
+	// Map credit type to human-readable phrase, GitHub returns the types in lower case
+	phrase, ok := creditTypeSummaries[strings.ToLower(creditType)]
+	if !ok {
 		// Fallback: use raw type
 		phrase = creditType
 	}
@@ -137,24 +667,51 @@ func creditTypeToSummary(creditType string) (summary *string) {
 	return
 }
 
+// getCategory returns the document category of the profile (see WithProfile) or the custom category of the base
+// profile.
+func (c *converter) getCategory() *gocsaf.DocumentCategory {
+	if c.profile != "" {
+		return utils.Ref(gocsaf.DocumentCategory(c.profile))
+	}
+	return getCategory()
+}
+
 func getCategory() *gocsaf.DocumentCategory {
 	cat := gocsaf.DocumentCategory(documentCategory)
 	return &cat
 }
 
-func getVersion() *gocsaf.Version {
-	v := gocsaf.CSAFVersion20 // Currently only CSAF 2.0 is supported
-	return &v
+// profileCategories are the document categories of the CSAF profiles (CSAF 2.1 section 4).
+var profileCategories = []string{
+	csaf.CategoryBase, csaf.CategorySecurityAdvisory, csaf.CategoryVEX, csaf.CategoryWithdrawn,
+	"csaf_informational_advisory", "csaf_security_incident_response", "csaf_deprecated_security_advisory",
+	"csaf_superseded",
 }
 
-func getDistribution() *gocsaf.DocumentDistribution {
-	label := gocsaf.TLPLabel(gocsaf.TLPLabelWhite) // Default TLP label is White
-	dist := gocsaf.DocumentDistribution{
-		TLP: &gocsaf.TLP{
-			DocumentTLPLabel: &label,
-		},
+// checkDocumentCategory checks that a custom document category cannot be mistaken for the category of a CSAF profile
+// (mandatory test 6.1.26): it must not use the reserved prefix csaf_ and must not match the name of a profile (e.g.
+// "Security Advisory") once case, whitespace, hyphens and underscores are ignored.
+func checkDocumentCategory(category string) error {
+	if slices.Contains(profileCategories, category) {
+		return nil
+	}
+	normalize := func(s string) string {
+		return strings.ToLower(strings.NewReplacer(" ", "", "\t", "", "-", "", "_", "").Replace(s))
+	}
+	if strings.HasPrefix(strings.ToLower(category), "csaf_") {
+		return fmt.Errorf("document category '%s' uses the reserved prefix csaf_", category)
 	}
-	return &dist
+	for _, profile := range profileCategories {
+		if normalize(category) == normalize(strings.TrimPrefix(profile, "csaf_")) {
+			return fmt.Errorf("document category '%s' is too similar to profile category %s", category, profile)
+		}
+	}
+	return nil
+}
+
+func getVersion() *gocsaf.Version {
+	v := gocsaf.CSAFVersion20 // CSAF 2.1 documents are derived from CSAF 2.0, see csaf.ToDocument
+	return &v
 }
 
 // getLang extracts the default language as "en" for the given Advisory because GHSA does not provide language
@@ -199,6 +756,14 @@ func getNotes(adv *repository.Advisory) (notes gocsaf.Notes) {
 	return
 }
 
+// getPublisher returns the configured publisher or, if none is configured, the GitHub user who published the GHSA.
+func (c *converter) getPublisher(adv *repository.Advisory) *gocsaf.DocumentPublisher {
+	if c.publisher != nil {
+		return c.publisher.toCSAF()
+	}
+	return getPublisher(&adv.Publisher)
+}
+
 // TODO(lebogg): In the case of GHSA, is GH the publisher or the single persons/entities themselves?
 func getPublisher(ghsapublisher *repository.User) (p *gocsaf.DocumentPublisher) {
 	var (
@@ -217,6 +782,19 @@ func getPublisher(ghsapublisher *repository.User) (p *gocsaf.DocumentPublisher)
 	return
 }
 
+// getAggregateSeverity returns the severity given by GitHub as aggregate severity if the advisory has no CVSS v3 score
+// (see getScores), e.g. OSV records with a CVSS v4 vector only. Returns nil otherwise.
+func getAggregateSeverity(adv *repository.Advisory) *gocsaf.AggregateSeverity {
+	if adv.CVSSSeverities.CVSSv3.VectorString != "" || adv.CVSS.VectorString != "" {
+		return nil
+	}
+	severity := strings.ToLower(adv.Severity)
+	if !slices.Contains(ghsaSeverityLevels, severity) && severity != "moderate" {
+		return nil
+	}
+	return &gocsaf.AggregateSeverity{Text: utils.Ref(strings.ToUpper(severity[:1]) + severity[1:])}
+}
+
 func getTitle(adv *repository.Advisory) *string {
 	if adv.Summary == "" {
 		return nil
@@ -231,67 +809,124 @@ func getTracking(adv *repository.Advisory) (tracking *gocsaf.Tracking) {
 	)
 
 	revisionHistory := getRevisionHistory(adv)
+	state, _ := getAdvisoryState(adv)
+	// Drafts have version 0 (see CSAF 2.0 section 3.1.11.2), released documents count their revisions
+	version := len(revisionHistory)
+	if state.status == gocsaf.CSAFTrackingStatusDraft {
+		version = 0
+	}
 
 	tracking = &gocsaf.Tracking{
 		Aliases: getAliases(adv.Identifiers),
 		// TODO(lebogg):  Check format (is ISO 8601)
 		CurrentReleaseDate: getCurrentReleaseDate(adv), // required
-		Generator:          nil,
+		Generator:          &gocsaf.Generator{Engine: &gocsaf.Engine{Name: utils.Ref(generatorEngine), Version: &Version}},
 		ID:                 &id, // required
 		// TODO(lebogg):  Check format (is ISO 8601)
-		InitialReleaseDate: &adv.PublishedAt,                                             // required. Assumption: UpdatedAt doesn't represent release dates
-		RevisionHistory:    revisionHistory,                                              // required
-		Status:             utils.Ref(gocsaf.CSAFTrackingStatusFinal),                    // required. Assumption: GHSA is final
-		Version:            utils.Ref(gocsaf.RevisionNumber(rune(len(revisionHistory)))), // required
+		InitialReleaseDate: getInitialReleaseDate(adv),                              // required. Assumption: UpdatedAt doesn't represent release dates
+		RevisionHistory:    revisionHistory,                                         // required
+		Status:             utils.Ref(state.status),                                 // required
+		Version:            utils.Ref(gocsaf.RevisionNumber(strconv.Itoa(version))), // required
 	}
 	return
 
 }
 
+// getInitialReleaseDate returns the publication date of the advisory. Drafts are not published, so their creation
+// date (or the update date if the creation date is not provided) is used instead.
+func getInitialReleaseDate(adv *repository.Advisory) *string {
+	if !isPublished(adv) {
+		if adv.CreatedAt == "" {
+			return &adv.UpdatedAt
+		}
+		return &adv.CreatedAt
+	}
+	return &adv.PublishedAt
+}
+
 func getCurrentReleaseDate(adv *repository.Advisory) (current *string) {
-	if adv.UpdatedAt != "" && adv.UpdatedAt > adv.PublishedAt {
+	current = getInitialReleaseDate(adv)
+	if adv.UpdatedAt != "" && adv.UpdatedAt > *current {
 		current = &adv.UpdatedAt
-		return
 	}
-	current = &adv.PublishedAt
+	if withdrawnAt := getWithdrawnAt(adv); withdrawnAt > *current {
+		current = utils.Ref(withdrawnAt)
+	}
 	return
 }
 
+// getAliases returns the values of the identifiers. Empty and duplicate values are skipped, since CSAF requires
+// aliases to be non-empty and unique.
 func getAliases(identifiers []repository.Identifier) (aliases []*string) {
-	aliases = make([]*string, len(identifiers))
-	for i, id := range identifiers {
-		aliases[i] = &id.Value
+	var (
+		seen = make(map[string]bool)
+	)
+	for _, id := range identifiers {
+		if id.Value == "" || seen[id.Value] {
+			continue
+		}
+		seen[id.Value] = true
+		aliases = append(aliases, utils.Ref(id.Value))
 	}
 	return
 }
 
 // getRevisionHistory processes the advisory and returns its chronological revision history as a slice of revisions.
-// Note: GHSA does not provide a revision history, so we create one based on the publication date and the update date.
+// Note: GHSA does not provide a revision history, so we create one based on the publication date, the update date and
+// the withdrawal date.
 func getRevisionHistory(adv *repository.Advisory) (revisions gocsaf.Revisions) {
 	var (
-		n = atomic.Int32{}
+		n           = atomic.Int32{}
+		withdrawnAt = getWithdrawnAt(adv)
 	)
-	// Published
-	if adv.PublishedAt != "" {
-		revNumber := gocsaf.RevisionNumber(n.Add(1))
+	add := func(date string, summary string) {
+		revNumber := gocsaf.RevisionNumber(strconv.Itoa(int(n.Add(1))))
 		revisions = append(revisions, &gocsaf.Revision{
-			Date:    &adv.PublishedAt,
+			Date:    utils.Ref(date),
 			Number:  &revNumber,
-			Summary: utils.Ref("Advisory published"),
+			Summary: utils.Ref(summary),
 		})
 	}
-	// Updated after publication (ISO 8601 strings are lexicographically sortable, so string comparison should work.)
-	if adv.UpdatedAt != "" && adv.UpdatedAt != adv.PublishedAt && adv.UpdatedAt > adv.PublishedAt {
-		revNumber := gocsaf.RevisionNumber(n.Add(1))
+
+	// Drafts have a single revision 0 for their current state
+	if !isPublished(adv) {
+		revNumber := gocsaf.RevisionNumber("0")
 		revisions = append(revisions, &gocsaf.Revision{
-			Date:    &adv.UpdatedAt,
+			Date:    getCurrentReleaseDate(adv),
 			Number:  &revNumber,
-			Summary: utils.Ref("Advisory updated"),
+			Summary: utils.Ref(fmt.Sprintf("Draft of the advisory in state %s", adv.State)),
 		})
+		return
+	}
+
+	// Published
+	if adv.PublishedAt != "" {
+		add(adv.PublishedAt, "Advisory published")
+	}
+	// Updated after publication (ISO 8601 strings are lexicographically sortable, so string comparison should work.)
+	// The withdrawal itself updates the advisory, so an update at the same time is not listed separately.
+	updated := adv.UpdatedAt != "" && adv.UpdatedAt > adv.PublishedAt && adv.UpdatedAt != withdrawnAt
+	if updated && (withdrawnAt == "" || adv.UpdatedAt < withdrawnAt) {
+		add(adv.UpdatedAt, "Advisory updated")
+		updated = false
+	}
+	// Withdrawn
+	if withdrawnAt != "" {
+		add(withdrawnAt, "Advisory withdrawn")
+	}
+	// Updated after withdrawal
+	if updated {
+		add(adv.UpdatedAt, "Advisory updated")
 	}
 	return
 }
 
+// getWithdrawnAt returns the date the advisory was withdrawn or an empty string if it was not withdrawn.
+func getWithdrawnAt(adv *repository.Advisory) string {
+	withdrawnAt, _ := adv.WithdrawnAt.(string)
+	return withdrawnAt
+}
+
 func provideContactInformation(u *repository.User) (contactInformation *string) {
 	var (
 		info string
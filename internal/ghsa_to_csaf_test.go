@@ -0,0 +1,478 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/csaf-poc/ghsa/internal/utils"
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/global"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata/golden")
+
+// goldenEPSSTimestamp is the time the EPSS scores of the global examples are assumed to be retrieved at.
+var goldenEPSSTimestamp = time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+
+// goldenCase is an example advisory of the examples folder whose conversion is compared against a golden file.
+type goldenCase struct {
+	// name is the path of the golden file below testdata/golden without extension
+	name    string
+	convert func() (*csaf.Advisory, error)
+}
+
+// goldenCases returns a goldenCase for every GHSA in the examples folder: repository advisories, global advisories and
+// OSV records of the advisory database checkout.
+func goldenCases(t *testing.T) (cases []goldenCase) {
+	t.Helper()
+
+	repositoryFiles, _ := filepath.Glob("../examples/repository_GHSA/*.json")
+	for _, file := range repositoryFiles {
+		var adv repository.Advisory
+		readJSON(t, file, &adv)
+		cases = append(cases, goldenCase{
+			name:    "repository_GHSA/" + strings.TrimSuffix(filepath.Base(file), ".json"),
+			convert: func() (*csaf.Advisory, error) { return ToCSAF(&adv) },
+		}, goldenCase{
+			name:    "csaf_2.1/repository_GHSA/" + strings.TrimSuffix(filepath.Base(file), ".json"),
+			convert: func() (*csaf.Advisory, error) { return ToCSAF(&adv, WithCSAFVersion(csaf.Version21)) },
+		})
+	}
+
+	globalFiles, _ := filepath.Glob("../examples/global_GHSA/*.json")
+	for _, file := range globalFiles {
+		var adv global.Advisory
+		readJSON(t, file, &adv)
+		cases = append(cases, goldenCase{
+			name:    "global_GHSA/" + strings.TrimSuffix(filepath.Base(file), ".json"),
+			convert: func() (*csaf.Advisory, error) { return GlobalToCSAF(&adv, WithEPSSTimestamp(goldenEPSSTimestamp)) },
+		}, goldenCase{
+			name: "csaf_2.1/global_GHSA/" + strings.TrimSuffix(filepath.Base(file), ".json"),
+			convert: func() (*csaf.Advisory, error) {
+				return GlobalToCSAF(&adv, WithCSAFVersion(csaf.Version21), WithEPSSTimestamp(goldenEPSSTimestamp))
+			},
+		})
+	}
+
+	err := WalkAdvisoryDatabase("../examples/osv_GHSA", func(_ string, adv *global.Advisory) error {
+		cases = append(cases, goldenCase{
+			name:    "osv_GHSA/" + adv.ID,
+			convert: func() (*csaf.Advisory, error) { return GlobalToCSAF(adv) },
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("could not import OSV examples: %v", err)
+	}
+
+	if len(cases) == 0 {
+		t.Fatal("no examples found")
+	}
+	return cases
+}
+
+// readJSON unmarshals the JSON file into v and fails the test on error.
+func readJSON(t *testing.T, file string, v any) {
+	t.Helper()
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("could not read '%s': %v", file, err)
+	}
+	if err = json.Unmarshal(b, v); err != nil {
+		t.Fatalf("could not unmarshal '%s': %v", file, err)
+	}
+}
+
+// TestToCSAF_Golden converts every example and compares the result with its golden file in testdata/golden.
+// Run `go test ./internal -run TestToCSAF_Golden -update` to regenerate the golden files after intended changes.
+func TestToCSAF_Golden(t *testing.T) {
+	for _, gc := range goldenCases(t) {
+		t.Run(gc.name, func(t *testing.T) {
+			golden := filepath.Join("testdata", "golden", filepath.FromSlash(gc.name)+".json")
+
+			adv, err := gc.convert()
+			if !assert.NoError(t, err) {
+				return
+			}
+			got, err := csaf.MarshalIndent(adv, "", "  ")
+			if !assert.NoError(t, err) {
+				return
+			}
+			got = append(got, '\n')
+
+			if *update {
+				assert.NoError(t, os.MkdirAll(filepath.Dir(golden), 0755))
+				assert.NoError(t, os.WriteFile(golden, got, 0644))
+			}
+
+			want, err := os.ReadFile(golden)
+			if !assert.NoError(t, err, "golden file missing, run with -update") {
+				return
+			}
+			assert.Equal(t, string(want), string(got))
+
+			// Round trip: the golden file must be loadable and valid according to gocsaf, which supports CSAF 2.0 only
+			if adv.CSAF21 == nil {
+				_, err = gocsaf.LoadAdvisory(golden)
+				assert.NoError(t, err)
+			}
+			assertValidCSAF(t, adv)
+		})
+	}
+}
+
+// addExamples adds all files matching pattern as seeds to the fuzz corpus.
+func addExamples(f *testing.F, pattern string) {
+	f.Helper()
+
+	files, _ := filepath.Glob(pattern)
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatalf("could not read '%s': %v", file, err)
+		}
+		f.Add(b)
+	}
+}
+
+// checkConversion fails if the conversion succeeded but produced an invalid advisory.
+func checkConversion(t *testing.T, adv *csaf.Advisory, err error) {
+	if err != nil {
+		return
+	}
+	if err = adv.Validate(); err != nil {
+		t.Fatalf("converted advisory is invalid: %v", err)
+	}
+	if err = ValidateCSAF(adv); err != nil && !errors.Is(err, ErrNoCSAF21Schema) {
+		t.Fatalf("converted advisory is invalid: %v", err)
+	}
+}
+
+func FuzzToCSAF(f *testing.F) {
+	addExamples(f, "../examples/repository_GHSA/*.json")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var adv repository.Advisory
+		if json.Unmarshal(data, &adv) != nil {
+			return
+		}
+		got, err := ToCSAF(&adv)
+		checkConversion(t, got, err)
+		got, err = ToCSAF(&adv, WithCSAFVersion(csaf.Version21))
+		checkConversion(t, got, err)
+	})
+}
+
+func FuzzGlobalToCSAF(f *testing.F) {
+	addExamples(f, "../examples/global_GHSA/*.json")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var adv global.Advisory
+		if json.Unmarshal(data, &adv) != nil {
+			return
+		}
+		got, err := GlobalToCSAF(&adv)
+		checkConversion(t, got, err)
+	})
+}
+
+func TestToCSAF_Errors(t *testing.T) {
+	var (
+		example repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &example)
+
+	tests := []struct {
+		name         string
+		modify       func(adv *repository.Advisory)
+		opts         []ConvertOption
+		wantSections []Section
+		wantAdvisory bool
+	}{
+		{
+			name:         "valid advisory",
+			modify:       func(*repository.Advisory) {},
+			wantAdvisory: true,
+		},
+		{
+			name: "strict",
+			modify: func(adv *repository.Advisory) {
+				adv.Summary = ""
+				adv.CVSSSeverities.CVSSv3.VectorString = "CVSS:3.1/AV:X"
+			},
+			wantSections: []Section{SectionDocument, SectionVulnerabilities},
+		},
+		{
+			name: "lenient",
+			modify: func(adv *repository.Advisory) {
+				adv.PublishedAt = "yesterday"
+				adv.Vulnerabilities[0].Package.Name = ""
+			},
+			opts:         []ConvertOption{WithLenient()},
+			wantSections: []Section{SectionDocument, SectionProductTree},
+			wantAdvisory: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				convErr *ConversionError
+				adv     = example
+			)
+			adv.Vulnerabilities = append([]repository.Vulnerability(nil), example.Vulnerabilities...)
+			tt.modify(&adv)
+
+			got, err := ToCSAF(&adv, tt.opts...)
+			assert.Equal(t, tt.wantAdvisory, got != nil)
+			if len(tt.wantSections) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			if !assert.ErrorAs(t, err, &convErr) {
+				return
+			}
+			var sections []Section
+			for _, e := range convErr.Errors {
+				sections = append(sections, e.Section)
+			}
+			assert.Equal(t, tt.wantSections, sections)
+		})
+	}
+}
+
+func TestToCSAF_Nil(t *testing.T) {
+	got, err := ToCSAF(nil, WithLenient())
+	assert.Nil(t, got)
+	assert.EqualError(t, err, "no advisory")
+}
+
+func TestGetRevisionHistory(t *testing.T) {
+	tests := []struct {
+		name string
+		adv  repository.Advisory
+		want []string
+	}{
+		{
+			name: "published",
+			adv:  repository.Advisory{PublishedAt: "2025-01-01T00:00:00Z", UpdatedAt: "2025-01-01T00:00:00Z"},
+			want: []string{"1 2025-01-01T00:00:00Z Advisory published"},
+		},
+		{
+			name: "updated",
+			adv:  repository.Advisory{PublishedAt: "2025-01-01T00:00:00Z", UpdatedAt: "2025-02-01T00:00:00Z"},
+			want: []string{
+				"1 2025-01-01T00:00:00Z Advisory published",
+				"2 2025-02-01T00:00:00Z Advisory updated",
+			},
+		},
+		{
+			name: "withdrawn",
+			adv: repository.Advisory{
+				PublishedAt: "2025-01-01T00:00:00Z",
+				UpdatedAt:   "2025-03-01T00:00:00Z",
+				WithdrawnAt: "2025-03-01T00:00:00Z",
+			},
+			want: []string{
+				"1 2025-01-01T00:00:00Z Advisory published",
+				"2 2025-03-01T00:00:00Z Advisory withdrawn",
+			},
+		},
+		{
+			name: "updated after withdrawal",
+			adv: repository.Advisory{
+				PublishedAt: "2025-01-01T00:00:00Z",
+				UpdatedAt:   "2025-04-01T00:00:00Z",
+				WithdrawnAt: "2025-03-01T00:00:00Z",
+			},
+			want: []string{
+				"1 2025-01-01T00:00:00Z Advisory published",
+				"2 2025-03-01T00:00:00Z Advisory withdrawn",
+				"3 2025-04-01T00:00:00Z Advisory updated",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, rev := range getRevisionHistory(&tt.adv) {
+				got = append(got, string(*rev.Number)+" "+*rev.Date+" "+*rev.Summary)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestToCSAF_Withdrawn(t *testing.T) {
+	var (
+		adv repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &adv)
+	adv.WithdrawnAt = "2025-04-01T00:00:00Z"
+	adv.UpdatedAt = "2025-04-01T00:00:00Z"
+
+	got, err := ToCSAF(&adv)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assertValidCSAF(t, got)
+	assert.Equal(t, "2025-04-01T00:00:00Z", *got.Document.Tracking.CurrentReleaseDate)
+
+	notes := got.Document.Notes
+	if assert.NotEmpty(t, notes) {
+		assert.Equal(t, "Withdrawn", *notes[len(notes)-1].Title)
+	}
+
+	// Consumers must not act on a withdrawn vulnerability
+	if assert.Len(t, got.Vulnerabilities, 1) {
+		v := got.Vulnerabilities[0]
+		assert.Nil(t, v.ProductStatus)
+		assert.Empty(t, v.Remediations)
+		assert.Empty(t, v.Scores)
+		assert.NotEmpty(t, v.IDs)
+	}
+}
+
+func TestToCSAF_State(t *testing.T) {
+	var (
+		example repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &example)
+
+	tests := []struct {
+		state      string
+		wantStatus gocsaf.TrackingStatus
+		wantTLP    gocsaf.TLPLabel
+		wantErr    assert.ErrorAssertionFunc
+	}{
+		{state: "published", wantStatus: gocsaf.CSAFTrackingStatusFinal, wantTLP: gocsaf.TLPLabelWhite, wantErr: assert.NoError},
+		{state: "draft", wantStatus: gocsaf.CSAFTrackingStatusDraft, wantTLP: gocsaf.TLPLabelAmber, wantErr: assert.NoError},
+		{state: "triage", wantStatus: gocsaf.CSAFTrackingStatusDraft, wantTLP: gocsaf.TLPLabelRed, wantErr: assert.NoError},
+		{state: "closed", wantStatus: gocsaf.CSAFTrackingStatusDraft, wantTLP: gocsaf.TLPLabelRed, wantErr: assert.NoError},
+		{state: "unknown", wantStatus: gocsaf.CSAFTrackingStatusDraft, wantTLP: gocsaf.TLPLabelRed, wantErr: assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			adv := example
+			adv.State = tt.state
+			if tt.state != "published" {
+				adv.PublishedAt = ""
+				adv.CreatedAt = "2025-03-20T08:00:00Z"
+			}
+
+			got, err := ToCSAF(&adv, WithLenient())
+			tt.wantErr(t, err)
+			if !assert.NotNil(t, got) {
+				return
+			}
+			tracking := got.Document.Tracking
+			assert.Equal(t, tt.wantStatus, *tracking.Status)
+			assert.Equal(t, tt.wantTLP, *got.Document.Distribution.TLP.DocumentTLPLabel)
+			if err == nil {
+				assertValidCSAF(t, got)
+			}
+			if tt.wantStatus == gocsaf.CSAFTrackingStatusDraft {
+				assert.Equal(t, adv.CreatedAt, *tracking.InitialReleaseDate)
+				assert.Equal(t, gocsaf.RevisionNumber("0"), *tracking.Version)
+				if assert.Len(t, tracking.RevisionHistory, 1) {
+					assert.Equal(t, gocsaf.RevisionNumber("0"), *tracking.RevisionHistory[0].Number)
+				}
+			}
+		})
+	}
+}
+
+func TestToCSAF_Publisher(t *testing.T) {
+	var (
+		adv repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &adv)
+
+	got, err := ToCSAF(&adv, WithPublisher(Publisher{
+		Name:             "Example PSIRT",
+		Namespace:        "https://psirt.example.com",
+		Category:         "vendor",
+		IssuingAuthority: "Example PSIRT",
+	}))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assertValidCSAF(t, got)
+
+	p := got.Document.Publisher
+	assert.Equal(t, "Example PSIRT", *p.Name)
+	assert.Equal(t, "https://psirt.example.com", *p.Namespace)
+	assert.Equal(t, gocsaf.CSAFCategoryVendor, *p.Category)
+	assert.Nil(t, p.ContactDetails)
+
+	// The GitHub publisher is acknowledged instead
+	if assert.NotNil(t, got.Document.Acknowledgements) {
+		ack := *got.Document.Acknowledgements
+		last := ack[len(ack)-1]
+		assert.Equal(t, adv.Publisher.Login, *last.Names[0])
+		assert.Equal(t, "Published the GitHub Security Advisory", *last.Summary)
+	}
+
+	// An invalid publisher fails the document section
+	_, err = ToCSAF(&adv, WithPublisher(Publisher{Name: "Example PSIRT"}))
+	assert.ErrorContains(t, err, "invalid publisher namespace")
+}
+
+func TestCreditTypeToSummary(t *testing.T) {
+	tests := map[string]*string{
+		"":                      nil,
+		"reporter":              utils.Ref("Reported the vulnerability"),
+		"ANALYST":               utils.Ref("Analyzed impact"),
+		"remediation_developer": utils.Ref("Provided the fix"),
+		"unknown":               utils.Ref("unknown"),
+	}
+	for creditType, want := range tests {
+		assert.Equal(t, want, creditTypeToSummary(creditType), creditType)
+	}
+}
+
+func TestGetAggregateSeverity(t *testing.T) {
+	cvss := repository.CVSS{VectorString: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:L/A:H", Score: 8.2}
+	tests := []struct {
+		name string
+		adv  repository.Advisory
+		want *gocsaf.AggregateSeverity
+	}{
+		{
+			name: "CVSS v3 score",
+			adv:  repository.Advisory{Severity: "high", CVSSSeverities: repository.CVSSSeverities{CVSSv3: cvss}},
+		},
+		{
+			name: "Older CVSS field",
+			adv:  repository.Advisory{Severity: "high", CVSS: cvss},
+		},
+		{
+			name: "CVSS v4 only",
+			adv: repository.Advisory{Severity: "critical", CVSSSeverities: repository.CVSSSeverities{CVSSv4: repository.CVSS{
+				VectorString: "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N",
+			}}},
+			want: &gocsaf.AggregateSeverity{Text: utils.Ref("Critical")},
+		},
+		{
+			name: "Moderate",
+			adv:  repository.Advisory{Severity: "moderate"},
+			want: &gocsaf.AggregateSeverity{Text: utils.Ref("Moderate")},
+		},
+		{
+			name: "Unknown severity",
+			adv:  repository.Advisory{Severity: "unknown"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getAggregateSeverity(&tt.adv))
+		})
+	}
+}
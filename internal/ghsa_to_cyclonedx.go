@@ -0,0 +1,311 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/csaf-poc/ghsa/models/cyclonedx"
+	"github.com/csaf-poc/ghsa/models/ghsa/global"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+)
+
+// DefaultCycloneDXVersion is the CycloneDX version of the BOMs written by default.
+const DefaultCycloneDXVersion = cyclonedx.SpecVersion16
+
+// ghsaSeverities maps the severities of GitHub to CycloneDX severities.
+var ghsaSeverities = map[string]cyclonedx.Severity{
+	"critical": cyclonedx.SeverityCritical,
+	"high":     cyclonedx.SeverityHigh,
+	"moderate": cyclonedx.SeverityMedium,
+	"medium":   cyclonedx.SeverityMedium,
+	"low":      cyclonedx.SeverityLow,
+}
+
+// GlobalToCycloneDX converts a global GitHub Security Advisory into a CycloneDX BOM.
+// See ToCycloneDX.
+func GlobalToCycloneDX(g *global.Advisory, specVersion string) (bom *cyclonedx.BOM, err error) {
+	if g == nil {
+		return ToCycloneDX(nil, specVersion)
+	}
+	return ToCycloneDX(globalToRepository(g), specVersion, withGlobal(g))
+}
+
+// ToCycloneDX converts a GitHub Security Advisory into a standalone CycloneDX BOM of the given version (1.5 or 1.6)
+// describing the vulnerability, i.e. a VEX document. The packages of the GHSA become library components, which the
+// vulnerability references in its affects by their package URL. See MergeCycloneDX to add the vulnerability to an
+// existing SBOM instead. Only the global advisory option of the conversion options is used.
+func ToCycloneDX(a *repository.Advisory, specVersion string, opts ...ConvertOption) (bom *cyclonedx.BOM, err error) {
+	if err = checkCycloneDXVersion(specVersion); err != nil {
+		return nil, err
+	}
+	vuln, err := CycloneDXVulnerability(a, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	bom = &cyclonedx.BOM{
+		BOMFormat:   cyclonedx.BOMFormat,
+		SpecVersion: specVersion,
+		Version:     1,
+		Metadata: &cyclonedx.Metadata{
+			Timestamp: a.UpdatedAt,
+			Tools: &cyclonedx.Tools{Components: []cyclonedx.Component{{
+				Type:    cyclonedx.ComponentTypeApplication,
+				Name:    generatorEngine,
+				Version: Version,
+			}}},
+		},
+		Components:      getCycloneDXComponents(a),
+		Vulnerabilities: []cyclonedx.Vulnerability{*vuln},
+	}
+	return bom, nil
+}
+
+// checkCycloneDXVersion reports an error if BOMs cannot be written in the given CycloneDX version.
+func checkCycloneDXVersion(specVersion string) error {
+	if specVersion != cyclonedx.SpecVersion15 && specVersion != cyclonedx.SpecVersion16 {
+		return fmt.Errorf("unsupported CycloneDX version '%s'", specVersion)
+	}
+	return nil
+}
+
+// CycloneDXVulnerability converts a GitHub Security Advisory into a CycloneDX vulnerability with ratings, CWEs, the
+// affected packages and the analysis state. The affects reference the packages by their package URL without version.
+func CycloneDXVulnerability(a *repository.Advisory, opts ...ConvertOption) (vuln *cyclonedx.Vulnerability, err error) {
+	if a == nil {
+		return nil, errors.New("advisory is nil")
+	}
+	if a.GhsaID == "" {
+		return nil, errors.New("advisory has no GHSA ID")
+	}
+	c := newConverter(opts...)
+	source := &cyclonedx.Source{Name: vulnerabilityIDSystemName, URL: a.HTMLURL}
+
+	vuln = &cyclonedx.Vulnerability{
+		BOMRef:         a.GhsaID,
+		ID:             a.GhsaID,
+		Source:         source,
+		CWEs:           getCycloneDXCWEs(a),
+		Description:    a.Summary,
+		Detail:         a.Description,
+		Recommendation: getRecommendation(a),
+		Advisories:     c.getCycloneDXAdvisories(a),
+		Created:        a.CreatedAt,
+		Published:      a.PublishedAt,
+		Updated:        a.UpdatedAt,
+		Rejected:       getWithdrawnAt(a),
+		Credits:        getCycloneDXCredits(a),
+		Analysis:       getCycloneDXAnalysis(a),
+		Affects:        getCycloneDXAffects(a),
+	}
+	if cvePattern.MatchString(a.CveID) {
+		vuln.References = []cyclonedx.VulnerabilityReference{{
+			ID:     a.CveID,
+			Source: &cyclonedx.Source{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/" + a.CveID},
+		}}
+	}
+
+	vuln.Ratings, err = getCycloneDXRatings(a, source)
+	if err != nil {
+		return nil, err
+	}
+	return vuln, nil
+}
+
+// getCycloneDXRatings returns the CVSS v3 and CVSS v4 scores of the advisory as ratings. Advisories without score are
+// rated with the severity given by GitHub. Invalid scores are reported as error.
+func getCycloneDXRatings(adv *repository.Advisory, source *cyclonedx.Source) (ratings []cyclonedx.Rating, err error) {
+	cvss3 := adv.CVSSSeverities.CVSSv3
+	// Older advisories only provide the cvss field
+	if cvss3.VectorString == "" {
+		cvss3 = adv.CVSS
+	}
+	if cvss3.VectorString != "" {
+		version, ok := cvss3Version(cvss3.VectorString)
+		if !ok {
+			err = fmt.Errorf("invalid CVSS v3 vector string '%s'", cvss3.VectorString)
+			return nil, err
+		}
+		if cvss3.Score < 0 || cvss3.Score > 10 {
+			err = fmt.Errorf("invalid CVSS v3 base score %v", cvss3.Score)
+			return nil, err
+		}
+		method := cyclonedx.RatingMethodCVSSv3
+		if version == gocsaf.CVSSVersion31 {
+			method = cyclonedx.RatingMethodCVSSv31
+		}
+		ratings = append(ratings, cyclonedx.Rating{
+			Source:   source,
+			Score:    &cvss3.Score,
+			Severity: cyclonedx.Severity(strings.ToLower(string(cvss3Severity(cvss3.Score)))),
+			Method:   method,
+			Vector:   cvss3.VectorString,
+		})
+	}
+
+	// Vectors without score are rated by the GitHub severity below
+	cvss4, err := getCVSS4(adv)
+	if err != nil && !errors.Is(err, errMissingCVSS4Score) {
+		return nil, err
+	}
+	if cvss4 != nil {
+		ratings = append(ratings, cyclonedx.Rating{
+			Source:   source,
+			Score:    &cvss4.BaseScore,
+			Severity: cyclonedx.Severity(strings.ToLower(cvss4.BaseSeverity)),
+			Method:   cyclonedx.RatingMethodCVSSv4,
+			Vector:   cvss4.VectorString,
+		})
+	}
+
+	if len(ratings) == 0 && adv.Severity != "" {
+		severity, ok := ghsaSeverities[strings.ToLower(adv.Severity)]
+		if !ok {
+			severity = cyclonedx.SeverityUnknown
+		}
+		ratings = append(ratings, cyclonedx.Rating{Source: source, Severity: severity, Method: cyclonedx.RatingMethodOther})
+	}
+	return ratings, nil
+}
+
+// getCycloneDXCWEs returns the numbers of the CWEs of the advisory (see getWeaknesses). Only the IDs are used, so
+// differing names are not reported.
+func getCycloneDXCWEs(adv *repository.Advisory) (cwes []int) {
+	weaknesses, _ := getWeaknesses(adv)
+	for _, w := range weaknesses {
+		if n, err := strconv.Atoi(strings.TrimPrefix(w.id, "CWE-")); err == nil {
+			cwes = append(cwes, n)
+		}
+	}
+	return
+}
+
+// getRecommendation recommends to update each package to its first patched version. Returns an empty string if no
+// patched version is known.
+func getRecommendation(adv *repository.Advisory) string {
+	var (
+		sentences []string
+	)
+	for _, v := range adv.Vulnerabilities {
+		patched := getPatchedVersions(&v)
+		if v.Package.Name == "" || len(patched) == 0 {
+			continue
+		}
+		s := fmt.Sprintf("Update %s to version %s or later.", v.Package.Name, patched[0])
+		if !slices.Contains(sentences, s) {
+			sentences = append(sentences, s)
+		}
+	}
+	return strings.Join(sentences, " ")
+}
+
+// getCycloneDXAdvisories returns the references of the advisory (see converter.getReferences).
+func (c *converter) getCycloneDXAdvisories(adv *repository.Advisory) (advisories []cyclonedx.Advisory) {
+	for _, ref := range c.getReferences(adv) {
+		advisories = append(advisories, cyclonedx.Advisory{Title: *ref.Summary, URL: *ref.URL})
+	}
+	return
+}
+
+// getCycloneDXCredits returns the credited users of the advisory or nil if there are none.
+func getCycloneDXCredits(adv *repository.Advisory) *cyclonedx.Credits {
+	var (
+		individuals []cyclonedx.OrganizationalContact
+	)
+	for _, c := range adv.CreditsDetailed {
+		if c.User.Login != "" {
+			individuals = append(individuals, cyclonedx.OrganizationalContact{Name: c.User.Login, Email: c.User.Email})
+		}
+	}
+	if len(individuals) == 0 {
+		return nil
+	}
+	return &cyclonedx.Credits{Individuals: individuals}
+}
+
+// getCycloneDXAnalysis returns the analysis state of the vulnerability: withdrawn and closed advisories are false
+// positives, advisories that are not published yet are in triage and published advisories are exploitable. Updating
+// is the response if a patched version is known.
+func getCycloneDXAnalysis(adv *repository.Advisory) *cyclonedx.Analysis {
+	withdrawnAt := getWithdrawnAt(adv)
+	switch {
+	case withdrawnAt != "":
+		return &cyclonedx.Analysis{
+			State:  cyclonedx.AnalysisStateFalsePositive,
+			Detail: fmt.Sprintf("The advisory was withdrawn on %s.", withdrawnAt),
+		}
+	case adv.State == "closed":
+		return &cyclonedx.Analysis{
+			State:  cyclonedx.AnalysisStateFalsePositive,
+			Detail: "The advisory was closed without being published.",
+		}
+	case !isPublished(adv):
+		return &cyclonedx.Analysis{State: cyclonedx.AnalysisStateInTriage}
+	}
+
+	analysis := &cyclonedx.Analysis{State: cyclonedx.AnalysisStateExploitable}
+	if getRecommendation(adv) != "" {
+		analysis.Response = []string{"update"}
+	}
+	return analysis
+}
+
+// getCycloneDXAffects returns the affected packages, referenced by their package URL without version. Each package is
+// listed once with its vulnerable version ranges (as vers range) and its patched versions as unaffected.
+func getCycloneDXAffects(adv *repository.Advisory) (affects []cyclonedx.Affect) {
+	var (
+		index = make(map[string]int)
+	)
+	for _, v := range adv.Vulnerabilities {
+		if v.Package.Name == "" {
+			continue
+		}
+		ref := packageURL(v.Package.Ecosystem, v.Package.Name, "")
+		i, ok := index[ref]
+		if !ok {
+			i = len(affects)
+			index[ref] = i
+			affects = append(affects, cyclonedx.Affect{Ref: ref})
+		}
+
+		versions := []cyclonedx.AffectVersion{{
+			Range:  versRange(v.Package.Ecosystem, v.VulnerableVersionRange),
+			Status: cyclonedx.AffectStatusAffected,
+		}}
+		for _, patched := range getPatchedVersions(&v) {
+			versions = append(versions, cyclonedx.AffectVersion{Version: patched, Status: cyclonedx.AffectStatusUnaffected})
+		}
+		for _, version := range versions {
+			if !slices.Contains(affects[i].Versions, version) {
+				affects[i].Versions = append(affects[i].Versions, version)
+			}
+		}
+	}
+	return
+}
+
+// getCycloneDXComponents returns the packages of the advisory as library components. Their bom-ref is the package URL
+// without version, which the affects of the vulnerability refer to.
+func getCycloneDXComponents(adv *repository.Advisory) (components []cyclonedx.Component) {
+	var (
+		seen = make(map[string]bool)
+	)
+	for _, v := range adv.Vulnerabilities {
+		purl := packageURL(v.Package.Ecosystem, v.Package.Name, "")
+		if v.Package.Name == "" || seen[purl] {
+			continue
+		}
+		seen[purl] = true
+		components = append(components, cyclonedx.Component{
+			Type:   cyclonedx.ComponentTypeLibrary,
+			BOMRef: purl,
+			Name:   v.Package.Name,
+			PURL:   purl,
+		})
+	}
+	return
+}
@@ -0,0 +1,287 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/csaf-poc/ghsa/internal/utils"
+	"github.com/csaf-poc/ghsa/models/cyclonedx"
+	"github.com/csaf-poc/ghsa/models/ghsa/global"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToCycloneDX(t *testing.T) {
+	var (
+		adv repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &adv)
+
+	bom, err := ToCycloneDX(&adv, cyclonedx.SpecVersion15)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, cyclonedx.BOMFormat, bom.BOMFormat)
+	assert.Equal(t, cyclonedx.SpecVersion15, bom.SpecVersion)
+	assert.Equal(t, []cyclonedx.Component{
+		{Type: cyclonedx.ComponentTypeLibrary, BOMRef: "pkg:golang/github.com/golang-jwt/jwt/v5", Name: "github.com/golang-jwt/jwt/v5", PURL: "pkg:golang/github.com/golang-jwt/jwt/v5"},
+		{Type: cyclonedx.ComponentTypeLibrary, BOMRef: "pkg:golang/github.com/golang-jwt/jwt/v4", Name: "github.com/golang-jwt/jwt/v4", PURL: "pkg:golang/github.com/golang-jwt/jwt/v4"},
+	}, bom.Components)
+
+	if !assert.Len(t, bom.Vulnerabilities, 1) {
+		return
+	}
+	vuln := bom.Vulnerabilities[0]
+	assert.Equal(t, "GHSA-mh63-6h87-95cp", vuln.ID)
+	assert.Equal(t, []cyclonedx.VulnerabilityReference{{
+		ID:     "CVE-2025-30204",
+		Source: &cyclonedx.Source{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/CVE-2025-30204"},
+	}}, vuln.References)
+	assert.Equal(t, []int{405}, vuln.CWEs)
+	if assert.Len(t, vuln.Ratings, 1) {
+		assert.Equal(t, cyclonedx.RatingMethodCVSSv31, vuln.Ratings[0].Method)
+		assert.Equal(t, cyclonedx.SeverityHigh, vuln.Ratings[0].Severity)
+		assert.Equal(t, 7.5, *vuln.Ratings[0].Score)
+	}
+	assert.Equal(t, &cyclonedx.Analysis{State: cyclonedx.AnalysisStateExploitable, Response: []string{"update"}}, vuln.Analysis)
+	assert.Equal(t, "Update github.com/golang-jwt/jwt/v5 to version 5.2.2 or later. "+
+		"Update github.com/golang-jwt/jwt/v4 to version 4.5.2 or later.", vuln.Recommendation)
+	assert.Equal(t, cyclonedx.Affect{
+		Ref: "pkg:golang/github.com/golang-jwt/jwt/v5",
+		Versions: []cyclonedx.AffectVersion{
+			{Range: "vers:golang/<=5.2.1", Status: cyclonedx.AffectStatusAffected},
+			{Version: "5.2.2", Status: cyclonedx.AffectStatusUnaffected},
+		},
+	}, vuln.Affects[0])
+
+	_, err = ToCycloneDX(&adv, "1.4")
+	assert.ErrorContains(t, err, "unsupported CycloneDX version '1.4'")
+}
+
+func TestGetCycloneDXRatings(t *testing.T) {
+	tests := []struct {
+		name    string
+		adv     repository.Advisory
+		want    []cyclonedx.Rating
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name: "CVSS v4",
+			adv: repository.Advisory{CVSSSeverities: repository.CVSSSeverities{CVSSv4: repository.CVSS{
+				VectorString: "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N",
+				Score:        9.3,
+			}}},
+			want: []cyclonedx.Rating{{
+				Score:    utils.Ref(9.3),
+				Severity: cyclonedx.SeverityCritical,
+				Method:   cyclonedx.RatingMethodCVSSv4,
+				Vector:   "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N",
+			}},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "CVSS v4 without score",
+			adv: repository.Advisory{Severity: "high", CVSSSeverities: repository.CVSSSeverities{CVSSv4: repository.CVSS{
+				VectorString: "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N",
+			}}},
+			want:    []cyclonedx.Rating{{Severity: cyclonedx.SeverityHigh, Method: cyclonedx.RatingMethodOther}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "Severity without score",
+			adv:     repository.Advisory{Severity: "moderate"},
+			want:    []cyclonedx.Rating{{Severity: cyclonedx.SeverityMedium, Method: cyclonedx.RatingMethodOther}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "No severity",
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Err: Invalid CVSS v3 vector",
+			adv:  repository.Advisory{CVSS: repository.CVSS{VectorString: "AV:N", Score: 7.5}},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorContains(t, err, "invalid CVSS v3 vector string 'AV:N'")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getCycloneDXRatings(&tt.adv, nil)
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGetCycloneDXAnalysis(t *testing.T) {
+	tests := []struct {
+		name string
+		adv  repository.Advisory
+		want cyclonedx.AnalysisState
+	}{
+		{name: "Published", adv: repository.Advisory{State: "published"}, want: cyclonedx.AnalysisStateExploitable},
+		{name: "Draft", adv: repository.Advisory{State: "draft"}, want: cyclonedx.AnalysisStateInTriage},
+		{name: "Closed", adv: repository.Advisory{State: "closed"}, want: cyclonedx.AnalysisStateFalsePositive},
+		{
+			name: "Withdrawn",
+			adv:  repository.Advisory{State: "published", WithdrawnAt: "2025-04-01T00:00:00Z"},
+			want: cyclonedx.AnalysisStateFalsePositive,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getCycloneDXAnalysis(&tt.adv).State)
+		})
+	}
+}
+
+func TestMergeCycloneDX(t *testing.T) {
+	var (
+		adv    repository.Advisory
+		merged map[string]any
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &adv)
+	vuln, err := CycloneDXVulnerability(&adv)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	sbom := []byte(`{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.6",
+  "serialNumber": "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79",
+  "metadata": {"component": {"type": "application", "name": "app", "bom-ref": "app"}},
+  "components": [
+    {"type": "library", "name": "github.com/golang-jwt/jwt/v5", "version": "v5.2.1", "purl": "pkg:golang/github.com/golang-jwt/jwt/v5@v5.2.1"},
+    {"type": "library", "name": "other", "bom-ref": "other", "purl": "pkg:golang/example.com/other@v1.0.0"}
+  ],
+  "vulnerabilities": [
+    {"id": "GHSA-mh63-6h87-95cp", "bom-ref": "outdated"},
+    {"id": "CVE-2024-0001", "bom-ref": "CVE-2024-0001"}
+  ]
+}`)
+	b, err := MergeCycloneDX(sbom, []*cyclonedx.Vulnerability{vuln})
+	if !assert.NoError(t, err) || !assert.NoError(t, json.Unmarshal(b, &merged)) {
+		return
+	}
+	assert.Equal(t, "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79", merged["serialNumber"])
+
+	// The component without bom-ref is referenced by its package URL
+	components := merged["components"].([]any)
+	assert.Equal(t, "pkg:golang/github.com/golang-jwt/jwt/v5@v5.2.1", components[0].(map[string]any)["bom-ref"])
+
+	vulns := merged["vulnerabilities"].([]any)
+	if !assert.Len(t, vulns, 2) {
+		return
+	}
+	got := vulns[0].(map[string]any)
+	assert.Equal(t, "GHSA-mh63-6h87-95cp", got["bom-ref"])
+	affects := got["affects"].([]any)
+	if assert.Len(t, affects, 1) {
+		assert.Equal(t, "pkg:golang/github.com/golang-jwt/jwt/v5@v5.2.1", affects[0].(map[string]any)["ref"])
+	}
+	// v5.2.1 is in the vulnerable version range
+	assert.Equal(t, "exploitable", got["analysis"].(map[string]any)["state"])
+
+	// Vulnerabilities of packages not contained in the SBOM are skipped
+	b, err = MergeCycloneDX([]byte(`{"bomFormat": "CycloneDX", "specVersion": "1.5"}`), []*cyclonedx.Vulnerability{vuln})
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"bomFormat": "CycloneDX", "specVersion": "1.5"}`, string(b))
+	}
+
+	_, err = MergeCycloneDX([]byte(`{"bomFormat": "CycloneDX", "specVersion": "1.4"}`), nil)
+	assert.ErrorContains(t, err, "unsupported CycloneDX version '1.4'")
+	_, err = MergeCycloneDX([]byte(`{"spdxVersion": "SPDX-2.3"}`), nil)
+	assert.ErrorContains(t, err, "not a CycloneDX BOM")
+}
+
+func TestMergeCycloneDX_Versions(t *testing.T) {
+	var (
+		g global.Advisory
+	)
+	readJSON(t, "../examples/global_GHSA/GHSA-cpj6-fhp6-mr6j.json", &g)
+	g.Vulnerabilities[0].Package.Name = "@remix-run/router"
+	vuln, err := CycloneDXVulnerability(globalToRepository(&g), withGlobal(&g))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := []struct {
+		name  string
+		purls []string
+		want  any
+	}{
+		{name: "Affected", purls: []string{"pkg:npm/%40remix-run/router@7.5.1"}, want: "exploitable"},
+		{name: "Patched", purls: []string{"pkg:npm/%40remix-run/router@7.5.2", "pkg:npm/%40remix-run/router@8.0.0"}, want: "resolved"},
+		// GitHub makes no statement about versions below the vulnerable version range
+		{name: "Below range", purls: []string{"pkg:npm/%40remix-run/router@6.0.0"}},
+		{name: "Mixed", purls: []string{"pkg:npm/%40remix-run/router@7.5.2", "pkg:npm/%40remix-run/router@7.1.0"}, want: "exploitable"},
+		{name: "Not comparable", purls: []string{"pkg:npm/%40remix-run/router@latest"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				components []map[string]any
+				merged     map[string]any
+			)
+			for _, purl := range tt.purls {
+				components = append(components, map[string]any{"type": "library", "name": "router", "purl": purl})
+			}
+			sbom, _ := json.Marshal(map[string]any{"bomFormat": "CycloneDX", "specVersion": "1.6", "components": components})
+			b, err := MergeCycloneDX(sbom, []*cyclonedx.Vulnerability{vuln})
+			if !assert.NoError(t, err) || !assert.NoError(t, json.Unmarshal(b, &merged)) {
+				return
+			}
+			vulns, _ := merged["vulnerabilities"].([]any)
+			if !assert.Len(t, vulns, 1) {
+				return
+			}
+			got := vulns[0].(map[string]any)
+			assert.Len(t, got["affects"], len(tt.purls))
+			analysis, _ := got["analysis"].(map[string]any)
+			assert.Equal(t, tt.want, analysis["state"])
+		})
+	}
+}
+
+func TestVersContains(t *testing.T) {
+	tests := []struct {
+		vers    string
+		version string
+		want    bool
+		wantOk  bool
+	}{
+		{vers: "vers:npm/>=7.0|<=7.5.1", version: "7.5.1", want: true, wantOk: true},
+		{vers: "vers:npm/>=7.0|<=7.5.1", version: "7.5.2", wantOk: true},
+		{vers: "vers:npm/>=7.0|<=7.5.1", version: "6.9", wantOk: true},
+		{vers: "vers:golang/<5.2.2", version: "v5.2.2-rc.1", want: true, wantOk: true},
+		{vers: "vers:npm/1.0.0|1.0.1", version: "1.0.1", want: true, wantOk: true},
+		{vers: "vers:npm/1.0.0|1.0.1", version: "1.0.2", wantOk: true},
+		{vers: "vers:npm/*", version: "1.0.0", want: true, wantOk: true},
+		{vers: "vers:pypi/<2.0", version: "1.0rc1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.vers+" "+tt.version, func(t *testing.T) {
+			got, ok := versContains(tt.vers, tt.version)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPurlKey(t *testing.T) {
+	assert.Equal(t, purlKey("pkg:npm/@remix-run/router"), purlKey("pkg:NPM/%40remix-run/router@1.0.0?x=y"))
+	assert.NotEqual(t, purlKey("pkg:npm/@remix-run/router"), purlKey("pkg:npm/router"))
+}
+
+func TestPurlBase(t *testing.T) {
+	tests := map[string]string{
+		"pkg:npm/%40remix-run/router@1.0.0":              "pkg:npm/%40remix-run/router",
+		"pkg:npm/@remix-run/router":                      "pkg:npm/@remix-run/router",
+		"pkg:maven/org.example/lib@1.0?type=jar#sub/dir": "pkg:maven/org.example/lib",
+	}
+	for purl, want := range tests {
+		assert.Equal(t, want, purlBase(purl), purl)
+	}
+}
@@ -0,0 +1,341 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/csaf-poc/ghsa/models/ghsa/global"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	"github.com/csaf-poc/ghsa/models/osv"
+)
+
+// osvSchemaVersion is the version of the OSV schema the records written by ToOSV comply with.
+const osvSchemaVersion = "1.6.0"
+
+// osvCreditTypes are the credit types defined by OSV. GitHub uses the same types in lower case.
+var osvCreditTypes = map[string]bool{
+	"FINDER":                true,
+	"REPORTER":              true,
+	"ANALYST":               true,
+	"COORDINATOR":           true,
+	"REMEDIATION_DEVELOPER": true,
+	"REMEDIATION_REVIEWER":  true,
+	"REMEDIATION_VERIFIER":  true,
+	"TOOL":                  true,
+	"SPONSOR":               true,
+	"OTHER":                 true,
+}
+
+// GlobalToOSV converts a global GitHub Security Advisory into an OSV record.
+// See ToOSV.
+func GlobalToOSV(g *global.Advisory) (vuln *osv.Vulnerability, err error) {
+	if g == nil {
+		return ToOSV(nil)
+	}
+	return ToOSV(globalToRepository(g), withGlobal(g))
+}
+
+// ToOSV converts a GitHub Security Advisory into an OSV record (see https://ossf.github.io/osv-schema/) like the ones
+// GitHub publishes in the github/advisory-database repository. Every GHSA vulnerability becomes an affected package
+// with an ECOSYSTEM range, so records written by ToOSV can be read by OSVToGlobal. Only the global advisory option of
+// the conversion options is used.
+func ToOSV(a *repository.Advisory, opts ...ConvertOption) (vuln *osv.Vulnerability, err error) {
+	var (
+		errs []error
+	)
+	if a == nil {
+		return nil, errors.New("advisory is nil")
+	}
+	if a.GhsaID == "" {
+		return nil, errors.New("advisory has no GHSA ID")
+	}
+	if a.UpdatedAt == "" {
+		return nil, errors.New("advisory has no update date")
+	}
+	c := newConverter(opts...)
+
+	vuln = &osv.Vulnerability{
+		SchemaVersion: osvSchemaVersion,
+		ID:            a.GhsaID,
+		Modified:      a.UpdatedAt,
+		Published:     a.PublishedAt,
+		Withdrawn:     getWithdrawnAt(a),
+		Aliases:       getOSVAliases(a),
+		Summary:       a.Summary,
+		Details:       a.Description,
+		References:    c.getOSVReferences(a),
+		Credits:       ghsaCreditsToOSV(a),
+	}
+	vuln.DatabaseSpecific = c.getOSVDatabaseSpecific(a)
+
+	vuln.Severity, err = getOSVSeverity(a)
+	errs = append(errs, err)
+
+	for _, v := range a.Vulnerabilities {
+		if v.Package.Name == "" {
+			continue
+		}
+		affected, err := getOSVAffected(&v)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		vuln.Affected = append(vuln.Affected, affected)
+	}
+
+	if err = errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return vuln, nil
+}
+
+// getOSVAliases returns the CVE ID and all identifiers other than the GHSA ID of the advisory.
+func getOSVAliases(adv *repository.Advisory) (aliases []string) {
+	var (
+		seen = map[string]bool{adv.GhsaID: true, "": true}
+	)
+	add := func(alias string) {
+		if !seen[alias] {
+			seen[alias] = true
+			aliases = append(aliases, alias)
+		}
+	}
+
+	add(adv.CveID)
+	for _, id := range adv.Identifiers {
+		add(id.Value)
+	}
+	return
+}
+
+// getOSVSeverity returns the CVSS v3 and CVSS v4 vector strings of the advisory. Invalid vector strings are reported as
+// error.
+func getOSVSeverity(adv *repository.Advisory) (severity []osv.Severity, err error) {
+	cvss3 := adv.CVSSSeverities.CVSSv3.VectorString
+	// Older advisories only provide the cvss field
+	if cvss3 == "" {
+		cvss3 = adv.CVSS.VectorString
+	}
+	if cvss3 != "" {
+		if _, ok := cvss3Version(cvss3); !ok {
+			err = fmt.Errorf("invalid CVSS v3 vector string '%s'", cvss3)
+			return nil, err
+		}
+		severity = append(severity, osv.Severity{Type: osv.SeverityTypeCVSSv3, Score: cvss3})
+	}
+
+	cvss4 := adv.CVSSSeverities.CVSSv4.VectorString
+	if cvss4 != "" {
+		if !cvss4Pattern.MatchString(cvss4) {
+			err = fmt.Errorf("invalid CVSS v4 vector string '%s'", cvss4)
+			return nil, err
+		}
+		severity = append(severity, osv.Severity{Type: osv.SeverityTypeCVSSv4, Score: cvss4})
+	}
+	return severity, nil
+}
+
+// getOSVAffected converts a GHSA vulnerability into an affected package. Its vulnerable version range becomes an
+// ECOSYSTEM range (see getOSVEvents), an exact version (e.g. "= 1.2.3") an enumerated version.
+func getOSVAffected(v *repository.Vulnerability) (affected osv.Affected, err error) {
+	affected = osv.Affected{
+		Package: osv.Package{
+			Ecosystem: ghsaEcosystemToOSV(v.Package.Ecosystem),
+			Name:      v.Package.Name,
+			PURL:      packageURL(v.Package.Ecosystem, v.Package.Name, ""),
+		},
+	}
+	if len(v.VulnerableFunctions) > 0 {
+		affected.EcosystemSpecific = map[string]any{"affected_functions": v.VulnerableFunctions}
+	}
+
+	if version, ok := strings.CutPrefix(strings.TrimSpace(v.VulnerableVersionRange), "= "); ok {
+		affected.Versions = []string{strings.TrimSpace(version)}
+		return affected, nil
+	}
+
+	events, err := getOSVEvents(v)
+	if err != nil {
+		return affected, err
+	}
+	affected.Ranges = []osv.Range{{Type: osv.RangeTypeEcosystem, Events: events}}
+	// Like GitHub, record the original range if the fixed version is unknown
+	if events[len(events)-1].LastAffected != "" {
+		affected.DatabaseSpecific.LastKnownAffectedVersionRange = lastConstraint(v.VulnerableVersionRange)
+	}
+	return affected, nil
+}
+
+// getOSVEvents converts a GHSA version range (e.g. ">= 7.0, <= 7.5.1") into OSV events. The lower bound becomes the
+// introduced version ("0" if there is none). An exclusive upper bound becomes the fixed version, an inclusive upper
+// bound the last affected version unless the first patched version is known, which is used as fixed version instead.
+// Exclusive lower bounds (e.g. "> 1.0") cannot be expressed in OSV and are reported as error.
+func getOSVEvents(v *repository.Vulnerability) (events []osv.Event, err error) {
+	var (
+		introduced = "0"
+		upper      osv.Event
+	)
+
+	for _, c := range strings.Split(v.VulnerableVersionRange, ",") {
+		op, version := splitConstraint(c)
+		switch {
+		case op == "" && version == "":
+			continue
+		case version == "":
+			err = fmt.Errorf("invalid version range '%s'", v.VulnerableVersionRange)
+			return nil, err
+		case op == ">=":
+			introduced = version
+		case op == "<":
+			upper = osv.Event{Fixed: version}
+		case op == "<=":
+			upper = osv.Event{LastAffected: version}
+			if patched := getPatchedVersions(v); len(patched) > 0 {
+				upper = osv.Event{Fixed: patched[0]}
+			}
+		default:
+			err = fmt.Errorf("cannot express version range '%s' in OSV", v.VulnerableVersionRange)
+			return nil, err
+		}
+	}
+
+	events = []osv.Event{{Introduced: introduced}}
+	if upper != (osv.Event{}) {
+		events = append(events, upper)
+	}
+	return events, nil
+}
+
+// splitConstraint splits a constraint of a GHSA version range (e.g. ">= 7.0") into operator and version.
+func splitConstraint(constraint string) (op string, version string) {
+	constraint = strings.TrimSpace(constraint)
+	i := strings.IndexFunc(constraint, func(r rune) bool { return !strings.ContainsRune("<>=", r) })
+	if i < 0 {
+		return constraint, ""
+	}
+	return constraint[:i], strings.TrimSpace(constraint[i:])
+}
+
+// lastConstraint returns the last constraint of a GHSA version range, e.g. "<= 7.5.1" of ">= 7.0, <= 7.5.1".
+func lastConstraint(ghsaRange string) string {
+	constraints := strings.Split(ghsaRange, ",")
+	return strings.TrimSpace(constraints[len(constraints)-1])
+}
+
+// ghsaEcosystemToOSV maps an ecosystem name of the GitHub API to the OSV ecosystem name. It is the inverse of
+// osvEcosystemToGHSA. Unknown ecosystems are kept.
+func ghsaEcosystemToOSV(ecosystem string) string {
+	switch strings.ToLower(ecosystem) {
+	case "go":
+		return "Go"
+	case "npm":
+		return "npm"
+	case "pip":
+		return "PyPI"
+	case "maven":
+		return "Maven"
+	case "nuget":
+		return "NuGet"
+	case "rubygems":
+		return "RubyGems"
+	case "rust":
+		return "crates.io"
+	case "composer":
+		return "Packagist"
+	case "erlang":
+		return "Hex"
+	case "pub":
+		return "Pub"
+	case "swift":
+		return "SwiftURL"
+	case "actions":
+		return "GitHub Actions"
+	default:
+		return ecosystem
+	}
+}
+
+// getOSVReferences returns the references of the record: the GHSA itself and the NVD entry of its CVE and, for global
+// advisories, the references listed by GitHub and the source code location as package. Invalid and duplicate URLs are
+// skipped.
+func (c *converter) getOSVReferences(adv *repository.Advisory) (refs []osv.Reference) {
+	var (
+		seen = make(map[string]bool)
+	)
+	add := func(typ osv.ReferenceType, rawURL string) {
+		if !isURL(rawURL) || seen[rawURL] {
+			return
+		}
+		seen[rawURL] = true
+		refs = append(refs, osv.Reference{Type: typ, URL: rawURL})
+	}
+
+	add(osv.ReferenceTypeAdvisory, adv.HTMLURL)
+	if cvePattern.MatchString(adv.CveID) {
+		add(osv.ReferenceTypeAdvisory, "https://nvd.nist.gov/vuln/detail/"+adv.CveID)
+	}
+	if c.global != nil {
+		for _, ref := range c.global.References {
+			add(osvReferenceType(ref), ref)
+		}
+		add(osv.ReferenceTypePackage, c.global.SourceCodeLocation)
+	}
+	return
+}
+
+// osvReferenceType classifies a reference by its URL (see referenceSummary). Commits and pull requests on GitHub are
+// the fix of the vulnerability.
+func osvReferenceType(rawURL string) osv.ReferenceType {
+	if _, ok := fixReferenceSummary(rawURL); ok {
+		return osv.ReferenceTypeFix
+	}
+	switch referenceSummary(rawURL) {
+	case "Advisory", "NVD entry", "CVE record":
+		return osv.ReferenceTypeAdvisory
+	case "Issue":
+		return osv.ReferenceTypeReport
+	case "Release notes":
+		return osv.ReferenceTypeArticle
+	default:
+		return osv.ReferenceTypeWeb
+	}
+}
+
+// ghsaCreditsToOSV returns the credited users of the advisory with their GitHub profile as contact. Credit types
+// unknown to OSV are mapped to OTHER.
+func ghsaCreditsToOSV(adv *repository.Advisory) (credits []osv.Credit) {
+	for _, c := range adv.CreditsDetailed {
+		if c.User.Login == "" {
+			continue
+		}
+		credit := osv.Credit{Name: c.User.Login}
+		if isURL(c.User.HTMLURL) {
+			credit.Contact = []string{c.User.HTMLURL}
+		}
+		if c.Type != "" {
+			credit.Type = strings.ToUpper(c.Type)
+			if !osvCreditTypes[credit.Type] {
+				credit.Type = "OTHER"
+			}
+		}
+		credits = append(credits, credit)
+	}
+	return
+}
+
+// getOSVDatabaseSpecific returns the GitHub specific information of the record: the CWE IDs, the severity and, for
+// global advisories, the review and NVD publication dates.
+func (c *converter) getOSVDatabaseSpecific(adv *repository.Advisory) (ds osv.DatabaseSpecific) {
+	weaknesses, _ := getWeaknesses(adv)
+	for _, w := range weaknesses {
+		ds.CWEIDs = append(ds.CWEIDs, w.id)
+	}
+	ds.Severity = strings.ToUpper(adv.Severity)
+	if c.global != nil {
+		ds.GithubReviewed = c.global.Type == "reviewed"
+		ds.GithubReviewedAt = formatGlobalTime(c.global.GithubReviewedAt)
+		ds.NVDPublishedAt = formatGlobalTime(c.global.NVDPublishedAt)
+	}
+	return
+}
@@ -0,0 +1,212 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/csaf-poc/ghsa/internal/utils"
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/global"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	"github.com/csaf-poc/ghsa/models/osv"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobalToOSV(t *testing.T) {
+	var (
+		g    global.Advisory
+		want osv.Vulnerability
+	)
+	readJSON(t, "../examples/global_GHSA/GHSA-cpj6-fhp6-mr6j.json", &g)
+	readJSON(t, "../examples/osv_GHSA/advisories/github-reviewed/2025/04/GHSA-cpj6-fhp6-mr6j/GHSA-cpj6-fhp6-mr6j.json", &want)
+
+	got, err := GlobalToOSV(&g)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The record matches the one GitHub published except for the normalized introduced version and the references,
+	// which are classified by their URL
+	assert.Equal(t, osvSchemaVersion, got.SchemaVersion)
+	assert.Equal(t, want.ID, got.ID)
+	assert.Equal(t, want.Modified, got.Modified)
+	assert.Equal(t, want.Published, got.Published)
+	assert.Equal(t, want.Aliases, got.Aliases)
+	assert.Equal(t, want.Summary, got.Summary)
+	assert.Equal(t, want.Details, got.Details)
+	assert.Equal(t, want.Severity, got.Severity)
+	assert.Equal(t, want.DatabaseSpecific, got.DatabaseSpecific)
+	if assert.Len(t, got.Affected, 1) {
+		assert.Equal(t, osv.Package{Ecosystem: "npm", Name: "react-router", PURL: "pkg:npm/react-router"}, got.Affected[0].Package)
+		assert.Equal(t, []osv.Range{{
+			Type:   osv.RangeTypeEcosystem,
+			Events: []osv.Event{{Introduced: "7.0"}, {Fixed: "7.5.2"}},
+		}}, got.Affected[0].Ranges)
+	}
+	assert.ElementsMatch(t, []string{
+		"https://github.com/remix-run/react-router/security/advisories/GHSA-cpj6-fhp6-mr6j",
+		"https://github.com/remix-run/react-router/commit/c84302972a152d851cf5dd859ff332b354b70111",
+		"https://github.com/remix-run/react-router/blob/e6c53a0130559b4a9bd47f9cf76ea5b08a69868a/packages/react-router/lib/server-runtime/routes.ts#L87",
+		"https://nvd.nist.gov/vuln/detail/CVE-2025-43865",
+		"https://github.com/advisories/GHSA-cpj6-fhp6-mr6j",
+		"https://github.com/remix-run/react-router",
+	}, referenceURLs(got.References))
+	assert.Contains(t, got.References, osv.Reference{
+		Type: osv.ReferenceTypeFix,
+		URL:  "https://github.com/remix-run/react-router/commit/c84302972a152d851cf5dd859ff332b354b70111",
+	})
+
+	// Round trip
+	back, err := OSVToGlobal(got)
+	if assert.NoError(t, err) {
+		assert.Equal(t, g.CveID, back.CveID)
+		assert.Equal(t, g.SourceCodeLocation, back.SourceCodeLocation)
+		assert.Equal(t, ">= 7.0, < 7.5.2", back.Vulnerabilities[0].VulnerableVersionRange)
+	}
+}
+
+func TestToOSV(t *testing.T) {
+	var (
+		adv repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &adv)
+
+	got, err := ToOSV(&adv)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"CVE-2025-30204"}, got.Aliases)
+	assert.Equal(t, []osv.Credit{
+		{Name: "jub0bs", Contact: []string{"https://github.com/jub0bs"}, Type: "REPORTER"},
+		{Name: "Web-E", Contact: []string{"https://github.com/Web-E"}, Type: "REPORTER"},
+	}, got.Credits)
+	assert.Equal(t, "HIGH", got.DatabaseSpecific.Severity)
+	assert.False(t, got.DatabaseSpecific.GithubReviewed)
+	for _, a := range got.Affected {
+		assert.Equal(t, "Go", a.Package.Ecosystem)
+	}
+
+	adv.CVSSSeverities.CVSSv4.VectorString = "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:L/A:H"
+	_, err = ToOSV(&adv)
+	assert.ErrorContains(t, err, "invalid CVSS v4 vector string")
+
+	_, err = ToOSV(&repository.Advisory{GhsaID: "GHSA-mh63-6h87-95cp"})
+	assert.ErrorContains(t, err, "no update date")
+}
+
+func TestGetOSVAffected(t *testing.T) {
+	tests := []struct {
+		name    string
+		vuln    repository.Vulnerability
+		want    osv.Affected
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name: "Fixed version",
+			vuln: repository.Vulnerability{VulnerableVersionRange: ">= 1.0, < 1.2.3"},
+			want: osv.Affected{Ranges: []osv.Range{{
+				Type:   osv.RangeTypeEcosystem,
+				Events: []osv.Event{{Introduced: "1.0"}, {Fixed: "1.2.3"}},
+			}}},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Inclusive upper bound with patched version",
+			vuln: repository.Vulnerability{VulnerableVersionRange: "<= 1.2.3", PatchedVersions: "1.2.4, 2.0.0"},
+			want: osv.Affected{Ranges: []osv.Range{{
+				Type:   osv.RangeTypeEcosystem,
+				Events: []osv.Event{{Introduced: "0"}, {Fixed: "1.2.4"}},
+			}}},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Inclusive upper bound without patched version",
+			vuln: repository.Vulnerability{VulnerableVersionRange: ">= 1.0, <= 1.2.3"},
+			want: osv.Affected{
+				Ranges: []osv.Range{{
+					Type:   osv.RangeTypeEcosystem,
+					Events: []osv.Event{{Introduced: "1.0"}, {LastAffected: "1.2.3"}},
+				}},
+				DatabaseSpecific: osv.AffectedDatabaseSpecific{LastKnownAffectedVersionRange: "<= 1.2.3"},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "No upper bound",
+			vuln: repository.Vulnerability{VulnerableVersionRange: ">= 1.0"},
+			want: osv.Affected{Ranges: []osv.Range{{
+				Type:   osv.RangeTypeEcosystem,
+				Events: []osv.Event{{Introduced: "1.0"}},
+			}}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "Single version",
+			vuln:    repository.Vulnerability{VulnerableVersionRange: "= 1.2.3"},
+			want:    osv.Affected{Versions: []string{"1.2.3"}},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "Err: Exclusive lower bound",
+			vuln: repository.Vulnerability{VulnerableVersionRange: "> 1.0, < 1.2.3"},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorContains(t, err, "cannot express version range '> 1.0, < 1.2.3' in OSV")
+			},
+		},
+		{
+			name: "Err: Missing version",
+			vuln: repository.Vulnerability{VulnerableVersionRange: ">= 1.0, <"},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorContains(t, err, "invalid version range")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.vuln.Package = repository.Package{Ecosystem: "pip", Name: "Django"}
+			got, err := getOSVAffected(&tt.vuln)
+			if !tt.wantErr(t, err) || err != nil {
+				return
+			}
+			tt.want.Package = osv.Package{Ecosystem: "PyPI", Name: "Django", PURL: "pkg:pypi/django"}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestStore_SaveOSV(t *testing.T) {
+	var (
+		ghsa repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &ghsa)
+	adv := &csaf.Advisory{Advisory: gocsaf.Advisory{Document: &csaf.Document{
+		Tracking: &gocsaf.Tracking{
+			ID:                 utils.Ref(gocsaf.TrackingID("GHSA-mh63-6h87-95cp")),
+			InitialReleaseDate: utils.Ref("2025-03-21T20:51:37Z"),
+		},
+	}}}
+	s := NewStore(t.TempDir())
+
+	path, err := s.SaveOSV(&ghsa, adv, "")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, filepath.Join(s.Dir, "white", "2025", "ghsa-mh63-6h87-95cp.osv.json"), path)
+
+	g, err := LoadOSVAdvisory(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "GHSA-mh63-6h87-95cp", g.ID)
+	}
+	_, err = os.Stat(filepath.Join(s.Dir, "white", "2025", "ghsa-mh63-6h87-95cp.json"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+// referenceURLs returns the URLs of the references.
+func referenceURLs(refs []osv.Reference) (urls []string) {
+	for _, ref := range refs {
+		urls = append(urls, ref.URL)
+	}
+	return
+}
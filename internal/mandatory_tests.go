@@ -0,0 +1,1304 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/csaf-poc/ghsa/models/csaf"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+)
+
+// mandatoryTest is a mandatory test of section 6.1 of the CSAF standard. run returns the violations of the advisory,
+// each prefixed with its location in the document like the violations of ValidateCSAF.
+type mandatoryTest struct {
+	name string
+	run  func(adv *csaf.Advisory) []string
+}
+
+// mandatoryTests are the mandatory tests of section 6.1 of CSAF 2.0, which CSAF 2.1 documents have to pass as well.
+// Test 6.1.8 (Invalid CVSS) is covered by the schema validation, test 6.1.12 (Language) needs the IANA language
+// subtag registry and is not run.
+var mandatoryTests = []mandatoryTest{
+	{"6.1.1 Missing Definition of Product ID", testMissingProductID},
+	{"6.1.2 Multiple Definition of Product ID", testMultipleProductID},
+	{"6.1.3 Circular Definition of Product ID", testCircularProductID},
+	{"6.1.4 Missing Definition of Product Group ID", testMissingGroupID},
+	{"6.1.5 Multiple Definition of Product Group ID", testMultipleGroupID},
+	{"6.1.6 Contradicting Product Status", testContradictingProductStatus},
+	{"6.1.7 Multiple Scores with same Version per Product", testMultipleScores},
+	{"6.1.9 Invalid CVSS computation", testCVSSComputation},
+	{"6.1.10 Inconsistent CVSS", testInconsistentCVSS},
+	{"6.1.11 CWE", testCWE},
+	{"6.1.13 PURL", testPURL},
+	{"6.1.14 Sorted Revision History", testSortedRevisionHistory},
+	{"6.1.15 Translator", testTranslator},
+	{"6.1.16 Latest Document Version", testLatestDocumentVersion},
+	{"6.1.17 Document Status Draft", testDocumentStatusDraft},
+	{"6.1.18 Released Revision History", testReleasedRevisionHistory},
+	{"6.1.19 Revision History Entries for Pre-release Versions", testPreReleaseRevisions},
+	{"6.1.20 Non-draft Document Version", testNonDraftDocumentVersion},
+	{"6.1.21 Missing Item in Revision History", testMissingRevision},
+	{"6.1.22 Multiple Definition in Revision History", testMultipleRevision},
+	{"6.1.23 Multiple Use of Same CVE", testMultipleCVE},
+	{"6.1.24 Multiple Definition in Involvements", testMultipleInvolvement},
+	{"6.1.25 Multiple Use of Same Hash Algorithm", testMultipleHashAlgorithm},
+	{"6.1.26 Prohibited Document Category Name", testProhibitedCategory},
+	{"6.1.27.1 Document Notes", testProfileDocumentNotes},
+	{"6.1.27.2 Document References", testProfileDocumentReferences},
+	{"6.1.27.3 Vulnerabilities", testProfileNoVulnerabilities},
+	{"6.1.27.4 Product Tree", testProfileProductTree},
+	{"6.1.27.5 Vulnerability Notes", testProfileVulnerabilityNotes},
+	{"6.1.27.6 Product Status", testProfileProductStatus},
+	{"6.1.27.7 VEX Product Status", testProfileVEXProductStatus},
+	{"6.1.27.8 Vulnerability ID", testProfileVulnerabilityID},
+	{"6.1.27.9 Impact Statement", testProfileImpactStatement},
+	{"6.1.27.10 Action Statement", testProfileActionStatement},
+	{"6.1.27.11 Vulnerabilities", testProfileVulnerabilities},
+	{"6.1.28 Translation", testTranslation},
+	{"6.1.29 Remediation without Product Reference", testRemediationReference},
+	{"6.1.30 Mixed Integer and Semantic Versioning", testMixedVersioning},
+	{"6.1.31 Version Range in Product Version", testVersionRange},
+	{"6.1.32 Flag without Product Reference", testFlagReference},
+	{"6.1.33 Multiple Flags with VEX Justification Codes per Product", testMultipleFlags},
+}
+
+// MandatoryTestResult is the outcome of a mandatory test of section 6.1 of the CSAF standard.
+type MandatoryTestResult struct {
+	// Name of the test, e.g. "6.1.1 Missing Definition of Product ID"
+	Name string
+	// Violations lists the violations, each prefixed with the location in the document. The test passed if it is empty.
+	Violations []string
+}
+
+// MandatoryTestError is returned by ConvertBulk if the advisory fails mandatory tests of section 6.1 of the CSAF
+// standard.
+type MandatoryTestError struct {
+	// Failed lists the failed tests
+	Failed []MandatoryTestResult
+}
+
+func (e *MandatoryTestError) Error() string {
+	var (
+		names []string
+	)
+	for _, res := range e.Failed {
+		names = append(names, res.Name)
+	}
+	return fmt.Sprintf("advisory fails the mandatory tests %s", strings.Join(names, ", "))
+}
+
+// RunMandatoryTests runs the mandatory tests of section 6.1 of the CSAF standard on the advisory and returns the
+// result of every test.
+func RunMandatoryTests(adv *csaf.Advisory) (results []MandatoryTestResult) {
+	for _, test := range mandatoryTests {
+		results = append(results, MandatoryTestResult{Name: test.name, Violations: test.run(adv)})
+	}
+	return results
+}
+
+// RunRemoteTests validates the advisory with a remote validation service, e.g. the csaf_validator_service of
+// Secvisogram, which the validator of gocsaf uses to run the mandatory tests. Every test of the service is returned as
+// result; its errors are the violations.
+func RunRemoteTests(validator gocsaf.RemoteValidator, adv *csaf.Advisory) (results []MandatoryTestResult, err error) {
+	doc, err := csaf.ToDocument(adv)
+	if err != nil {
+		err = fmt.Errorf("could not marshal advisory: %v", err)
+		return
+	}
+	rvr, err := validator.Validate(doc)
+	if err != nil {
+		err = fmt.Errorf("remote validation failed: %w", err)
+		return
+	}
+	for _, test := range rvr.Tests {
+		res := MandatoryTestResult{Name: test.Name}
+		for _, e := range test.Error {
+			res.Violations = append(res.Violations, e.InstancePath+": "+e.Message)
+		}
+		if !test.Valid && len(res.Violations) == 0 {
+			res.Violations = []string{"test failed"}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// failedTests returns the failed tests of the results.
+func failedTests(results []MandatoryTestResult) (failed []MandatoryTestResult) {
+	for _, res := range results {
+		if len(res.Violations) > 0 {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// productRef is a product ID (or product group ID) and its location in the document.
+type productRef struct {
+	loc string
+	id  string
+}
+
+// definedProducts returns the definitions of product IDs in the product tree.
+func definedProducts(pt *csaf.ProductTree) (defs []productRef) {
+	var (
+		walk func(loc string, branches gocsaf.Branches)
+	)
+	add := func(loc string, fpn *gocsaf.FullProductName) {
+		if fpn != nil && fpn.ProductID != nil {
+			defs = append(defs, productRef{loc + "/product_id", string(*fpn.ProductID)})
+		}
+	}
+	walk = func(loc string, branches gocsaf.Branches) {
+		for i, b := range branches {
+			bloc := fmt.Sprintf("%s/branches/%d", loc, i)
+			add(bloc+"/product", b.Product)
+			walk(bloc, b.Branches)
+		}
+	}
+	if pt == nil {
+		return nil
+	}
+
+	walk("/product_tree", pt.Branches)
+	if pt.FullProductNames != nil {
+		for i, fpn := range *pt.FullProductNames {
+			add(fmt.Sprintf("/product_tree/full_product_names/%d", i), fpn)
+		}
+	}
+	if pt.RelationShips != nil {
+		for i, r := range *pt.RelationShips {
+			add(fmt.Sprintf("/product_tree/relationships/%d/full_product_name", i), r.FullProductName)
+		}
+	}
+	return defs
+}
+
+// definedGroups returns the definitions of product group IDs in the product tree.
+func definedGroups(pt *csaf.ProductTree) (defs []productRef) {
+	if pt == nil || pt.ProductGroups == nil {
+		return nil
+	}
+	for i, id := range pt.ProductGroups.ProductGroupIDs {
+		if id != nil {
+			defs = append(defs, productRef{fmt.Sprintf("/product_tree/product_groups/%d/group_id", i), string(*id)})
+		}
+	}
+	return defs
+}
+
+// productList returns the product IDs of a list at location loc.
+func productList(loc string, products *gocsaf.Products) (refs []productRef) {
+	if products == nil {
+		return nil
+	}
+	for i, id := range *products {
+		if id != nil {
+			refs = append(refs, productRef{fmt.Sprintf("%s/%d", loc, i), string(*id)})
+		}
+	}
+	return refs
+}
+
+// groupList returns the product group IDs of a list at location loc.
+func groupList(loc string, groups *gocsaf.ProductGroups) (refs []productRef) {
+	if groups == nil {
+		return nil
+	}
+	for i, id := range groups.ProductGroupIDs {
+		if id != nil {
+			refs = append(refs, productRef{fmt.Sprintf("%s/%d", loc, i), string(*id)})
+		}
+	}
+	return refs
+}
+
+// productStatusLists returns the lists of a product status by their names.
+func productStatusLists(s *gocsaf.ProductStatus) map[string]*gocsaf.Products {
+	if s == nil {
+		return nil
+	}
+	return map[string]*gocsaf.Products{
+		"first_affected":      s.FirstAffected,
+		"first_fixed":         s.FirstFixed,
+		"fixed":               s.Fixed,
+		"known_affected":      s.KnownAffected,
+		"known_not_affected":  s.KnownNotAffected,
+		"last_affected":       s.LastAffected,
+		"recommended":         s.Recommended,
+		"under_investigation": s.UnderInvestigation,
+	}
+}
+
+// sortedKeys returns the keys of the map in ascending order, so violations are reported in a stable order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// vulnerabilityLoc returns the location of the vulnerability with index i.
+func vulnerabilityLoc(i int) string {
+	return fmt.Sprintf("/vulnerabilities/%d", i)
+}
+
+// vulnerability21 returns the CSAF 2.1 properties of the vulnerability with index i or nil if there are none.
+func vulnerability21(adv *csaf.Advisory, i int) *csaf.Vulnerability21 {
+	if adv.CSAF21 == nil || i >= len(adv.CSAF21.Vulnerabilities) {
+		return nil
+	}
+	return adv.CSAF21.Vulnerabilities[i]
+}
+
+// scores returns the scores of the vulnerability. CSAF 2.1 documents replace them by metrics, see vulnerability21.
+func scores(adv *csaf.Advisory, v *gocsaf.Vulnerability) gocsaf.Scores {
+	if adv.CSAF21 != nil {
+		return nil
+	}
+	return v.Scores
+}
+
+// referencedProducts returns all references to product IDs outside their definitions.
+func referencedProducts(adv *csaf.Advisory) (refs []productRef) {
+	if pt := adv.ProductTree; pt != nil {
+		if pt.RelationShips != nil {
+			for i, r := range *pt.RelationShips {
+				loc := fmt.Sprintf("/product_tree/relationships/%d", i)
+				if r.ProductReference != nil {
+					refs = append(refs, productRef{loc + "/product_reference", string(*r.ProductReference)})
+				}
+				if r.RelatesToProductReference != nil {
+					refs = append(refs, productRef{loc + "/relates_to_product_reference",
+						string(*r.RelatesToProductReference)})
+				}
+			}
+		}
+	}
+
+	for i, v := range adv.Vulnerabilities {
+		loc := vulnerabilityLoc(i)
+		lists := productStatusLists(v.ProductStatus)
+		for _, name := range sortedKeys(lists) {
+			refs = append(refs, productList(loc+"/product_status/"+name, lists[name])...)
+		}
+		for j, r := range v.Remediations {
+			refs = append(refs, productList(fmt.Sprintf("%s/remediations/%d/product_ids", loc, j), r.ProductIds)...)
+		}
+		for j, s := range scores(adv, v) {
+			refs = append(refs, productList(fmt.Sprintf("%s/scores/%d/products", loc, j), s.Products)...)
+		}
+		for j, t := range v.Threats {
+			refs = append(refs, productList(fmt.Sprintf("%s/threats/%d/product_ids", loc, j), t.ProductIds)...)
+		}
+		for j, f := range v.Flags {
+			refs = append(refs, productList(fmt.Sprintf("%s/flags/%d/product_ids", loc, j), f.ProductIds)...)
+		}
+		if ext := vulnerability21(adv, i); ext != nil {
+			for j, m := range ext.Metrics {
+				for k, id := range m.Products {
+					refs = append(refs, productRef{fmt.Sprintf("%s/metrics/%d/products/%d", loc, j, k), id})
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// referencedGroups returns all references to product group IDs outside their definitions.
+func referencedGroups(adv *csaf.Advisory) (refs []productRef) {
+	for i, v := range adv.Vulnerabilities {
+		loc := vulnerabilityLoc(i)
+		for j, r := range v.Remediations {
+			refs = append(refs, groupList(fmt.Sprintf("%s/remediations/%d/group_ids", loc, j), r.GroupIds)...)
+		}
+		for j, t := range v.Threats {
+			refs = append(refs, groupList(fmt.Sprintf("%s/threats/%d/group_ids", loc, j), t.GroupIds)...)
+		}
+		for j, f := range v.Flags {
+			refs = append(refs, groupList(fmt.Sprintf("%s/flags/%d/group_ids", loc, j), f.GroupIDs)...)
+		}
+	}
+	return refs
+}
+
+// missingDefinitions returns a violation for every reference without definition.
+func missingDefinitions(defs []productRef, refs []productRef, what string) (violations []string) {
+	defined := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		defined[def.id] = true
+	}
+	for _, ref := range refs {
+		if !defined[ref.id] {
+			violations = append(violations, fmt.Sprintf("%s: %s %s is not defined", ref.loc, what, ref.id))
+		}
+	}
+	return violations
+}
+
+// multipleDefinitions returns a violation for every definition of an ID that was defined before.
+func multipleDefinitions(defs []productRef, what string) (violations []string) {
+	first := make(map[string]string, len(defs))
+	for _, def := range defs {
+		if loc, ok := first[def.id]; ok {
+			violations = append(violations, fmt.Sprintf("%s: %s %s is already defined at %s", def.loc, what, def.id,
+				loc))
+			continue
+		}
+		first[def.id] = def.loc
+	}
+	return violations
+}
+
+func testMissingProductID(adv *csaf.Advisory) []string {
+	return missingDefinitions(definedProducts(adv.ProductTree), referencedProducts(adv), "product ID")
+}
+
+func testMultipleProductID(adv *csaf.Advisory) []string {
+	return multipleDefinitions(definedProducts(adv.ProductTree), "product ID")
+}
+
+func testCircularProductID(adv *csaf.Advisory) (violations []string) {
+	if adv.ProductTree == nil || adv.ProductTree.RelationShips == nil {
+		return nil
+	}
+	// Map every product defined by a relationship to the products it is built of
+	parts := make(map[string][]string)
+	for _, r := range *adv.ProductTree.RelationShips {
+		if r.FullProductName == nil || r.FullProductName.ProductID == nil {
+			continue
+		}
+		id := string(*r.FullProductName.ProductID)
+		for _, ref := range []*gocsaf.ProductID{r.ProductReference, r.RelatesToProductReference} {
+			if ref != nil {
+				parts[id] = append(parts[id], string(*ref))
+			}
+		}
+	}
+
+	var (
+		contains func(id string, part string, seen map[string]bool) bool
+	)
+	contains = func(id string, part string, seen map[string]bool) bool {
+		if seen[id] {
+			return false
+		}
+		seen[id] = true
+		for _, p := range parts[id] {
+			if p == part || contains(p, part, seen) {
+				return true
+			}
+		}
+		return false
+	}
+	for i, r := range *adv.ProductTree.RelationShips {
+		if r.FullProductName == nil || r.FullProductName.ProductID == nil {
+			continue
+		}
+		id := string(*r.FullProductName.ProductID)
+		if contains(id, id, make(map[string]bool)) {
+			violations = append(violations, fmt.Sprintf("/product_tree/relationships/%d/full_product_name/product_id: "+
+				"product ID %s is defined by itself", i, id))
+		}
+	}
+	return violations
+}
+
+func testMissingGroupID(adv *csaf.Advisory) []string {
+	return missingDefinitions(definedGroups(adv.ProductTree), referencedGroups(adv), "product group ID")
+}
+
+func testMultipleGroupID(adv *csaf.Advisory) []string {
+	return multipleDefinitions(definedGroups(adv.ProductTree), "product group ID")
+}
+
+// productStatusGroups are the groups of contradicting product statuses, see test 6.1.6.
+var productStatusGroups = map[string]string{
+	"first_affected":      "affected",
+	"known_affected":      "affected",
+	"last_affected":       "affected",
+	"known_not_affected":  "not affected",
+	"first_fixed":         "fixed",
+	"fixed":               "fixed",
+	"under_investigation": "under investigation",
+}
+
+func testContradictingProductStatus(adv *csaf.Advisory) (violations []string) {
+	for i, v := range adv.Vulnerabilities {
+		var (
+			groups = make(map[string]string)
+			lists  = productStatusLists(v.ProductStatus)
+		)
+		for _, name := range sortedKeys(lists) {
+			group, ok := productStatusGroups[name]
+			if !ok {
+				continue
+			}
+			for _, ref := range productList(vulnerabilityLoc(i)+"/product_status/"+name, lists[name]) {
+				if other, ok := groups[ref.id]; ok && other != group {
+					violations = append(violations, fmt.Sprintf("%s: product %s is %s and %s", ref.loc, ref.id, other,
+						group))
+					continue
+				}
+				groups[ref.id] = group
+			}
+		}
+	}
+	return violations
+}
+
+func testMultipleScores(adv *csaf.Advisory) (violations []string) {
+	for i, v := range adv.Vulnerabilities {
+		var (
+			loc  = vulnerabilityLoc(i)
+			seen = make(map[string]bool)
+		)
+		check := func(key string, products []productRef) {
+			for _, ref := range products {
+				if seen[key+"|"+ref.id] {
+					violations = append(violations, fmt.Sprintf("%s: product %s has multiple %s scores", ref.loc,
+						ref.id, key))
+				}
+				seen[key+"|"+ref.id] = true
+			}
+		}
+
+		for j, s := range scores(adv, v) {
+			products := productList(fmt.Sprintf("%s/scores/%d/products", loc, j), s.Products)
+			if s.CVSS2 != nil && s.CVSS2.Version != nil {
+				check("CVSS "+string(*s.CVSS2.Version), products)
+			}
+			if s.CVSS3 != nil && s.CVSS3.Version != nil {
+				check("CVSS "+string(*s.CVSS3.Version), products)
+			}
+		}
+		ext := vulnerability21(adv, i)
+		if ext == nil {
+			continue
+		}
+		for j, m := range ext.Metrics {
+			var (
+				products []productRef
+			)
+			if m.Content == nil {
+				continue
+			}
+			for k, id := range m.Products {
+				products = append(products, productRef{fmt.Sprintf("%s/metrics/%d/products/%d", loc, j, k), id})
+			}
+			// CSAF 2.1 allows a score per version and source
+			source := ""
+			if m.Source != "" {
+				source = " (" + m.Source + ")"
+			}
+			if c := m.Content.CVSS3; c != nil && c.Version != nil {
+				check("CVSS "+string(*c.Version)+source, products)
+			}
+			if c := m.Content.CVSS4; c != nil {
+				check("CVSS "+c.Version+source, products)
+			}
+			if m.Content.EPSS != nil {
+				check("EPSS"+source, products)
+			}
+		}
+	}
+	return violations
+}
+
+// cvssScores returns the CVSS v3 and v4 scores of the advisory by their location.
+func cvssScores(adv *csaf.Advisory) (cvss3 map[string]*gocsaf.CVSS3, cvss4 map[string]*csaf.CVSS4) {
+	cvss3, cvss4 = make(map[string]*gocsaf.CVSS3), make(map[string]*csaf.CVSS4)
+	for i, v := range adv.Vulnerabilities {
+		loc := vulnerabilityLoc(i)
+		for j, s := range scores(adv, v) {
+			if s.CVSS3 != nil {
+				cvss3[fmt.Sprintf("%s/scores/%d/cvss_v3", loc, j)] = s.CVSS3
+			}
+		}
+		ext := vulnerability21(adv, i)
+		if ext == nil {
+			continue
+		}
+		for j, m := range ext.Metrics {
+			if m.Content == nil {
+				continue
+			}
+			if m.Content.CVSS3 != nil {
+				cvss3[fmt.Sprintf("%s/metrics/%d/content/cvss_v3", loc, j)] = m.Content.CVSS3
+			}
+			if m.Content.CVSS4 != nil {
+				cvss4[fmt.Sprintf("%s/metrics/%d/content/cvss_v4", loc, j)] = m.Content.CVSS4
+			}
+		}
+	}
+	return cvss3, cvss4
+}
+
+func testCVSSComputation(adv *csaf.Advisory) (violations []string) {
+	cvss3, cvss4 := cvssScores(adv)
+	for _, loc := range sortedKeys(cvss3) {
+		c := cvss3[loc]
+		if c.VectorString == nil || c.BaseScore == nil {
+			continue
+		}
+		score, ok := cvss3BaseScore(string(*c.VectorString))
+		if !ok {
+			violations = append(violations, loc+"/vectorString: invalid CVSS v3 vector string")
+			continue
+		}
+		if *c.BaseScore != score {
+			violations = append(violations, fmt.Sprintf("%s/baseScore: %v does not match the computed score %v", loc,
+				*c.BaseScore, score))
+		}
+		if c.BaseSeverity != nil && *c.BaseSeverity != cvss3Severity(score) {
+			violations = append(violations, fmt.Sprintf("%s/baseSeverity: %s does not match the computed severity %s",
+				loc, *c.BaseSeverity, cvss3Severity(score)))
+		}
+	}
+	for _, loc := range sortedKeys(cvss4) {
+		c := cvss4[loc]
+		score, ok := cvss4BaseScore(c.VectorString)
+		if !ok {
+			violations = append(violations, loc+"/vectorString: invalid CVSS v4 vector string")
+			continue
+		}
+		if c.BaseScore != score {
+			violations = append(violations, fmt.Sprintf("%s/baseScore: %v does not match the computed score %v", loc,
+				c.BaseScore, score))
+		}
+		if c.BaseSeverity != string(cvss3Severity(score)) {
+			violations = append(violations, fmt.Sprintf("%s/baseSeverity: %s does not match the computed severity %s",
+				loc, c.BaseSeverity, cvss3Severity(score)))
+		}
+	}
+	return violations
+}
+
+// cvss3Properties maps the base metrics of a CVSS v3 vector string to the properties of the CVSS v3 schema.
+var cvss3Properties = []struct {
+	metric   string
+	property string
+	values   map[string]string
+}{
+	{"AV", "attackVector", map[string]string{"N": "NETWORK", "A": "ADJACENT_NETWORK", "L": "LOCAL", "P": "PHYSICAL"}},
+	{"AC", "attackComplexity", map[string]string{"L": "LOW", "H": "HIGH"}},
+	{"PR", "privilegesRequired", map[string]string{"N": "NONE", "L": "LOW", "H": "HIGH"}},
+	{"UI", "userInteraction", map[string]string{"N": "NONE", "R": "REQUIRED"}},
+	{"S", "scope", map[string]string{"U": "UNCHANGED", "C": "CHANGED"}},
+	{"C", "confidentialityImpact", map[string]string{"N": "NONE", "L": "LOW", "H": "HIGH"}},
+	{"I", "integrityImpact", map[string]string{"N": "NONE", "L": "LOW", "H": "HIGH"}},
+	{"A", "availabilityImpact", map[string]string{"N": "NONE", "L": "LOW", "H": "HIGH"}},
+}
+
+func testInconsistentCVSS(adv *csaf.Advisory) (violations []string) {
+	cvss3, _ := cvssScores(adv)
+	for _, loc := range sortedKeys(cvss3) {
+		var (
+			c          = cvss3[loc]
+			properties map[string]any
+			metrics    = make(map[string]string)
+		)
+		if c.VectorString == nil {
+			continue
+		}
+		b, err := json.Marshal(c)
+		if err != nil || json.Unmarshal(b, &properties) != nil {
+			continue
+		}
+		for _, part := range strings.Split(string(*c.VectorString), "/")[1:] {
+			metric, value, _ := strings.Cut(part, ":")
+			metrics[metric] = value
+		}
+		if c.Version != nil && !strings.HasPrefix(string(*c.VectorString), "CVSS:"+string(*c.Version)+"/") {
+			violations = append(violations, fmt.Sprintf("%s/version: version %s does not match the vector string",
+				loc, *c.Version))
+		}
+		for _, p := range cvss3Properties {
+			value, ok := properties[p.property].(string)
+			if ok && value != "" && value != p.values[metrics[p.metric]] {
+				violations = append(violations, fmt.Sprintf("%s/%s: %s does not match the vector string", loc,
+					p.property, value))
+			}
+		}
+	}
+	return violations
+}
+
+func testCWE(adv *csaf.Advisory) (violations []string) {
+	check := func(loc string, id string, name string) {
+		// The embedded catalogue may be a subset of the CWE List, so only the names of known CWEs are checked
+		if official, ok := cweCatalogue[id]; ok && name != official {
+			violations = append(violations, fmt.Sprintf("%s/name: '%s' is not the name of %s", loc, name, id))
+		}
+	}
+	for i, v := range adv.Vulnerabilities {
+		loc := vulnerabilityLoc(i)
+		if v.CWE != nil && v.CWE.ID != nil && v.CWE.Name != nil {
+			check(loc+"/cwe", string(*v.CWE.ID), *v.CWE.Name)
+		}
+		if ext := vulnerability21(adv, i); ext != nil {
+			for j, cwe := range ext.CWEs {
+				check(fmt.Sprintf("%s/cwes/%d", loc, j), cwe.ID, cwe.Name)
+			}
+		}
+	}
+	return violations
+}
+
+// purlTypePattern is the pattern of the type of a package URL.
+var purlTypePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9.+-]*$`)
+
+// checkPURL checks a package URL against the package URL specification. Returns an empty string if it is valid or the
+// reason otherwise.
+func checkPURL(purl string) string {
+	rest, ok := strings.CutPrefix(purl, "pkg:")
+	if !ok {
+		return "scheme is not pkg"
+	}
+	rest, _, _ = strings.Cut(rest, "#")
+	rest, qualifiers, _ := strings.Cut(rest, "?")
+	if qualifiers != "" {
+		if _, err := url.ParseQuery(qualifiers); err != nil {
+			return "invalid qualifiers"
+		}
+	}
+	rest = strings.TrimLeft(rest, "/")
+	typ, path, _ := strings.Cut(rest, "/")
+	if !purlTypePattern.MatchString(typ) {
+		return "invalid type"
+	}
+	if i := strings.LastIndex(path, "@"); i >= 0 {
+		path = path[:i]
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if segments[len(segments)-1] == "" {
+		return "name is missing"
+	}
+	for _, segment := range segments {
+		if _, err := url.PathUnescape(segment); err != nil {
+			return "invalid percent-encoding"
+		}
+	}
+	return ""
+}
+
+func testPURL(adv *csaf.Advisory) (violations []string) {
+	var (
+		walk func(loc string, branches gocsaf.Branches)
+	)
+	check := func(loc string, fpn *gocsaf.FullProductName) {
+		if fpn == nil || fpn.ProductIdentificationHelper == nil || fpn.ProductIdentificationHelper.PURL == nil {
+			return
+		}
+		if reason := checkPURL(string(*fpn.ProductIdentificationHelper.PURL)); reason != "" {
+			violations = append(violations, fmt.Sprintf("%s/product_identification_helper/purl: invalid package URL: %s",
+				loc, reason))
+		}
+	}
+	walk = func(loc string, branches gocsaf.Branches) {
+		for i, b := range branches {
+			bloc := fmt.Sprintf("%s/branches/%d", loc, i)
+			check(bloc+"/product", b.Product)
+			walk(bloc, b.Branches)
+		}
+	}
+	pt := adv.ProductTree
+	if pt == nil {
+		return nil
+	}
+	walk("/product_tree", pt.Branches)
+	if pt.FullProductNames != nil {
+		for i, fpn := range *pt.FullProductNames {
+			check(fmt.Sprintf("/product_tree/full_product_names/%d", i), fpn)
+		}
+	}
+	if pt.RelationShips != nil {
+		for i, r := range *pt.RelationShips {
+			check(fmt.Sprintf("/product_tree/relationships/%d/full_product_name", i), r.FullProductName)
+		}
+	}
+	return violations
+}
+
+// revisionNumber is a version of a document in integer or semantic versioning.
+type revisionNumber struct {
+	// semantic is true for semantic versioning
+	semantic            bool
+	major, minor, patch int
+	preRelease          string
+}
+
+// integerVersionPattern is the pattern of an integer version as given by the CSAF standard.
+var integerVersionPattern = regexp.MustCompile(`^(0|[1-9]\d*)$`)
+
+// semverPattern is the pattern of a semantic version as given by the CSAF standard.
+var semverPattern = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+	`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+// parseRevisionNumber parses a version of a document. Build metadata is dropped. ok is false if the version is
+// invalid.
+func parseRevisionNumber(s *gocsaf.RevisionNumber) (n revisionNumber, ok bool) {
+	if s == nil {
+		return n, false
+	}
+	if integerVersionPattern.MatchString(string(*s)) {
+		n.major, _ = strconv.Atoi(string(*s))
+		return n, true
+	}
+	m := semverPattern.FindStringSubmatch(string(*s))
+	if m == nil {
+		return n, false
+	}
+	n = revisionNumber{semantic: true, preRelease: m[4]}
+	n.major, _ = strconv.Atoi(m[1])
+	n.minor, _ = strconv.Atoi(m[2])
+	n.patch, _ = strconv.Atoi(m[3])
+	return n, true
+}
+
+// compare compares the revision numbers by precedence, see semantic versioning.
+func (n revisionNumber) compare(o revisionNumber) int {
+	if c := n.major - o.major; c != 0 {
+		return c
+	}
+	if c := n.minor - o.minor; c != 0 {
+		return c
+	}
+	if c := n.patch - o.patch; c != 0 {
+		return c
+	}
+	switch {
+	case n.preRelease == o.preRelease:
+		return 0
+	case n.preRelease == "":
+		return 1
+	case o.preRelease == "":
+		return -1
+	default:
+		return strings.Compare(n.preRelease, o.preRelease)
+	}
+}
+
+// String formats the revision number without build metadata.
+func (n revisionNumber) String() string {
+	if !n.semantic {
+		return strconv.Itoa(n.major)
+	}
+	s := fmt.Sprintf("%d.%d.%d", n.major, n.minor, n.patch)
+	if n.preRelease != "" {
+		s += "-" + n.preRelease
+	}
+	return s
+}
+
+// revision is an item of the revision history with its location.
+type revision struct {
+	loc    string
+	number revisionNumber
+	date   time.Time
+}
+
+// tracking returns the tracking of the advisory or an empty one.
+func tracking(adv *csaf.Advisory) *gocsaf.Tracking {
+	if adv.Document == nil || adv.Document.Tracking == nil {
+		return &gocsaf.Tracking{}
+	}
+	return adv.Document.Tracking
+}
+
+// revisions returns the items of the revision history with valid numbers.
+func revisions(adv *csaf.Advisory) (revs []revision) {
+	for i, r := range tracking(adv).RevisionHistory {
+		n, ok := parseRevisionNumber(r.Number)
+		if !ok {
+			continue
+		}
+		rev := revision{loc: fmt.Sprintf("/document/tracking/revision_history/%d", i), number: n}
+		if r.Date != nil {
+			rev.date, _ = time.Parse(time.RFC3339, *r.Date)
+		}
+		revs = append(revs, rev)
+	}
+	return revs
+}
+
+// documentStatus returns the status of the advisory or an empty string.
+func documentStatus(adv *csaf.Advisory) gocsaf.TrackingStatus {
+	if status := tracking(adv).Status; status != nil {
+		return *status
+	}
+	return ""
+}
+
+// isDraft reports whether the version is a draft version: 0, 0.y.z or a pre-release.
+func (n revisionNumber) isDraft() bool {
+	return n.major == 0 || n.preRelease != ""
+}
+
+func testSortedRevisionHistory(adv *csaf.Advisory) (violations []string) {
+	revs := revisions(adv)
+	byNumber := slices.Clone(revs)
+	slices.SortStableFunc(byNumber, func(a, b revision) int { return a.number.compare(b.number) })
+	for i := 1; i < len(byNumber); i++ {
+		if byNumber[i].date.Before(byNumber[i-1].date) {
+			violations = append(violations, fmt.Sprintf("%s: revision %s is dated before revision %s",
+				byNumber[i].loc, byNumber[i].number, byNumber[i-1].number))
+		}
+	}
+	return violations
+}
+
+func testTranslator(adv *csaf.Advisory) []string {
+	doc := adv.Document
+	if doc == nil || doc.Publisher == nil || doc.Publisher.Category == nil ||
+		*doc.Publisher.Category != gocsaf.CSAFCategoryTranslator {
+		return nil
+	}
+	if doc.SourceLang == nil {
+		return []string{"/document/source_lang: the source language of a translation is required"}
+	}
+	return nil
+}
+
+func testLatestDocumentVersion(adv *csaf.Advisory) []string {
+	revs := revisions(adv)
+	version, ok := parseRevisionNumber(tracking(adv).Version)
+	if !ok || len(revs) == 0 {
+		return nil
+	}
+	slices.SortStableFunc(revs, func(a, b revision) int { return a.date.Compare(b.date) })
+	latest := revs[len(revs)-1].number
+	if documentStatus(adv) == gocsaf.CSAFTrackingStatusDraft {
+		version.preRelease, latest.preRelease = "", ""
+	}
+	if version.compare(latest) != 0 || version.semantic != latest.semantic {
+		return []string{fmt.Sprintf("/document/tracking/version: version %s is not the latest revision %s", version,
+			latest)}
+	}
+	return nil
+}
+
+func testDocumentStatusDraft(adv *csaf.Advisory) []string {
+	version, ok := parseRevisionNumber(tracking(adv).Version)
+	if ok && version.isDraft() && documentStatus(adv) != gocsaf.CSAFTrackingStatusDraft {
+		return []string{fmt.Sprintf("/document/tracking/status: documents with version %s must be drafts", version)}
+	}
+	return nil
+}
+
+func testReleasedRevisionHistory(adv *csaf.Advisory) (violations []string) {
+	if documentStatus(adv) == gocsaf.CSAFTrackingStatusDraft {
+		return nil
+	}
+	for _, rev := range revisions(adv) {
+		if rev.number.major == 0 {
+			violations = append(violations, fmt.Sprintf("%s/number: released documents must not have revision %s",
+				rev.loc, rev.number))
+		}
+	}
+	return violations
+}
+
+func testPreReleaseRevisions(adv *csaf.Advisory) (violations []string) {
+	for _, rev := range revisions(adv) {
+		if rev.number.preRelease != "" {
+			violations = append(violations, fmt.Sprintf("%s/number: revision %s is a pre-release", rev.loc,
+				rev.number))
+		}
+	}
+	return violations
+}
+
+func testNonDraftDocumentVersion(adv *csaf.Advisory) []string {
+	version, ok := parseRevisionNumber(tracking(adv).Version)
+	if ok && version.preRelease != "" && documentStatus(adv) != gocsaf.CSAFTrackingStatusDraft {
+		return []string{fmt.Sprintf("/document/tracking/version: version %s of a released document is a pre-release",
+			version)}
+	}
+	return nil
+}
+
+func testMissingRevision(adv *csaf.Advisory) (violations []string) {
+	revs := revisions(adv)
+	if len(revs) == 0 {
+		return nil
+	}
+	// Integer versions have to be consecutive, semantic versions their major versions
+	slices.SortStableFunc(revs, func(a, b revision) int { return a.number.compare(b.number) })
+	if first := revs[0].number.major; first > 1 {
+		violations = append(violations, fmt.Sprintf("%s/number: revision history starts with %s instead of 0 or 1",
+			revs[0].loc, revs[0].number))
+	}
+	for i := 1; i < len(revs); i++ {
+		if revs[i].number.major > revs[i-1].number.major+1 {
+			violations = append(violations, fmt.Sprintf("%s/number: revisions between %s and %s are missing",
+				revs[i].loc, revs[i-1].number, revs[i].number))
+		}
+	}
+	return violations
+}
+
+func testMultipleRevision(adv *csaf.Advisory) (violations []string) {
+	seen := make(map[string]string)
+	for _, rev := range revisions(adv) {
+		number := rev.number.String()
+		if loc, ok := seen[number]; ok {
+			violations = append(violations, fmt.Sprintf("%s/number: revision %s is already defined at %s", rev.loc,
+				number, loc))
+			continue
+		}
+		seen[number] = rev.loc
+	}
+	return violations
+}
+
+func testMultipleCVE(adv *csaf.Advisory) (violations []string) {
+	seen := make(map[gocsaf.CVE]string)
+	for i, v := range adv.Vulnerabilities {
+		if v.CVE == nil {
+			continue
+		}
+		loc := vulnerabilityLoc(i) + "/cve"
+		if first, ok := seen[*v.CVE]; ok {
+			violations = append(violations, fmt.Sprintf("%s: %s is already used at %s", loc, *v.CVE, first))
+			continue
+		}
+		seen[*v.CVE] = loc
+	}
+	return violations
+}
+
+func testMultipleInvolvement(adv *csaf.Advisory) (violations []string) {
+	for i, v := range adv.Vulnerabilities {
+		seen := make(map[string]bool)
+		for j, inv := range v.Involvements {
+			if inv.Party == nil {
+				continue
+			}
+			date := ""
+			if inv.Date != nil {
+				date = *inv.Date
+			}
+			key := string(*inv.Party) + "|" + date
+			if seen[key] {
+				violations = append(violations, fmt.Sprintf("%s/involvements/%d: party %s is already involved at %s",
+					vulnerabilityLoc(i), j, *inv.Party, date))
+			}
+			seen[key] = true
+		}
+	}
+	return violations
+}
+
+func testMultipleHashAlgorithm(adv *csaf.Advisory) (violations []string) {
+	var (
+		walk func(loc string, branches gocsaf.Branches)
+	)
+	check := func(loc string, fpn *gocsaf.FullProductName) {
+		if fpn == nil || fpn.ProductIdentificationHelper == nil || fpn.ProductIdentificationHelper.Hashes == nil {
+			return
+		}
+		seen := make(map[string]bool)
+		for i, h := range fpn.ProductIdentificationHelper.Hashes.FileHashes {
+			if h.Algorithm == nil {
+				continue
+			}
+			if seen[*h.Algorithm] {
+				violations = append(violations, fmt.Sprintf("%s/product_identification_helper/hashes/file_hashes/%d: "+
+					"algorithm %s is already used", loc, i, *h.Algorithm))
+			}
+			seen[*h.Algorithm] = true
+		}
+	}
+	walk = func(loc string, branches gocsaf.Branches) {
+		for i, b := range branches {
+			bloc := fmt.Sprintf("%s/branches/%d", loc, i)
+			check(bloc+"/product", b.Product)
+			walk(bloc, b.Branches)
+		}
+	}
+	pt := adv.ProductTree
+	if pt == nil {
+		return nil
+	}
+	walk("/product_tree", pt.Branches)
+	if pt.FullProductNames != nil {
+		for i, fpn := range *pt.FullProductNames {
+			check(fmt.Sprintf("/product_tree/full_product_names/%d", i), fpn)
+		}
+	}
+	if pt.RelationShips != nil {
+		for i, r := range *pt.RelationShips {
+			check(fmt.Sprintf("/product_tree/relationships/%d/full_product_name", i), r.FullProductName)
+		}
+	}
+	return violations
+}
+
+// advisoryCategory returns the document category of the advisory, taking the category of CSAF 2.1 into account.
+func advisoryCategory(adv *csaf.Advisory) string {
+	if adv.CSAF21 != nil && adv.CSAF21.Document != nil && adv.CSAF21.Document.Category != "" {
+		return adv.CSAF21.Document.Category
+	}
+	if adv.Document == nil || adv.Document.Category == nil {
+		return ""
+	}
+	return string(*adv.Document.Category)
+}
+
+func testProhibitedCategory(adv *csaf.Advisory) []string {
+	if err := checkDocumentCategory(advisoryCategory(adv)); err != nil {
+		return []string{"/document/category: " + err.Error()}
+	}
+	return nil
+}
+
+// profileTest returns a test that only applies to documents of the given profiles.
+func profileTest(test func(adv *csaf.Advisory) []string, categories ...string) func(adv *csaf.Advisory) []string {
+	return func(adv *csaf.Advisory) []string {
+		if !slices.Contains(categories, advisoryCategory(adv)) {
+			return nil
+		}
+		return test(adv)
+	}
+}
+
+var testProfileDocumentNotes = profileTest(func(adv *csaf.Advisory) []string {
+	if adv.Document == nil {
+		return nil
+	}
+	for _, n := range adv.Document.Notes {
+		if n.NoteCategory != nil && slices.Contains([]gocsaf.NoteCategory{gocsaf.CSAFNoteCategoryDescription,
+			gocsaf.CSAFNoteCategoryDetails, gocsaf.CSAFNoteCategoryGeneral, gocsaf.CSAFNoteCategorySummary},
+			*n.NoteCategory) {
+			return nil
+		}
+	}
+	return []string{"/document/notes: a note of category description, details, general or summary is required"}
+}, "csaf_informational_advisory", "csaf_security_incident_response")
+
+var testProfileDocumentReferences = profileTest(func(adv *csaf.Advisory) []string {
+	if adv.Document == nil {
+		return nil
+	}
+	for _, r := range adv.Document.References {
+		// The category defaults to external
+		if r.ReferenceCategory == nil || *r.ReferenceCategory == string(gocsaf.CSAFReferenceCategoryExternal) {
+			return nil
+		}
+	}
+	return []string{"/document/references: a reference of category external is required"}
+}, "csaf_informational_advisory", "csaf_security_incident_response")
+
+var testProfileNoVulnerabilities = profileTest(func(adv *csaf.Advisory) []string {
+	if len(adv.Vulnerabilities) > 0 {
+		return []string{"/vulnerabilities: informational advisories must not have vulnerabilities"}
+	}
+	return nil
+}, "csaf_informational_advisory")
+
+var testProfileProductTree = profileTest(func(adv *csaf.Advisory) []string {
+	if adv.ProductTree == nil {
+		return []string{"/product_tree: the product tree is required"}
+	}
+	return nil
+}, csaf.CategorySecurityAdvisory, csaf.CategoryVEX)
+
+var testProfileVulnerabilityNotes = profileTest(func(adv *csaf.Advisory) (violations []string) {
+	for i, v := range adv.Vulnerabilities {
+		if len(v.Notes) == 0 {
+			violations = append(violations, vulnerabilityLoc(i)+"/notes: notes are required")
+		}
+	}
+	return violations
+}, csaf.CategorySecurityAdvisory, csaf.CategoryVEX)
+
+var testProfileProductStatus = profileTest(func(adv *csaf.Advisory) (violations []string) {
+	for i, v := range adv.Vulnerabilities {
+		if v.ProductStatus == nil {
+			violations = append(violations, vulnerabilityLoc(i)+"/product_status: the product status is required")
+		}
+	}
+	return violations
+}, csaf.CategorySecurityAdvisory)
+
+var testProfileVEXProductStatus = profileTest(func(adv *csaf.Advisory) (violations []string) {
+	for i, v := range adv.Vulnerabilities {
+		s := v.ProductStatus
+		if s == nil || (s.Fixed == nil && s.KnownAffected == nil && s.KnownNotAffected == nil &&
+			s.UnderInvestigation == nil) {
+			violations = append(violations, vulnerabilityLoc(i)+"/product_status: one of fixed, known_affected, "+
+				"known_not_affected or under_investigation is required")
+		}
+	}
+	return violations
+}, csaf.CategoryVEX)
+
+var testProfileVulnerabilityID = profileTest(func(adv *csaf.Advisory) (violations []string) {
+	for i, v := range adv.Vulnerabilities {
+		if v.CVE == nil && len(v.IDs) == 0 {
+			violations = append(violations, vulnerabilityLoc(i)+": cve or ids is required")
+		}
+	}
+	return violations
+}, csaf.CategoryVEX)
+
+// statusProducts returns the products of a list of the product status that are not covered by products, e.g. by the
+// flags of the vulnerability.
+func statusProducts(loc string, list *gocsaf.Products, covered map[string]bool) (missing []productRef) {
+	for _, ref := range productList(loc, list) {
+		if !covered[ref.id] {
+			missing = append(missing, ref)
+		}
+	}
+	return missing
+}
+
+var testProfileImpactStatement = profileTest(func(adv *csaf.Advisory) (violations []string) {
+	for i, v := range adv.Vulnerabilities {
+		if v.ProductStatus == nil {
+			continue
+		}
+		covered := make(map[string]bool)
+		for _, f := range v.Flags {
+			for _, ref := range productList("", f.ProductIds) {
+				covered[ref.id] = true
+			}
+		}
+		for _, t := range v.Threats {
+			if t.Category != nil && *t.Category == gocsaf.CSAFThreatCategoryImpact {
+				for _, ref := range productList("", t.ProductIds) {
+					covered[ref.id] = true
+				}
+			}
+		}
+		loc := vulnerabilityLoc(i) + "/product_status/known_not_affected"
+		for _, ref := range statusProducts(loc, v.ProductStatus.KnownNotAffected, covered) {
+			violations = append(violations, fmt.Sprintf("%s: product %s has no flag or impact statement", ref.loc,
+				ref.id))
+		}
+	}
+	return violations
+}, csaf.CategoryVEX)
+
+var testProfileActionStatement = profileTest(func(adv *csaf.Advisory) (violations []string) {
+	for i, v := range adv.Vulnerabilities {
+		if v.ProductStatus == nil {
+			continue
+		}
+		covered := make(map[string]bool)
+		for _, r := range v.Remediations {
+			for _, ref := range productList("", r.ProductIds) {
+				covered[ref.id] = true
+			}
+		}
+		loc := vulnerabilityLoc(i) + "/product_status/known_affected"
+		for _, ref := range statusProducts(loc, v.ProductStatus.KnownAffected, covered) {
+			violations = append(violations, fmt.Sprintf("%s: product %s has no remediation", ref.loc, ref.id))
+		}
+	}
+	return violations
+}, csaf.CategoryVEX)
+
+var testProfileVulnerabilities = profileTest(func(adv *csaf.Advisory) []string {
+	if len(adv.Vulnerabilities) == 0 {
+		return []string{"/vulnerabilities: vulnerabilities are required"}
+	}
+	return nil
+}, csaf.CategorySecurityAdvisory, csaf.CategoryVEX)
+
+func testTranslation(adv *csaf.Advisory) []string {
+	doc := adv.Document
+	if doc != nil && doc.Lang != nil && doc.SourceLang != nil && strings.EqualFold(string(*doc.Lang),
+		string(*doc.SourceLang)) {
+		return []string{"/document/source_lang: the source language equals the language of the document"}
+	}
+	return nil
+}
+
+func testRemediationReference(adv *csaf.Advisory) (violations []string) {
+	for i, v := range adv.Vulnerabilities {
+		for j, r := range v.Remediations {
+			if r.ProductIds == nil && r.GroupIds == nil {
+				violations = append(violations, fmt.Sprintf("%s/remediations/%d: product_ids or group_ids is required",
+					vulnerabilityLoc(i), j))
+			}
+		}
+	}
+	return violations
+}
+
+func testMixedVersioning(adv *csaf.Advisory) (violations []string) {
+	version, ok := parseRevisionNumber(tracking(adv).Version)
+	if !ok {
+		return nil
+	}
+	for _, rev := range revisions(adv) {
+		if rev.number.semantic != version.semantic {
+			violations = append(violations, fmt.Sprintf("%s/number: revision %s mixes integer and semantic versioning",
+				rev.loc, rev.number))
+		}
+	}
+	return violations
+}
+
+// versionRangePattern matches the operators and keywords of version ranges, see test 6.1.31.
+var versionRangePattern = regexp.MustCompile(`(?i)(<|>|\b(after|all|before|earlier|later|prior|versions)\b)`)
+
+func testVersionRange(adv *csaf.Advisory) (violations []string) {
+	var (
+		walk func(loc string, branches gocsaf.Branches)
+	)
+	walk = func(loc string, branches gocsaf.Branches) {
+		for i, b := range branches {
+			bloc := fmt.Sprintf("%s/branches/%d", loc, i)
+			if b.Category != nil && *b.Category == gocsaf.CSAFBranchCategoryProductVersion && b.Name != nil &&
+				versionRangePattern.MatchString(*b.Name) {
+				violations = append(violations, fmt.Sprintf("%s/name: product version %s is a version range", bloc,
+					*b.Name))
+			}
+			walk(bloc, b.Branches)
+		}
+	}
+	if adv.ProductTree != nil {
+		walk("/product_tree", adv.ProductTree.Branches)
+	}
+	return violations
+}
+
+func testFlagReference(adv *csaf.Advisory) (violations []string) {
+	for i, v := range adv.Vulnerabilities {
+		for j, f := range v.Flags {
+			if f.ProductIds == nil && f.GroupIDs == nil {
+				violations = append(violations, fmt.Sprintf("%s/flags/%d: product_ids or group_ids is required",
+					vulnerabilityLoc(i), j))
+			}
+		}
+	}
+	return violations
+}
+
+func testMultipleFlags(adv *csaf.Advisory) (violations []string) {
+	for i, v := range adv.Vulnerabilities {
+		flagged := make(map[string]bool)
+		for j, f := range v.Flags {
+			for _, ref := range productList(fmt.Sprintf("%s/flags/%d/product_ids", vulnerabilityLoc(i), j),
+				f.ProductIds) {
+				if flagged[ref.id] {
+					violations = append(violations, fmt.Sprintf("%s: product %s has multiple flags", ref.loc, ref.id))
+				}
+				flagged[ref.id] = true
+			}
+		}
+	}
+	return violations
+}
@@ -0,0 +1,492 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/csaf-poc/ghsa/internal/utils"
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+	"github.com/stretchr/testify/assert"
+)
+
+// exampleAdvisory converts the example repository advisory with the given options.
+func exampleAdvisory(t *testing.T, opts ...ConvertOption) *csaf.Advisory {
+	t.Helper()
+	var (
+		ghsa repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &ghsa)
+	adv, err := ToCSAF(&ghsa, opts...)
+	if err != nil {
+		t.Fatalf("could not convert example: %v", err)
+	}
+	return adv
+}
+
+func TestRunMandatoryTests_Examples(t *testing.T) {
+	for _, version := range []string{csaf.Version20, csaf.Version21} {
+		for _, profile := range []string{"", csaf.CategorySecurityAdvisory, csaf.CategoryVEX} {
+			t.Run(version+" "+profile, func(t *testing.T) {
+				results := RunMandatoryTests(exampleAdvisory(t, WithCSAFVersion(version), WithProfile(profile)))
+				assert.Len(t, results, len(mandatoryTests))
+				assert.Empty(t, failedTests(results))
+			})
+		}
+	}
+}
+
+func TestRunMandatoryTests(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []ConvertOption
+		modify  func(adv *csaf.Advisory)
+		test    string
+		wantErr []string
+	}{
+		{
+			name: "Missing product ID",
+			modify: func(adv *csaf.Advisory) {
+				*adv.Vulnerabilities[0].Remediations[0].ProductIds = append(
+					*adv.Vulnerabilities[0].Remediations[0].ProductIds, utils.Ref(gocsaf.ProductID("CSAFPID-9999")))
+			},
+			test:    "6.1.1 Missing Definition of Product ID",
+			wantErr: []string{"/vulnerabilities/0/remediations/0/product_ids/1: product ID CSAFPID-9999 is not defined"},
+		},
+		{
+			name: "Multiple product ID",
+			modify: func(adv *csaf.Advisory) {
+				adv.ProductTree.Branches[0].Branches[1].Product.ProductID = utils.Ref(gocsaf.ProductID("CSAFPID-0001"))
+			},
+			test: "6.1.2 Multiple Definition of Product ID",
+			wantErr: []string{"/product_tree/branches/0/branches/1/product/product_id: product ID CSAFPID-0001 is " +
+				"already defined at /product_tree/branches/0/branches/0/product/product_id"},
+		},
+		{
+			name: "Circular product ID",
+			modify: func(adv *csaf.Advisory) {
+				adv.ProductTree.RelationShips = &gocsaf.Relationships{{
+					Category: utils.Ref(gocsaf.CSAFRelationshipCategoryDefaultComponentOf),
+					FullProductName: &gocsaf.FullProductName{
+						Name:      utils.Ref("jwt in jwt"),
+						ProductID: utils.Ref(gocsaf.ProductID("CSAFPID-0005")),
+					},
+					ProductReference:          utils.Ref(gocsaf.ProductID("CSAFPID-0001")),
+					RelatesToProductReference: utils.Ref(gocsaf.ProductID("CSAFPID-0005")),
+				}}
+			},
+			test: "6.1.3 Circular Definition of Product ID",
+			wantErr: []string{"/product_tree/relationships/0/full_product_name/product_id: product ID CSAFPID-0005 " +
+				"is defined by itself"},
+		},
+		{
+			name: "Contradicting product status",
+			modify: func(adv *csaf.Advisory) {
+				status := adv.Vulnerabilities[0].ProductStatus
+				*status.Fixed = append(*status.Fixed, utils.Ref(gocsaf.ProductID("CSAFPID-0001")))
+			},
+			test:    "6.1.6 Contradicting Product Status",
+			wantErr: []string{"/vulnerabilities/0/product_status/known_affected/0: product CSAFPID-0001 is fixed and affected"},
+		},
+		{
+			name: "Multiple scores",
+			modify: func(adv *csaf.Advisory) {
+				v := adv.Vulnerabilities[0]
+				v.Scores = append(v.Scores, v.Scores[0])
+			},
+			test: "6.1.7 Multiple Scores with same Version per Product",
+			wantErr: []string{
+				"/vulnerabilities/0/scores/1/products/0: product CSAFPID-0001 has multiple CVSS 3.1 scores",
+				"/vulnerabilities/0/scores/1/products/1: product CSAFPID-0003 has multiple CVSS 3.1 scores",
+			},
+		},
+		{
+			name: "Multiple metrics",
+			opts: []ConvertOption{WithCSAFVersion(csaf.Version21)},
+			modify: func(adv *csaf.Advisory) {
+				ext := adv.CSAF21.Vulnerabilities[0]
+				ext.Metrics = append(ext.Metrics, ext.Metrics[0])
+			},
+			test: "6.1.7 Multiple Scores with same Version per Product",
+			wantErr: []string{
+				"/vulnerabilities/0/metrics/1/products/0: product CSAFPID-0001 has multiple CVSS 3.1 scores",
+				"/vulnerabilities/0/metrics/1/products/1: product CSAFPID-0003 has multiple CVSS 3.1 scores",
+			},
+		},
+		{
+			name: "Invalid CVSS computation",
+			modify: func(adv *csaf.Advisory) {
+				adv.Vulnerabilities[0].Scores[0].CVSS3.BaseScore = utils.Ref(9.8)
+			},
+			test:    "6.1.9 Invalid CVSS computation",
+			wantErr: []string{"/vulnerabilities/0/scores/0/cvss_v3/baseScore: 9.8 does not match the computed score 7.5"},
+		},
+		{
+			name: "Invalid CVSS v4 computation",
+			opts: []ConvertOption{WithCSAFVersion(csaf.Version21)},
+			modify: func(adv *csaf.Advisory) {
+				ext := adv.CSAF21.Vulnerabilities[0]
+				ext.Metrics[0].Content.CVSS4 = &csaf.CVSS4{
+					Version:      "4.0",
+					VectorString: "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N",
+					BaseScore:    9.3,
+					BaseSeverity: "HIGH",
+				}
+			},
+			test: "6.1.9 Invalid CVSS computation",
+			wantErr: []string{"/vulnerabilities/0/metrics/0/content/cvss_v4/baseSeverity: HIGH does not match the " +
+				"computed severity CRITICAL"},
+		},
+		{
+			name: "Inconsistent CVSS",
+			modify: func(adv *csaf.Advisory) {
+				adv.Vulnerabilities[0].Scores[0].CVSS3.AttackVector = utils.Ref(gocsaf.CVSS3AttackVectorLocal)
+			},
+			test:    "6.1.10 Inconsistent CVSS",
+			wantErr: []string{"/vulnerabilities/0/scores/0/cvss_v3/attackVector: LOCAL does not match the vector string"},
+		},
+		{
+			name: "CWE",
+			modify: func(adv *csaf.Advisory) {
+				adv.Vulnerabilities[0].CWE.Name = utils.Ref("Amplification")
+			},
+			test:    "6.1.11 CWE",
+			wantErr: []string{"/vulnerabilities/0/cwe/name: 'Amplification' is not the name of CWE-405"},
+		},
+		{
+			name: "PURL",
+			modify: func(adv *csaf.Advisory) {
+				adv.ProductTree.Branches[0].Branches[0].Product.ProductIdentificationHelper.PURL =
+					utils.Ref(gocsaf.PURL("pkg:golang"))
+			},
+			test: "6.1.13 PURL",
+			wantErr: []string{"/product_tree/branches/0/branches/0/product/product_identification_helper/purl: " +
+				"invalid package URL: name is missing"},
+		},
+		{
+			name: "Unsorted revision history",
+			modify: func(adv *csaf.Advisory) {
+				history := adv.Document.Tracking.RevisionHistory
+				history[0].Date, history[1].Date = history[1].Date, history[0].Date
+			},
+			test:    "6.1.14 Sorted Revision History",
+			wantErr: []string{"/document/tracking/revision_history/1: revision 2 is dated before revision 1"},
+		},
+		{
+			name: "Translator",
+			modify: func(adv *csaf.Advisory) {
+				adv.Document.Publisher.Category = utils.Ref(gocsaf.CSAFCategoryTranslator)
+			},
+			test:    "6.1.15 Translator",
+			wantErr: []string{"/document/source_lang: the source language of a translation is required"},
+		},
+		{
+			name: "Latest document version",
+			modify: func(adv *csaf.Advisory) {
+				adv.Document.Tracking.Version = utils.Ref(gocsaf.RevisionNumber("1"))
+			},
+			test:    "6.1.16 Latest Document Version",
+			wantErr: []string{"/document/tracking/version: version 1 is not the latest revision 2"},
+		},
+		{
+			name: "Document status draft",
+			modify: func(adv *csaf.Advisory) {
+				adv.Document.Tracking.Version = utils.Ref(gocsaf.RevisionNumber("0"))
+			},
+			test:    "6.1.17 Document Status Draft",
+			wantErr: []string{"/document/tracking/status: documents with version 0 must be drafts"},
+		},
+		{
+			name: "Released revision history",
+			modify: func(adv *csaf.Advisory) {
+				adv.Document.Tracking.RevisionHistory[0].Number = utils.Ref(gocsaf.RevisionNumber("0"))
+			},
+			test:    "6.1.18 Released Revision History",
+			wantErr: []string{"/document/tracking/revision_history/0/number: released documents must not have revision 0"},
+		},
+		{
+			name: "Pre-release revision",
+			modify: func(adv *csaf.Advisory) {
+				tracking := adv.Document.Tracking
+				tracking.Version = utils.Ref(gocsaf.RevisionNumber("2.0.0"))
+				tracking.RevisionHistory[0].Number = utils.Ref(gocsaf.RevisionNumber("1.0.0-rc.1"))
+				tracking.RevisionHistory[1].Number = utils.Ref(gocsaf.RevisionNumber("2.0.0"))
+			},
+			test:    "6.1.19 Revision History Entries for Pre-release Versions",
+			wantErr: []string{"/document/tracking/revision_history/0/number: revision 1.0.0-rc.1 is a pre-release"},
+		},
+		{
+			name: "Non-draft document version",
+			modify: func(adv *csaf.Advisory) {
+				tracking := adv.Document.Tracking
+				tracking.Version = utils.Ref(gocsaf.RevisionNumber("2.0.0-rc.1+build.1"))
+			},
+			test:    "6.1.20 Non-draft Document Version",
+			wantErr: []string{"/document/tracking/version: version 2.0.0-rc.1 of a released document is a pre-release"},
+		},
+		{
+			name: "Missing revision",
+			modify: func(adv *csaf.Advisory) {
+				tracking := adv.Document.Tracking
+				tracking.Version = utils.Ref(gocsaf.RevisionNumber("3"))
+				tracking.RevisionHistory[1].Number = utils.Ref(gocsaf.RevisionNumber("3"))
+			},
+			test:    "6.1.21 Missing Item in Revision History",
+			wantErr: []string{"/document/tracking/revision_history/1/number: revisions between 1 and 3 are missing"},
+		},
+		{
+			name: "Multiple revision",
+			modify: func(adv *csaf.Advisory) {
+				adv.Document.Tracking.RevisionHistory[1].Number = utils.Ref(gocsaf.RevisionNumber("1"))
+			},
+			test: "6.1.22 Multiple Definition in Revision History",
+			wantErr: []string{"/document/tracking/revision_history/1/number: revision 1 is already defined at " +
+				"/document/tracking/revision_history/0"},
+		},
+		{
+			name: "Multiple CVE",
+			modify: func(adv *csaf.Advisory) {
+				v := *adv.Vulnerabilities[0]
+				adv.Vulnerabilities = append(adv.Vulnerabilities, &v)
+			},
+			test:    "6.1.23 Multiple Use of Same CVE",
+			wantErr: []string{"/vulnerabilities/1/cve: CVE-2025-30204 is already used at /vulnerabilities/0/cve"},
+		},
+		{
+			name: "Multiple involvement",
+			modify: func(adv *csaf.Advisory) {
+				inv := &gocsaf.Involvement{
+					Date:   utils.Ref("2025-03-21T20:51:37Z"),
+					Party:  utils.Ref(gocsaf.CSAFInvolvementPartyVendor),
+					Status: utils.Ref(gocsaf.CSAFInvolvementStatusCompleted),
+				}
+				adv.Vulnerabilities[0].Involvements = gocsaf.Involvements{inv, inv}
+			},
+			test: "6.1.24 Multiple Definition in Involvements",
+			wantErr: []string{"/vulnerabilities/0/involvements/1: party vendor is already involved at " +
+				"2025-03-21T20:51:37Z"},
+		},
+		{
+			name: "Multiple hash algorithm",
+			modify: func(adv *csaf.Advisory) {
+				hash := &gocsaf.FileHash{
+					Algorithm: utils.Ref("sha256"),
+					Value:     utils.Ref(gocsaf.FileHashValue("b1946ac92492d2347c6235b4d2611184")),
+				}
+				adv.ProductTree.Branches[0].Branches[1].Product.ProductIdentificationHelper.Hashes = &gocsaf.Hashes{
+					FileHashes: []*gocsaf.FileHash{hash, hash},
+					FileName:   utils.Ref("jwt.zip"),
+				}
+			},
+			test: "6.1.25 Multiple Use of Same Hash Algorithm",
+			wantErr: []string{"/product_tree/branches/0/branches/1/product/product_identification_helper/hashes/" +
+				"file_hashes/1: algorithm sha256 is already used"},
+		},
+		{
+			name: "Prohibited category",
+			modify: func(adv *csaf.Advisory) {
+				adv.Document.Category = utils.Ref(gocsaf.DocumentCategory("Security-Advisory"))
+			},
+			test: "6.1.26 Prohibited Document Category Name",
+			wantErr: []string{"/document/category: document category 'Security-Advisory' is too similar to " +
+				"profile category csaf_security_advisory"},
+		},
+		{
+			name: "Product tree",
+			opts: []ConvertOption{WithProfile(csaf.CategorySecurityAdvisory)},
+			modify: func(adv *csaf.Advisory) {
+				adv.ProductTree = nil
+			},
+			test:    "6.1.27.4 Product Tree",
+			wantErr: []string{"/product_tree: the product tree is required"},
+		},
+		{
+			name: "Vulnerability notes",
+			opts: []ConvertOption{WithProfile(csaf.CategoryVEX)},
+			modify: func(adv *csaf.Advisory) {
+				adv.Vulnerabilities[0].Notes = nil
+			},
+			test:    "6.1.27.5 Vulnerability Notes",
+			wantErr: []string{"/vulnerabilities/0/notes: notes are required"},
+		},
+		{
+			name: "Product status",
+			opts: []ConvertOption{WithProfile(csaf.CategorySecurityAdvisory)},
+			modify: func(adv *csaf.Advisory) {
+				adv.Vulnerabilities[0].ProductStatus = nil
+			},
+			test:    "6.1.27.6 Product Status",
+			wantErr: []string{"/vulnerabilities/0/product_status: the product status is required"},
+		},
+		{
+			name: "VEX product status",
+			opts: []ConvertOption{WithProfile(csaf.CategoryVEX)},
+			modify: func(adv *csaf.Advisory) {
+				adv.Vulnerabilities[0].ProductStatus = &gocsaf.ProductStatus{}
+			},
+			test: "6.1.27.7 VEX Product Status",
+			wantErr: []string{"/vulnerabilities/0/product_status: one of fixed, known_affected, known_not_affected " +
+				"or under_investigation is required"},
+		},
+		{
+			name: "Vulnerability ID",
+			opts: []ConvertOption{WithProfile(csaf.CategoryVEX)},
+			modify: func(adv *csaf.Advisory) {
+				adv.Vulnerabilities[0].CVE, adv.Vulnerabilities[0].IDs = nil, nil
+			},
+			test:    "6.1.27.8 Vulnerability ID",
+			wantErr: []string{"/vulnerabilities/0: cve or ids is required"},
+		},
+		{
+			name: "Impact statement",
+			opts: []ConvertOption{WithProfile(csaf.CategoryVEX)},
+			modify: func(adv *csaf.Advisory) {
+				status := adv.Vulnerabilities[0].ProductStatus
+				status.KnownNotAffected, status.Fixed = status.Fixed, nil
+			},
+			test: "6.1.27.9 Impact Statement",
+			wantErr: []string{
+				"/vulnerabilities/0/product_status/known_not_affected/0: product CSAFPID-0002 has no flag or impact " +
+					"statement",
+				"/vulnerabilities/0/product_status/known_not_affected/1: product CSAFPID-0004 has no flag or impact " +
+					"statement",
+			},
+		},
+		{
+			name: "Action statement",
+			opts: []ConvertOption{WithProfile(csaf.CategoryVEX)},
+			modify: func(adv *csaf.Advisory) {
+				adv.Vulnerabilities[0].Remediations = adv.Vulnerabilities[0].Remediations[:1]
+			},
+			test:    "6.1.27.10 Action Statement",
+			wantErr: []string{"/vulnerabilities/0/product_status/known_affected/1: product CSAFPID-0003 has no remediation"},
+		},
+		{
+			name: "Vulnerabilities",
+			opts: []ConvertOption{WithProfile(csaf.CategoryVEX)},
+			modify: func(adv *csaf.Advisory) {
+				adv.Vulnerabilities = nil
+			},
+			test:    "6.1.27.11 Vulnerabilities",
+			wantErr: []string{"/vulnerabilities: vulnerabilities are required"},
+		},
+		{
+			name: "Translation",
+			modify: func(adv *csaf.Advisory) {
+				adv.Document.SourceLang = adv.Document.Lang
+			},
+			test:    "6.1.28 Translation",
+			wantErr: []string{"/document/source_lang: the source language equals the language of the document"},
+		},
+		{
+			name: "Remediation without product reference",
+			modify: func(adv *csaf.Advisory) {
+				adv.Vulnerabilities[0].Remediations[1].ProductIds = nil
+			},
+			test:    "6.1.29 Remediation without Product Reference",
+			wantErr: []string{"/vulnerabilities/0/remediations/1: product_ids or group_ids is required"},
+		},
+		{
+			name: "Mixed versioning",
+			modify: func(adv *csaf.Advisory) {
+				adv.Document.Tracking.RevisionHistory[0].Number = utils.Ref(gocsaf.RevisionNumber("1.0.0"))
+			},
+			test: "6.1.30 Mixed Integer and Semantic Versioning",
+			wantErr: []string{"/document/tracking/revision_history/0/number: revision 1.0.0 mixes integer and " +
+				"semantic versioning"},
+		},
+		{
+			name: "Version range",
+			modify: func(adv *csaf.Advisory) {
+				adv.ProductTree.Branches[0].Branches[1].Name = utils.Ref("5.2.2 and later versions")
+			},
+			test: "6.1.31 Version Range in Product Version",
+			wantErr: []string{"/product_tree/branches/0/branches/1/name: product version 5.2.2 and later versions is " +
+				"a version range"},
+		},
+		{
+			name: "Flag without product reference",
+			modify: func(adv *csaf.Advisory) {
+				adv.Vulnerabilities[0].Flags = gocsaf.Flags{{Label: utils.Ref(gocsaf.CSAFFlagLabelComponentNotPresent)}}
+			},
+			test:    "6.1.32 Flag without Product Reference",
+			wantErr: []string{"/vulnerabilities/0/flags/0: product_ids or group_ids is required"},
+		},
+		{
+			name: "Multiple flags",
+			modify: func(adv *csaf.Advisory) {
+				products := &gocsaf.Products{utils.Ref(gocsaf.ProductID("CSAFPID-0002"))}
+				adv.Vulnerabilities[0].Flags = gocsaf.Flags{
+					{Label: utils.Ref(gocsaf.CSAFFlagLabelComponentNotPresent), ProductIds: products},
+					{Label: utils.Ref(gocsaf.CSAFFlagLabelVulnerableCodeNotPresent), ProductIds: products},
+				}
+			},
+			test:    "6.1.33 Multiple Flags with VEX Justification Codes per Product",
+			wantErr: []string{"/vulnerabilities/0/flags/1/product_ids/0: product CSAFPID-0002 has multiple flags"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adv := exampleAdvisory(t, tt.opts...)
+			tt.modify(adv)
+
+			var found bool
+			for _, res := range RunMandatoryTests(adv) {
+				if res.Name == tt.test {
+					found = true
+					assert.Equal(t, tt.wantErr, res.Violations)
+				}
+			}
+			assert.True(t, found, "no test %s", tt.test)
+		})
+	}
+}
+
+func TestCheckPURL(t *testing.T) {
+	tests := []struct {
+		purl string
+		want string
+	}{
+		{purl: "pkg:golang/github.com/golang-jwt/jwt/v5@5.2.2", want: ""},
+		{purl: "pkg:npm/%40angular/core@16.0.0?arch=x86#src", want: ""},
+		{purl: "pkg:maven/org.apache.logging.log4j/log4j-core", want: ""},
+		{purl: "npm/lodash", want: "scheme is not pkg"},
+		{purl: "pkg:1npm/lodash", want: "invalid type"},
+		{purl: "pkg:npm/", want: "name is missing"},
+		{purl: "pkg:npm/lo%zzdash", want: "invalid percent-encoding"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.purl, func(t *testing.T) {
+			assert.Equal(t, tt.want, checkPURL(tt.purl))
+		})
+	}
+}
+
+func TestParseRevisionNumber(t *testing.T) {
+	tests := []struct {
+		number string
+		want   string
+		ok     bool
+	}{
+		{number: "0", want: "0", ok: true},
+		{number: "12", want: "12", ok: true},
+		{number: "1.2.3", want: "1.2.3", ok: true},
+		{number: "1.0.0-rc.1+build.5", want: "1.0.0-rc.1", ok: true},
+		{number: "01", ok: false},
+		{number: "1.2", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			got, ok := parseRevisionNumber(utils.Ref(gocsaf.RevisionNumber(tt.number)))
+			assert.Equal(t, tt.ok, ok)
+			if ok {
+				assert.Equal(t, tt.want, got.String())
+			}
+		})
+	}
+
+	a, _ := parseRevisionNumber(utils.Ref(gocsaf.RevisionNumber("1.0.0-rc.1")))
+	b, _ := parseRevisionNumber(utils.Ref(gocsaf.RevisionNumber("1.0.0")))
+	assert.Negative(t, a.compare(b))
+	assert.Positive(t, b.compare(a))
+}
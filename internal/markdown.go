@@ -0,0 +1,476 @@
+package internal
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	// mdHeadingPattern matches ATX headings, e.g. "### Summary".
+	mdHeadingPattern = regexp.MustCompile(`^(#{1,6})(?:\s+(.*?))?(?:\s+#+)?\s*$`)
+	// mdRulePattern matches thematic breaks, e.g. "---" or "* * *".
+	mdRulePattern = regexp.MustCompile(`^(?:(?:\*\s*){3,}|(?:-\s*){3,}|(?:_\s*){3,})$`)
+	// mdSetextPattern matches the underline of setext headings.
+	mdSetextPattern = regexp.MustCompile(`^(=+|-+)$`)
+	// mdListItemPattern matches the first line of a list item, e.g. "- item" or "1. item".
+	mdListItemPattern = regexp.MustCompile(`^ {0,3}([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	// mdAutolinkPattern matches autolinks, e.g. "<https://example.com>".
+	mdAutolinkPattern = regexp.MustCompile(`^<((?:https?://|mailto:)[^\s<>]+)>`)
+	// mdBareURLPattern matches URLs in text, which GitHub links automatically.
+	mdBareURLPattern = regexp.MustCompile(`^https?://[^\s<>]+`)
+	// mdFencePattern matches the opening line of a fenced code block, e.g. "```go".
+	mdFencePattern = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})(.*)$")
+)
+
+// mdDestinationEscaper percent-encodes the characters that would end the destination of a Markdown link.
+var mdDestinationEscaper = strings.NewReplacer(" ", "%20", "<", "%3C", ">", "%3E", "(", "%28", ")", "%29")
+
+// mdEscapable are the characters that can be escaped with a backslash in Markdown.
+const mdEscapable = "\\`*_{}[]()#+-.!<>|~\""
+
+// markdownToHTML renders Markdown, e.g. the description of a GHSA, as HTML. Only a safe subset of Markdown is
+// supported: headings, paragraphs, lists, block quotes, code blocks, thematic breaks and the inline elements code,
+// emphasis, links and autolinks. All text is escaped, so raw HTML is shown as text, and only http, https and mailto
+// links are kept, so untrusted descriptions cannot inject markup or scripts. Images are rendered as links. Headings are
+// rendered at least at level minLevel to fit into the surrounding page.
+func markdownToHTML(src string, minLevel int) template.HTML {
+	var (
+		b     strings.Builder
+		para  []string
+		lines = strings.Split(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(src), "\n")
+	)
+	flush := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>" + renderInline(strings.Join(para, "\n"), true) + "</p>\n")
+			para = nil
+		}
+	}
+	heading := func(level int, text string) {
+		level = min(max(level, minLevel), 6)
+		fmt.Fprintf(&b, "<h%d>%s</h%d>\n", level, renderInline(text, true), level)
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			var (
+				fence = trimmed[:3]
+				code  []string
+			)
+			flush()
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			b.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+		case len(para) == 0 && (strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")):
+			var (
+				code []string
+			)
+			for ; i < len(lines); i++ {
+				l := lines[i]
+				if strings.TrimSpace(l) != "" && !strings.HasPrefix(l, "    ") && !strings.HasPrefix(l, "\t") {
+					break
+				}
+				l = strings.TrimPrefix(l, "\t")
+				code = append(code, strings.TrimPrefix(l, "    "))
+			}
+			i--
+			for len(code) > 0 && strings.TrimSpace(code[len(code)-1]) == "" {
+				code = code[:len(code)-1]
+			}
+			b.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+		case len(para) > 0 && mdSetextPattern.MatchString(trimmed):
+			level := 1
+			if trimmed[0] == '-' {
+				level = 2
+			}
+			heading(level, strings.Join(para, " "))
+			para = nil
+		case mdHeadingPattern.MatchString(trimmed):
+			flush()
+			m := mdHeadingPattern.FindStringSubmatch(trimmed)
+			heading(len(m[1]), m[2])
+		case mdRulePattern.MatchString(trimmed):
+			flush()
+			b.WriteString("<hr>\n")
+		case strings.HasPrefix(trimmed, ">"):
+			var (
+				quote []string
+			)
+			flush()
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				l := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quote = append(quote, strings.TrimPrefix(l, " "))
+			}
+			i--
+			b.WriteString("<blockquote>\n" + string(markdownToHTML(strings.Join(quote, "\n"), minLevel)) + "</blockquote>\n")
+		case len(para) == 0 && mdListItemPattern.MatchString(line):
+			i += renderList(&b, lines[i:]) - 1
+		default:
+			para = append(para, trimmed)
+		}
+	}
+	flush()
+	return template.HTML(b.String())
+}
+
+// renderList renders the list starting at the first line and returns the number of lines it spans. Nested lists are
+// not supported; their items become part of the text of the enclosing item.
+func renderList(b *strings.Builder, lines []string) (n int) {
+	var (
+		items   []string
+		ordered = isOrderedItem(mdListItemPattern.FindStringSubmatch(lines[0])[1])
+		tag     = "ul"
+	)
+	if ordered {
+		tag = "ol"
+	}
+
+	for ; n < len(lines); n++ {
+		line := lines[n]
+		if m := mdListItemPattern.FindStringSubmatch(line); m != nil {
+			if isOrderedItem(m[1]) != ordered {
+				break
+			}
+			items = append(items, strings.TrimSpace(m[2]))
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			// A blank line continues the list only if another item follows
+			if n+1 < len(lines) && mdListItemPattern.MatchString(lines[n+1]) {
+				continue
+			}
+			break
+		}
+		// Lines directly following an item continue its text
+		if strings.TrimSpace(lines[n-1]) == "" {
+			break
+		}
+		items[len(items)-1] += "\n" + strings.TrimSpace(line)
+	}
+
+	b.WriteString("<" + tag + ">\n")
+	for _, item := range items {
+		b.WriteString("<li>" + renderInline(item, true) + "</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return n
+}
+
+// isOrderedItem reports whether the marker of a list item belongs to an ordered list, e.g. "1.".
+func isOrderedItem(marker string) bool {
+	return marker != "-" && marker != "*" && marker != "+"
+}
+
+// renderInline renders the inline elements of Markdown text as escaped HTML. Links are only rendered if links is set,
+// which prevents nested links in the text of a link.
+func renderInline(s string, links bool) string {
+	var (
+		b strings.Builder
+	)
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(mdEscapable, s[i+1]) >= 0:
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+		case c == '`':
+			run := backtickRun(s[i:])
+			if end := strings.Index(s[i+run:], strings.Repeat("`", run)); end >= 0 {
+				code := s[i+run : i+run+end]
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+					code = code[1 : len(code)-1]
+				}
+				b.WriteString("<code>" + html.EscapeString(strings.ReplaceAll(code, "\n", " ")) + "</code>")
+				i += 2*run + end
+				continue
+			}
+			b.WriteString(s[i : i+run])
+			i += run
+			continue
+		case c == '[' || (c == '!' && i+1 < len(s) && s[i+1] == '['):
+			start := i
+			if c == '!' {
+				start++
+			}
+			if text, dest, n, ok := parseLink(s[start:]); ok {
+				text = renderInline(text, false)
+				if links && isSafeURL(dest) {
+					b.WriteString(`<a href="` + html.EscapeString(dest) + `" rel="nofollow noopener">` + text + "</a>")
+				} else {
+					b.WriteString(text)
+				}
+				i = start + n
+				continue
+			}
+		case c == '<':
+			if m := mdAutolinkPattern.FindStringSubmatch(s[i:]); m != nil {
+				b.WriteString(linkHTML(m[1], links))
+				i += len(m[0])
+				continue
+			}
+		case c == 'h' && (i == 0 || !isWordByte(s[i-1])):
+			if m := mdBareURLPattern.FindString(s[i:]); m != "" {
+				m = strings.TrimRight(m, ".,;:!?)'\"*_")
+				b.WriteString(linkHTML(m, links))
+				i += len(m)
+				continue
+			}
+		case c == '*' || c == '_' || c == '~':
+			if out, n, ok := renderEmphasis(s, i, links); ok {
+				b.WriteString(out)
+				i += n
+				continue
+			}
+			// Write the whole delimiter run, so it is not matched again by its second character
+			n := 1
+			for i+n < len(s) && s[i+n] == c {
+				n++
+			}
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		b.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+	return b.String()
+}
+
+// renderEmphasis renders the emphasis (*text* or _text_), strong emphasis (**text** or __text__) or strikethrough
+// (~~text~~) starting at s[i] and returns the HTML and the number of bytes consumed.
+func renderEmphasis(s string, i int, links bool) (out string, n int, ok bool) {
+	var (
+		c     = s[i]
+		delim = string(c)
+		tag   = "em"
+	)
+	if i+1 < len(s) && s[i+1] == c {
+		delim, tag = delim+delim, "strong"
+		if c == '~' {
+			tag = "del"
+		}
+	} else if c == '~' {
+		return "", 0, false
+	}
+	// Underscores inside words, e.g. snake_case, do not start emphasis
+	if c == '_' && i > 0 && isWordByte(s[i-1]) {
+		return "", 0, false
+	}
+
+	rest := s[i+len(delim):]
+	if rest == "" || rest[0] == ' ' || rest[0] == '\n' {
+		return "", 0, false
+	}
+	end := strings.Index(rest, delim)
+	if end <= 0 || rest[end-1] == ' ' {
+		return "", 0, false
+	}
+	if c == '_' && end+len(delim) < len(rest) && isWordByte(rest[end+len(delim)]) {
+		return "", 0, false
+	}
+	inner := renderInline(rest[:end], links)
+	return "<" + tag + ">" + inner + "</" + tag + ">", 2*len(delim) + end, true
+}
+
+// parseLink parses a link ([text](destination "title")) at the start of s and returns its text, destination and length.
+func parseLink(s string) (text string, dest string, n int, ok bool) {
+	depth := 0
+	closing := -1
+	for i := 0; i < len(s) && closing < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closing = i
+			}
+		}
+	}
+	if closing < 0 || closing+1 >= len(s) || s[closing+1] != '(' {
+		return "", "", 0, false
+	}
+
+	depth = 0
+	for i := closing + 1; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				target := strings.TrimSpace(s[closing+2 : i])
+				// Strip the optional title
+				if j := strings.IndexAny(target, " \t\n"); j >= 0 {
+					target = target[:j]
+				}
+				target = strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+				return s[1:closing], target, i + 1, true
+			}
+		case '\n':
+			return "", "", 0, false
+		}
+	}
+	return "", "", 0, false
+}
+
+// linkHTML returns the HTML of an autolink, which is plain text if links are disabled or the URL is not safe.
+func linkHTML(u string, links bool) string {
+	text := html.EscapeString(strings.TrimPrefix(u, "mailto:"))
+	if !links || !isSafeURL(u) {
+		return text
+	}
+	return `<a href="` + html.EscapeString(u) + `" rel="nofollow noopener">` + text + "</a>"
+}
+
+// isSafeURL reports whether u is an absolute http, https or mailto URL.
+func isSafeURL(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+		return parsed.Host != ""
+	case "mailto":
+		return parsed.Opaque != ""
+	}
+	return false
+}
+
+// backtickRun returns the number of leading backticks of s.
+func backtickRun(s string) (n int) {
+	for n < len(s) && s[n] == '`' {
+		n++
+	}
+	return n
+}
+
+// isWordByte reports whether c is an ASCII letter or digit.
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// sanitizeMarkdown makes Markdown, e.g. the description of a GHSA, safe to include in a Markdown document with the same
+// guarantees as markdownToHTML: raw HTML is escaped, so it is shown as text by Markdown renderers, and links and images
+// are only kept for http, https and mailto URLs. Links to other URLs are replaced by their text. Fenced code blocks and
+// code spans are kept as they are, everything else keeps its Markdown formatting except for reference links, whose
+// definitions cannot be checked line by line and are shown as text.
+func sanitizeMarkdown(src string) string {
+	var (
+		lines = strings.Split(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(src), "\n")
+		// fence and indent are the delimiter and the indentation of the fenced code block the line is in, if any
+		fence, indent string
+	)
+	for i, line := range lines {
+		if fence != "" {
+			rest := strings.TrimLeft(line, " ")
+			if n := len(rest) - len(strings.TrimLeft(rest, fence[:1])); len(line)-len(rest) <= 3 && n >= len(fence) &&
+				strings.TrimSpace(rest[n:]) == "" {
+				fence = ""
+				continue
+			}
+			// A line indented less than the fence ends the block if it is nested, e.g. in a list item
+			if strings.TrimSpace(line) == "" || strings.HasPrefix(line, indent) {
+				continue
+			}
+			fence = ""
+		}
+		if m := mdFencePattern.FindStringSubmatch(line); m != nil && !(m[2][0] == '`' && strings.Contains(m[3], "`")) {
+			indent, fence = m[1], m[2]
+			continue
+		}
+		lines[i] = sanitizeInline(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sanitizeInline escapes the raw HTML of Markdown text and removes links and images to URLs other than http, https
+// and mailto URLs (see sanitizeMarkdown). Closing brackets that do not end such a link are escaped, so no other links
+// can be formed, e.g. across lines or with reference definitions.
+func sanitizeInline(s string) string {
+	var (
+		b strings.Builder
+	)
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(mdEscapable, s[i+1]) >= 0:
+			b.WriteString(s[i : i+2])
+			i += 2
+			continue
+		case c == '`':
+			run := backtickRun(s[i:])
+			if end := closingBacktickRun(s[i+run:], run); end >= 0 {
+				b.WriteString(s[i : i+2*run+end])
+				i += 2*run + end
+				continue
+			}
+			b.WriteString(s[i : i+run])
+			i += run
+			continue
+		case c == '[' || (c == '!' && i+1 < len(s) && s[i+1] == '['):
+			start := i
+			if c == '!' {
+				start++
+			}
+			if text, dest, n, ok := parseLink(s[start:]); ok {
+				if isSafeURL(dest) {
+					b.WriteString(s[i:start] + "[" + sanitizeInline(text) + "](" + mdDestinationEscaper.Replace(dest) + ")")
+				} else {
+					b.WriteString(sanitizeInline(text))
+				}
+				i = start + n
+				continue
+			}
+		case c == ']':
+			b.WriteString(`\]`)
+			i++
+			continue
+		case c == '<':
+			if m := mdAutolinkPattern.FindString(s[i:]); m != "" {
+				b.WriteString(m)
+				i += len(m)
+				continue
+			}
+			// Only a letter, /, ! or ? after < starts HTML, so comparisons like "<= 1.2" are kept
+			if i+1 < len(s) && (isWordByte(s[i+1]) || strings.IndexByte("/!?", s[i+1]) >= 0) {
+				b.WriteString("&lt;")
+				i++
+				continue
+			}
+		}
+		b.WriteByte(c)
+		i++
+	}
+	return b.String()
+}
+
+// closingBacktickRun returns the index of the first run of exactly n backticks in s, which closes a code span opened
+// by n backticks, or -1 if there is none.
+func closingBacktickRun(s string, n int) int {
+	for i := 0; i < len(s); {
+		if s[i] != '`' {
+			i++
+			continue
+		}
+		run := backtickRun(s[i:])
+		if run == n {
+			return i
+		}
+		i += run
+	}
+	return -1
+}
@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownToHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     template.HTML
+	}{
+		{
+			name:     "Headings",
+			markdown: "# Title\r\n\r\n### Summary\r\nText",
+			want:     "<h4>Title</h4>\n<h4>Summary</h4>\n<p>Text</p>\n",
+		},
+		{
+			name:     "Inline",
+			markdown: "Use `a < b` with **strong _and em_** in snake_case and ~~old~~ text.",
+			want:     "<p>Use <code>a &lt; b</code> with <strong>strong <em>and em</em></strong> in snake_case and <del>old</del> text.</p>\n",
+		},
+		{
+			name:     "Links",
+			markdown: "[docs](https://example.com/a?b=1&c=2) ![img](https://example.com/i.png) <https://example.com> see https://example.com/x.",
+			want: `<p><a href="https://example.com/a?b=1&amp;c=2" rel="nofollow noopener">docs</a> ` +
+				`<a href="https://example.com/i.png" rel="nofollow noopener">img</a> ` +
+				`<a href="https://example.com" rel="nofollow noopener">https://example.com</a> ` +
+				`see <a href="https://example.com/x" rel="nofollow noopener">https://example.com/x</a>.</p>` + "\n",
+		},
+		{
+			name:     "Unsafe links",
+			markdown: `[click](javascript:alert(1)) [x](data:text/html,<script>)`,
+			want:     "<p>click x</p>\n",
+		},
+		{
+			name:     "Raw HTML",
+			markdown: `<img src=x onerror="alert(1)"><script>alert(1)</script>`,
+			want:     "<p>&lt;img src=x onerror=&#34;alert(1)&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;</p>\n",
+		},
+		{
+			name:     "Code blocks",
+			markdown: "```go\nif a < b {}\n```\n\n    indented <code>\n\nafter",
+			want:     "<pre><code>if a &lt; b {}</code></pre>\n<pre><code>indented &lt;code&gt;</code></pre>\n<p>after</p>\n",
+		},
+		{
+			name:     "Lists and quotes",
+			markdown: "- one\n- two\n  continued\n\n1. first\n2. second\n\n> quoted *text*\n\n---",
+			want: "<ul>\n<li>one</li>\n<li>two\ncontinued</li>\n</ul>\n<ol>\n<li>first</li>\n<li>second</li>\n</ol>\n" +
+				"<blockquote>\n<p>quoted <em>text</em></p>\n</blockquote>\n<hr>\n",
+		},
+		{
+			name:     "Escapes",
+			markdown: `\*not emphasis\* and 2 * 3 * 4 and [unclosed`,
+			want:     "<p>*not emphasis* and 2 * 3 * 4 and [unclosed</p>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, markdownToHTML(tt.markdown, 4))
+		})
+	}
+}
+
+// htmlTagPattern matches the tags markdownToHTML writes. A link is captured with its destination.
+var htmlTagPattern = regexp.MustCompile(`^<(?:/?(?:p|h[1-6]|ul|ol|li|blockquote|pre|code|em|strong|del|a)|hr|` +
+	`a href="([^"<>]*)" rel="nofollow noopener")>`)
+
+func FuzzMarkdownToHTML(f *testing.F) {
+	f.Add("# Title\n\nText with `code`, **strong** and [a link](https://example.com).")
+	f.Add(`<img src=x onerror="alert(1)"><script>alert(1)</script>`)
+	f.Add("[click](javascript:alert(1)) ![x](data:text/html,<script>) <javascript:alert(1)>")
+	f.Add("- [a](<https://example.com/\"onmouseover=\"x>)\n> `<b>` ```\n```\n<b>\n```")
+	f.Fuzz(func(t *testing.T, markdown string) {
+		out := string(markdownToHTML(markdown, 4))
+		for i := strings.IndexByte(out, '<'); i >= 0; i = strings.IndexByte(out, '<') {
+			out = out[i:]
+			m := htmlTagPattern.FindStringSubmatch(out)
+			if m == nil {
+				t.Fatalf("markdownToHTML(%q) contains unexpected markup: %q", markdown, out)
+			}
+			if m[1] != "" && !isSafeURL(html.UnescapeString(m[1])) {
+				t.Fatalf("markdownToHTML(%q) links to unsafe URL %q", markdown, m[1])
+			}
+			out = out[len(m[0]):]
+		}
+	})
+}
+
+func TestSanitizeMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			name:     "Formatting",
+			markdown: "## Summary\n\n- **a** < b, c <= 1.2\n> `<b>` and <https://example.com>",
+			want:     "## Summary\n\n- **a** < b, c <= 1.2\n> `<b>` and <https://example.com>",
+		},
+		{
+			name:     "Raw HTML",
+			markdown: `<img src=x onerror="alert(1)"><!-- x --></div>\<b> \\<b>`,
+			want:     `&lt;img src=x onerror="alert(1)">&lt;!-- x -->&lt;/div>\<b> \\&lt;b>`,
+		},
+		{
+			name:     "Links",
+			markdown: "[docs](https://example.com \"title\") ![img](<https://example.com/a b.png>) [x](javascript:alert(1)) [y][ref]\n\n[ref]: javascript:alert(1)",
+			want:     "[docs](https://example.com) ![img](https://example.com/a) x [y\\][ref\\]\n\n[ref\\]: javascript:alert(1)",
+		},
+		{
+			name:     "Links across lines",
+			markdown: "[click\nhere](javascript:alert(1))",
+			want:     "[click\nhere\\](javascript:alert(1))",
+		},
+		{
+			name:     "Code",
+			markdown: "```html\n<b>\n``\n````\n<i>\n``<b>`` and `<i>`` and `<u>`",
+			want:     "```html\n<b>\n``\n````\n&lt;i>\n``<b>`` and `<i>`` and `&lt;u>`",
+		},
+		{
+			name:     "Nested fence",
+			markdown: "- item\n\n  ```\n  <b>\n<i>\n  ```",
+			want:     "- item\n\n  ```\n  <b>\n&lt;i>\n  ```",
+		},
+		{
+			name:     "No fence",
+			markdown: "```a`b\n<b>",
+			want:     "```a`b\n&lt;b>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sanitizeMarkdown(tt.markdown))
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/csaf-poc/ghsa/models/ghsa/global"
+	"github.com/csaf-poc/ghsa/models/osv"
+)
+
+// reviewedAdvisoriesDir is the folder of the github/advisory-database repository that contains the GitHub reviewed
+// advisories in OSV format. The advisories are located at YYYY/MM/GHSA_ID/GHSA_ID.json below this folder.
+const reviewedAdvisoriesDir = "advisories/github-reviewed"
+
+// ImportAdvisoryDatabase reads all GitHub reviewed advisories of a local checkout of the github/advisory-database
+// repository located at root and maps them into the global GHSA model.
+// The advisories are returned in lexical order of their file paths. Files that cannot be imported are skipped; their
+// errors are returned joined together with the imported advisories.
+func ImportAdvisoryDatabase(root string) (advisories []*global.Advisory, err error) {
+	err = WalkAdvisoryDatabase(root, func(_ string, adv *global.Advisory) error {
+		advisories = append(advisories, adv)
+		return nil
+	})
+	return advisories, err
+}
+
+// WalkAdvisoryDatabase walks all GitHub reviewed advisories of a local checkout of the github/advisory-database
+// repository located at root. Each OSV record is mapped into the global GHSA model and passed to fn together with
+// its file path. Records that cannot be imported are skipped and their errors are returned joined after the walk.
+// Walking stops at the first error returned by fn.
+func WalkAdvisoryDatabase(root string, fn func(path string, adv *global.Advisory) error) (err error) {
+	var (
+		errs []error
+	)
+	paths, err := AdvisoryDatabaseFiles(root)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		adv, err := LoadOSVAdvisory(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err = fn(path, adv); err != nil {
+			return err
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AdvisoryDatabaseFiles returns the paths of the OSV files of all GitHub reviewed advisories of a local checkout of the
+// github/advisory-database repository located at root in lexical order, e.g. to convert them with ConvertBulk and
+// LoadOSVAdvisory.
+func AdvisoryDatabaseFiles(root string) (paths []string, err error) {
+	dir := filepath.Join(root, filepath.FromSlash(reviewedAdvisoriesDir))
+	if _, err = os.Stat(dir); err != nil {
+		err = fmt.Errorf("no advisory database checkout at '%s': %w", root, err)
+		return
+	}
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if isOSVAdvisoryFile(path, d) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// isOSVAdvisoryFile reports whether path is an advisory file of the form GHSA_ID/GHSA_ID.json.
+func isOSVAdvisoryFile(path string, d fs.DirEntry) bool {
+	if d.IsDir() || filepath.Ext(path) != ".json" {
+		return false
+	}
+	name := strings.TrimSuffix(d.Name(), ".json")
+	return strings.HasPrefix(name, "GHSA-") && filepath.Base(filepath.Dir(path)) == name
+}
+
+// LoadOSVAdvisory reads the OSV record stored at path and maps it into the global GHSA model.
+func LoadOSVAdvisory(path string) (adv *global.Advisory, err error) {
+	var (
+		vuln osv.Vulnerability
+	)
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		err = fmt.Errorf("could not read OSV file: %w", err)
+		return nil, err
+	}
+	err = json.Unmarshal(b, &vuln)
+	if err != nil {
+		err = fmt.Errorf("could not unmarshal OSV file '%s': %w", path, err)
+		return nil, err
+	}
+
+	adv, err = OSVToGlobal(&vuln)
+	if err != nil {
+		err = fmt.Errorf("could not map OSV file '%s': %w", path, err)
+		return nil, err
+	}
+	return adv, nil
+}
+
+// OSVToGlobal maps an OSV record published by GitHub into the global GHSA model.
+// OSV does not carry scores, CWE names, EPSS data or full user profiles, so the corresponding fields stay empty.
+func OSVToGlobal(vuln *osv.Vulnerability) (adv *global.Advisory, err error) {
+	if vuln == nil || !strings.HasPrefix(vuln.ID, "GHSA-") {
+		err = fmt.Errorf("not a GitHub Security Advisory")
+		return nil, err
+	}
+
+	adv = &global.Advisory{
+		ID:          vuln.ID,
+		URL:         "https://api.github.com/advisories/" + vuln.ID,
+		HTMLURL:     "https://github.com/advisories/" + vuln.ID,
+		Summary:     vuln.Summary,
+		Description: vuln.Details,
+		Severity:    strings.ToLower(vuln.DatabaseSpecific.Severity),
+		Type:        "unreviewed",
+		Identifiers: []global.Identifier{{Type: "GHSA", Value: vuln.ID}},
+		Credits:     getOSVCredits(vuln.Credits),
+	}
+	if vuln.DatabaseSpecific.GithubReviewed {
+		adv.Type = "reviewed"
+	}
+
+	// Aliases contain the CVE ID (if any)
+	for _, alias := range vuln.Aliases {
+		if strings.HasPrefix(alias, "CVE-") {
+			adv.CveID = alias
+			adv.Identifiers = append(adv.Identifiers, global.Identifier{Type: "CVE", Value: alias})
+		}
+	}
+
+	// The package reference points to the source code, all others are plain references
+	for _, ref := range vuln.References {
+		if ref.Type == osv.ReferenceTypePackage && adv.SourceCodeLocation == "" {
+			adv.SourceCodeLocation = ref.URL
+			continue
+		}
+		adv.References = append(adv.References, ref.URL)
+	}
+
+	for _, s := range vuln.Severity {
+		switch s.Type {
+		case osv.SeverityTypeCVSSv3:
+			// OSV only carries the vector, so the base score is computed from it
+			score, _ := cvss3BaseScore(s.Score)
+			adv.CVSSSeverities.CVSSv3 = global.CVSS{VectorString: s.Score, Score: score}
+			adv.CVSS = global.CVSS{VectorString: s.Score, Score: score}
+		case osv.SeverityTypeCVSSv4:
+			score, _ := cvss4BaseScore(s.Score)
+			adv.CVSSSeverities.CVSSv4 = global.CVSS{VectorString: s.Score, Score: score}
+		}
+	}
+
+	for _, id := range vuln.DatabaseSpecific.CWEIDs {
+		adv.CWEs = append(adv.CWEs, global.CWE{CWEID: id})
+	}
+
+	for _, a := range vuln.Affected {
+		adv.Vulnerabilities = append(adv.Vulnerabilities, getOSVVulnerabilities(&a)...)
+	}
+
+	// Timestamps
+	if adv.PublishedAt, err = parseOSVTime(vuln.Published); err != nil {
+		return nil, err
+	}
+	if adv.UpdatedAt, err = parseOSVTime(vuln.Modified); err != nil {
+		return nil, err
+	}
+	if adv.GithubReviewedAt, err = parseOSVTime(vuln.DatabaseSpecific.GithubReviewedAt); err != nil {
+		return nil, err
+	}
+	if adv.NVDPublishedAt, err = parseOSVTime(vuln.DatabaseSpecific.NVDPublishedAt); err != nil {
+		return nil, err
+	}
+	if vuln.Withdrawn != "" {
+		var withdrawn time.Time
+		if withdrawn, err = parseOSVTime(vuln.Withdrawn); err != nil {
+			return nil, err
+		}
+		adv.WithdrawnAt = &withdrawn
+	}
+	return adv, nil
+}
+
+// getOSVVulnerabilities maps an affected package into GHSA vulnerabilities. GHSA holds exactly one version range per
+// vulnerability, so every introduced/fixed interval of the OSV ranges results in its own vulnerability.
+// Explicitly enumerated versions are mapped to "= VERSION" ranges.
+func getOSVVulnerabilities(a *osv.Affected) (vulns []global.GHSAVulnerability) {
+	var (
+		pkg = global.Package{
+			Ecosystem: osvEcosystemToGHSA(a.Package.Ecosystem),
+			Name:      a.Package.Name,
+		}
+	)
+
+	for _, r := range a.Ranges {
+		// Git ranges contain commit hashes which are not represented in GHSA
+		if r.Type == osv.RangeTypeGit {
+			continue
+		}
+
+		var introduced string
+		for _, e := range r.Events {
+			switch {
+			case e.Introduced != "":
+				introduced = e.Introduced
+			case e.Fixed != "":
+				vulns = append(vulns, global.GHSAVulnerability{
+					Package:                pkg,
+					VulnerableVersionRange: joinRange(introduced, "< "+e.Fixed),
+					FirstPatchedVersion:    e.Fixed,
+				})
+				introduced = ""
+			case e.LastAffected != "":
+				vulns = append(vulns, global.GHSAVulnerability{
+					Package:                pkg,
+					VulnerableVersionRange: joinRange(introduced, "<= "+e.LastAffected),
+				})
+				introduced = ""
+			}
+		}
+
+		// Interval without an upper bound. GitHub records its original range in the database specific part.
+		if introduced != "" {
+			upper := a.DatabaseSpecific.LastKnownAffectedVersionRange
+			vulns = append(vulns, global.GHSAVulnerability{
+				Package:                pkg,
+				VulnerableVersionRange: joinRange(introduced, upper),
+			})
+		}
+	}
+
+	if len(a.Ranges) == 0 {
+		for _, v := range a.Versions {
+			vulns = append(vulns, global.GHSAVulnerability{
+				Package:                pkg,
+				VulnerableVersionRange: "= " + v,
+			})
+		}
+	}
+	return
+}
+
+// joinRange builds a GHSA version range out of an introduced version and an upper bound (e.g. "< 1.2.3").
+// The introduced version "0" marks all versions and is omitted.
+func joinRange(introduced string, upper string) string {
+	var (
+		parts []string
+	)
+	if introduced != "" && introduced != "0" {
+		parts = append(parts, ">= "+introduced)
+	}
+	if upper != "" {
+		parts = append(parts, upper)
+	}
+	if len(parts) == 0 {
+		// Every version is affected
+		return ">= 0"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// osvEcosystemToGHSA maps an OSV ecosystem name to the ecosystem name used by the GitHub API.
+// Unknown ecosystems are lower-cased.
+func osvEcosystemToGHSA(ecosystem string) string {
+	switch ecosystem {
+	case "Go":
+		return "go"
+	case "npm":
+		return "npm"
+	case "PyPI":
+		return "pip"
+	case "Maven":
+		return "maven"
+	case "NuGet":
+		return "nuget"
+	case "RubyGems":
+		return "rubygems"
+	case "crates.io":
+		return "rust"
+	case "Packagist":
+		return "composer"
+	case "Hex":
+		return "erlang"
+	case "Pub":
+		return "pub"
+	case "SwiftURL":
+		return "swift"
+	case "GitHub Actions":
+		return "actions"
+	default:
+		return strings.ToLower(ecosystem)
+	}
+}
+
+// getOSVCredits maps OSV credits to GHSA credits. OSV only provides a name and contact URLs, so the name is used as
+// login and a GitHub profile contact (if any) as HTML URL.
+func getOSVCredits(credits []osv.Credit) (ghsaCredits []global.Credit) {
+	for _, c := range credits {
+		credit := global.Credit{
+			Type: strings.ToLower(c.Type),
+			User: global.User{Login: c.Name},
+		}
+		for _, contact := range c.Contact {
+			if strings.HasPrefix(contact, "https://github.com/") {
+				credit.User.HTMLURL = contact
+				break
+			}
+		}
+		ghsaCredits = append(ghsaCredits, credit)
+	}
+	return
+}
+
+// parseOSVTime parses an RFC 3339 timestamp of an OSV record. An empty string results in the zero time.
+func parseOSVTime(s string) (t time.Time, err error) {
+	if s == "" {
+		return
+	}
+	t, err = time.Parse(time.RFC3339, s)
+	if err != nil {
+		err = fmt.Errorf("invalid OSV timestamp '%s': %w", s, err)
+	}
+	return
+}
@@ -0,0 +1,190 @@
+package internal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/csaf-poc/ghsa/models/ghsa/global"
+	"github.com/csaf-poc/ghsa/models/osv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportAdvisoryDatabase(t *testing.T) {
+	var (
+		want global.Advisory
+	)
+	b, err := os.ReadFile("../examples/global_GHSA/GHSA-cpj6-fhp6-mr6j.json")
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(b, &want))
+
+	got, err := ImportAdvisoryDatabase("../examples/osv_GHSA")
+	assert.NoError(t, err)
+	if !assert.Len(t, got, 1) {
+		return
+	}
+
+	// Compare the fields OSV provides with the advisory downloaded from the GitHub API
+	adv := got[0]
+	assert.Equal(t, want.ID, adv.ID)
+	assert.Equal(t, want.CveID, adv.CveID)
+	assert.Equal(t, want.URL, adv.URL)
+	assert.Equal(t, want.HTMLURL, adv.HTMLURL)
+	assert.Equal(t, want.Summary, adv.Summary)
+	assert.Equal(t, want.Description, adv.Description)
+	assert.Equal(t, want.Severity, adv.Severity)
+	assert.Equal(t, want.Type, adv.Type)
+	assert.Equal(t, want.Identifiers, adv.Identifiers)
+	assert.Equal(t, want.SourceCodeLocation, adv.SourceCodeLocation)
+	assert.ElementsMatch(t, want.References, adv.References)
+	assert.Equal(t, want.CVSSSeverities.CVSSv3, adv.CVSSSeverities.CVSSv3)
+	assert.Equal(t, want.CVSS, adv.CVSS)
+	assert.Equal(t, want.CWEs[0].CWEID, adv.CWEs[0].CWEID)
+	assert.True(t, want.PublishedAt.Equal(adv.PublishedAt))
+	assert.True(t, want.UpdatedAt.Equal(adv.UpdatedAt))
+	assert.True(t, want.NVDPublishedAt.Equal(adv.NVDPublishedAt))
+	assert.Nil(t, adv.WithdrawnAt)
+	if assert.Len(t, adv.Vulnerabilities, 1) {
+		assert.Equal(t, want.Vulnerabilities[0].Package, adv.Vulnerabilities[0].Package)
+		assert.Equal(t, want.Vulnerabilities[0].FirstPatchedVersion, adv.Vulnerabilities[0].FirstPatchedVersion)
+		assert.Equal(t, ">= 7.0.0, < 7.5.2", adv.Vulnerabilities[0].VulnerableVersionRange)
+	}
+}
+
+func TestImportAdvisoryDatabase_NoCheckout(t *testing.T) {
+	got, err := ImportAdvisoryDatabase(t.TempDir())
+	assert.ErrorContains(t, err, "no advisory database checkout")
+	assert.Nil(t, got)
+}
+
+func TestImportAdvisoryDatabase_BadRecord(t *testing.T) {
+	root := t.TempDir()
+	for id, content := range map[string]string{
+		"GHSA-aaaa-aaaa-aaaa": "{",
+		"GHSA-bbbb-bbbb-bbbb": `{"id": "GHSA-bbbb-bbbb-bbbb", "summary": "Valid"}`,
+		"GHSA-cccc-cccc-cccc": `{"id": "CVE-2025-0001"}`,
+	} {
+		dir := filepath.Join(root, "advisories", "github-reviewed", "2025", "01", id)
+		assert.NoError(t, os.MkdirAll(dir, 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, id+".json"), []byte(content), 0644))
+	}
+
+	// Bad records do not stop the import
+	got, err := ImportAdvisoryDatabase(root)
+	assert.ErrorContains(t, err, "GHSA-aaaa-aaaa-aaaa.json")
+	assert.ErrorContains(t, err, "GHSA-cccc-cccc-cccc.json")
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, "GHSA-bbbb-bbbb-bbbb", got[0].ID)
+	}
+
+	paths, err := AdvisoryDatabaseFiles(root)
+	assert.NoError(t, err)
+	assert.Len(t, paths, 3)
+}
+
+func TestGetOSVVulnerabilities(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected osv.Affected
+		want     []global.GHSAVulnerability
+	}{
+		{
+			name: "Introduced zero and fixed",
+			affected: osv.Affected{
+				Package: osv.Package{Ecosystem: "Go", Name: "github.com/golang-jwt/jwt/v5"},
+				Ranges: []osv.Range{{Type: osv.RangeTypeSemver, Events: []osv.Event{
+					{Introduced: "0"}, {Fixed: "5.2.2"},
+				}}},
+			},
+			want: []global.GHSAVulnerability{{
+				Package:                global.Package{Ecosystem: "go", Name: "github.com/golang-jwt/jwt/v5"},
+				VulnerableVersionRange: "< 5.2.2",
+				FirstPatchedVersion:    "5.2.2",
+			}},
+		},
+		{
+			name: "Multiple intervals and last affected",
+			affected: osv.Affected{
+				Package: osv.Package{Ecosystem: "PyPI", Name: "django"},
+				Ranges: []osv.Range{{Type: osv.RangeTypeEcosystem, Events: []osv.Event{
+					{Introduced: "4.0"}, {Fixed: "4.2.1"}, {Introduced: "5.0"}, {LastAffected: "5.0.3"},
+				}}},
+			},
+			want: []global.GHSAVulnerability{
+				{
+					Package:                global.Package{Ecosystem: "pip", Name: "django"},
+					VulnerableVersionRange: ">= 4.0, < 4.2.1",
+					FirstPatchedVersion:    "4.2.1",
+				},
+				{
+					Package:                global.Package{Ecosystem: "pip", Name: "django"},
+					VulnerableVersionRange: ">= 5.0, <= 5.0.3",
+				},
+			},
+		},
+		{
+			name: "Open interval uses last known affected range",
+			affected: osv.Affected{
+				Package: osv.Package{Ecosystem: "crates.io", Name: "foo"},
+				Ranges: []osv.Range{{Type: osv.RangeTypeEcosystem, Events: []osv.Event{
+					{Introduced: "0"},
+				}}},
+				DatabaseSpecific: osv.AffectedDatabaseSpecific{LastKnownAffectedVersionRange: "<= 0.3.0"},
+			},
+			want: []global.GHSAVulnerability{{
+				Package:                global.Package{Ecosystem: "rust", Name: "foo"},
+				VulnerableVersionRange: "<= 0.3.0",
+			}},
+		},
+		{
+			name: "Enumerated versions",
+			affected: osv.Affected{
+				Package:  osv.Package{Ecosystem: "npm", Name: "bar"},
+				Versions: []string{"1.0.0"},
+			},
+			want: []global.GHSAVulnerability{{
+				Package:                global.Package{Ecosystem: "npm", Name: "bar"},
+				VulnerableVersionRange: "= 1.0.0",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getOSVVulnerabilities(&tt.affected))
+		})
+	}
+}
+
+func TestOSVToGlobal(t *testing.T) {
+	tests := []struct {
+		name    string
+		vuln    *osv.Vulnerability
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "Err: No GHSA",
+			vuln:    &osv.Vulnerability{ID: "GO-2025-3553"},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "Err: Invalid timestamp",
+			vuln:    &osv.Vulnerability{ID: "GHSA-mh63-6h87-95cp", Modified: "yesterday"},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "Withdrawn advisory",
+			vuln:    &osv.Vulnerability{ID: "GHSA-mh63-6h87-95cp", Modified: "2025-03-21T21:35:28Z", Withdrawn: "2025-03-22T10:00:00Z"},
+			wantErr: assert.NoError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := OSVToGlobal(tt.vuln)
+			tt.wantErr(t, err)
+			if err == nil {
+				assert.NotNil(t, got.WithdrawnAt)
+			}
+		})
+	}
+}
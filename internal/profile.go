@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"fmt"
+
+	"github.com/csaf-poc/ghsa/internal/utils"
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+)
+
+// conversionNote explains how the advisory was mapped onto the profile named by the first verb. The second verb is the
+// part of the explanation specific to the profile (see profileNotes).
+const conversionNote = "This document was converted from a GitHub Security Advisory to comply with the CSAF profile " +
+	"%s. The products are the packages of the advisory in the versions named by it: vulnerable version ranges are " +
+	"known affected, patched versions are fixed. GitHub does not name versions that are not affected, so versions " +
+	"outside the listed ranges are not covered. The products are identified by package URLs of their ecosystems, " +
+	"they are not products of the publisher. %s"
+
+// profileNotes are the names of the profiles and what is specific to their conversion.
+var profileNotes = map[string]struct{ name, text string }{
+	csaf.CategorySecurityAdvisory: {"Security Advisory", "Withdrawn advisories list all products as known not " +
+		"affected, flagged as vulnerable_code_not_present."},
+	csaf.CategoryVEX: {"VEX", "Only the products of withdrawn advisories are known not affected, flagged as " +
+		"vulnerable_code_not_present. A remediation is given for every known affected product."},
+}
+
+// getProfileNotes returns the note explaining the conversion into the profile or nil for the base profile.
+func (c *converter) getProfileNotes() gocsaf.Notes {
+	note, ok := profileNotes[c.profile]
+	if !ok {
+		return nil
+	}
+	return gocsaf.Notes{{
+		NoteCategory: utils.Ref(gocsaf.CSAFNoteCategoryGeneral),
+		Text:         utils.Ref(fmt.Sprintf(conversionNote, note.name, note.text)),
+		Title:        utils.Ref("Conversion"),
+	}}
+}
+
+// checkProducts checks that the advisory has products, which the profiles require (test 6.1.27.4).
+func (c *converter) checkProducts(products []*product) error {
+	if c.profile != "" && len(products) == 0 {
+		return fmt.Errorf("profile %s requires products, but the advisory has none", c.profile)
+	}
+	return nil
+}
+
+// addProfile adds the note the security advisory and VEX profiles require (CSAF 2.0 sections 4.4 and 4.5) to the
+// vulnerability. Withdrawn advisories list all products as known not affected instead, since the profiles require a
+// product status. They are justified by a vulnerable_code_not_present flag and an impact statement (test 6.1.27.9).
+func addProfile(v *gocsaf.Vulnerability, adv *repository.Advisory, products []*product) {
+	var (
+		notAffected gocsaf.Products
+	)
+	v.Notes = append(getVulnerabilityNotes(adv), v.Notes...)
+
+	if withdrawnAt := getWithdrawnAt(adv); withdrawnAt != "" {
+		for _, p := range products {
+			notAffected = append(notAffected, utils.Ref(p.id))
+		}
+		if len(notAffected) == 0 {
+			return
+		}
+		v.ProductStatus = &gocsaf.ProductStatus{KnownNotAffected: &notAffected}
+		v.Flags = append(v.Flags, &gocsaf.Flag{
+			Date:       utils.Ref(withdrawnAt),
+			Label:      utils.Ref(gocsaf.CSAFFlagLabelVulnerableCodeNotPresent),
+			ProductIds: &notAffected,
+		})
+		v.Threats = append(v.Threats, &gocsaf.Threat{
+			Category: utils.Ref(gocsaf.CSAFThreatCategoryImpact),
+			Date:     utils.Ref(withdrawnAt),
+			Details: utils.Ref("The advisory was withdrawn, the vulnerability is no longer considered valid. " +
+				"No product is affected."),
+			ProductIds: &notAffected,
+		})
+	}
+}
+
+// getVulnerabilityNotes returns the summary of the advisory as note of the vulnerability, which the profiles require. Returns nil if the advisory has no summary.
+func getVulnerabilityNotes(adv *repository.Advisory) gocsaf.Notes {
+	if adv.Summary == "" {
+		return nil
+	}
+	return gocsaf.Notes{{
+		NoteCategory: utils.Ref(gocsaf.CSAFNoteCategorySummary),
+		Text:         utils.Ref(adv.Summary),
+		Title:        utils.Ref("Summary"),
+	}}
+}
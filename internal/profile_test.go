@@ -0,0 +1,180 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/global"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToCSAF_VEX(t *testing.T) {
+	var (
+		adv global.Advisory
+	)
+	readJSON(t, "../examples/global_GHSA/GHSA-cpj6-fhp6-mr6j.json", &adv)
+
+	for _, version := range []string{csaf.Version20, csaf.Version21} {
+		t.Run(version, func(t *testing.T) {
+			got, err := GlobalToCSAF(&adv, WithProfile(csaf.CategoryVEX), WithCSAFVersion(version))
+			if !assert.NoError(t, err) {
+				return
+			}
+			assertValidCSAF(t, got)
+			assert.Equal(t, csaf.CategoryVEX, string(*got.Document.Category))
+			assert.NotNil(t, got.ProductTree)
+
+			if !assert.Len(t, got.Vulnerabilities, 1) {
+				return
+			}
+			v := got.Vulnerabilities[0]
+			assert.NotEmpty(t, v.Notes)
+			assert.NotEmpty(t, v.IDs)
+
+			// GitHub does not name versions that are not affected, so none are derived
+			status := v.ProductStatus
+			if !assert.NotNil(t, status) {
+				return
+			}
+			assert.Nil(t, status.KnownNotAffected)
+			assert.Empty(t, v.Flags)
+			// Every known affected product has a remediation
+			for _, id := range *status.KnownAffected {
+				assert.True(t, hasRemediation(v, *id), "no remediation for %s", *id)
+			}
+		})
+	}
+}
+
+func TestToCSAF_VEXWithdrawn(t *testing.T) {
+	var (
+		adv repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &adv)
+	adv.WithdrawnAt = "2025-04-01T00:00:00Z"
+
+	got, err := ToCSAF(&adv, WithProfile(csaf.CategoryVEX))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assertValidCSAF(t, got)
+	if assert.Len(t, got.Vulnerabilities, 1) {
+		v := got.Vulnerabilities[0]
+		if assert.NotNil(t, v.ProductStatus) {
+			assert.Nil(t, v.ProductStatus.KnownAffected)
+			assert.NotNil(t, v.ProductStatus.KnownNotAffected)
+		}
+		if assert.Len(t, v.Threats, 1) {
+			assert.Equal(t, gocsaf.CSAFThreatCategoryImpact, *v.Threats[0].Category)
+		}
+		if assert.Len(t, v.Flags, 1) {
+			assert.Equal(t, gocsaf.CSAFFlagLabelVulnerableCodeNotPresent, *v.Flags[0].Label)
+			assert.Equal(t, v.ProductStatus.KnownNotAffected, v.Flags[0].ProductIds)
+		}
+	}
+}
+
+func TestToCSAF_VEXProfileTests(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   string
+		withdrawn bool
+	}{
+		{name: "2.0", version: csaf.Version20},
+		{name: "2.1", version: csaf.Version21},
+		{name: "withdrawn", version: csaf.Version20, withdrawn: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				adv repository.Advisory
+			)
+			readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &adv)
+			if tt.withdrawn {
+				adv.WithdrawnAt = "2025-04-01T00:00:00Z"
+			}
+
+			got, err := ToCSAF(&adv, WithProfile(csaf.CategoryVEX), WithCSAFVersion(tt.version))
+			if !assert.NoError(t, err) {
+				return
+			}
+			var run int
+			for _, result := range RunMandatoryTests(got) {
+				if strings.HasPrefix(result.Name, "6.1.27.") {
+					run++
+					assert.Empty(t, result.Violations, result.Name)
+				}
+			}
+			assert.Equal(t, 11, run)
+		})
+	}
+}
+
+func TestToCSAF_SecurityAdvisory(t *testing.T) {
+	var (
+		adv repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &adv)
+
+	for _, version := range []string{csaf.Version20, csaf.Version21} {
+		t.Run(version, func(t *testing.T) {
+			got, err := ToCSAF(&adv, WithProfile(csaf.CategorySecurityAdvisory), WithCSAFVersion(version))
+			if !assert.NoError(t, err) {
+				return
+			}
+			assertValidCSAF(t, got)
+			assert.Equal(t, csaf.CategorySecurityAdvisory, string(*got.Document.Category))
+			assert.True(t, slices.ContainsFunc(got.Document.Notes, func(n *gocsaf.Note) bool {
+				return *n.Title == "Conversion"
+			}), "conversion note missing")
+
+			// Tests 6.1.27.4 to 6.1.27.6 and 6.1.27.11
+			assert.NotNil(t, got.ProductTree)
+			if assert.Len(t, got.Vulnerabilities, 1) {
+				v := got.Vulnerabilities[0]
+				assert.NotEmpty(t, v.Notes)
+				if assert.NotNil(t, v.ProductStatus) {
+					assert.Nil(t, v.ProductStatus.KnownNotAffected)
+				}
+			}
+		})
+	}
+}
+
+func TestToCSAF_ProfileWithoutProducts(t *testing.T) {
+	var (
+		adv repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &adv)
+	adv.Vulnerabilities = nil
+
+	_, err := ToCSAF(&adv, WithProfile(csaf.CategorySecurityAdvisory))
+	assert.ErrorContains(t, err, "profile csaf_security_advisory requires products")
+
+	_, err = ToCSAF(&adv)
+	assert.NoError(t, err)
+}
+
+func TestToCSAF_UnsupportedProfile(t *testing.T) {
+	_, err := ToCSAF(&repository.Advisory{}, WithProfile("csaf_informational_advisory"))
+	assert.ErrorContains(t, err, "unsupported profile 'csaf_informational_advisory'")
+}
+
+// hasRemediation reports whether the vulnerability has a remediation for the product.
+func hasRemediation(v *gocsaf.Vulnerability, id gocsaf.ProductID) bool {
+	for _, r := range v.Remediations {
+		if r.ProductIds == nil {
+			continue
+		}
+		for _, pid := range *r.ProductIds {
+			if *pid == id {
+				return true
+			}
+		}
+	}
+	return false
+}
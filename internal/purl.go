@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"cmp"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// purlType maps a GHSA ecosystem to the corresponding package URL type
+// (see https://github.com/package-url/purl-spec/blob/main/PURL-TYPES.rst).
+// Unknown ecosystems are mapped to the "generic" type.
+func purlType(ecosystem string) string {
+	switch strings.ToLower(ecosystem) {
+	case "go":
+		return "golang"
+	case "npm":
+		return "npm"
+	case "pip":
+		return "pypi"
+	case "maven":
+		return "maven"
+	case "nuget":
+		return "nuget"
+	case "rubygems":
+		return "gem"
+	case "rust":
+		return "cargo"
+	case "composer":
+		return "composer"
+	case "erlang":
+		return "hex"
+	case "pub":
+		return "pub"
+	case "swift":
+		return "swift"
+	case "actions":
+		return "githubactions"
+	default:
+		return "generic"
+	}
+}
+
+// packageURL builds the package URL of a package in a GHSA ecosystem. The version is omitted if empty.
+// Maven coordinates (GROUP:ARTIFACT) are split into namespace and name, npm scopes are percent-encoded and PyPI names
+// are lower-cased as required by the purl specification.
+func packageURL(ecosystem string, name string, version string) string {
+	var (
+		typ      = purlType(ecosystem)
+		segments []string
+	)
+
+	switch typ {
+	case "maven":
+		name = strings.Replace(name, ":", "/", 1)
+	case "pypi":
+		name = strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+	}
+
+	for _, s := range strings.Split(name, "/") {
+		segments = append(segments, purlEscape(s))
+	}
+
+	purl := "pkg:" + typ + "/" + strings.Join(segments, "/")
+	if version != "" {
+		purl += "@" + purlEscape(version)
+	}
+	return purl
+}
+
+// purlEscape percent-encodes a segment or the version of a package URL. Unlike url.PathEscape, it also encodes "@",
+// which separates the version, e.g. in the npm scope "@remix-run" ("%40remix-run").
+func purlEscape(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), "@", "%40")
+}
+
+// versRange converts a GHSA version range (e.g. ">= 7.0, <= 7.5.1") into a vers range
+// (see https://github.com/package-url/purl-spec/blob/main/VERSION-RANGE-SPEC.rst), e.g. "vers:npm/>=7.0|<=7.5.1".
+func versRange(ecosystem string, ghsaRange string) string {
+	var (
+		constraints []string
+	)
+	for _, c := range strings.Split(ghsaRange, ",") {
+		c = strings.ReplaceAll(strings.TrimSpace(c), " ", "")
+		// vers uses a bare version for equality
+		c = strings.TrimPrefix(c, "=")
+		if c != "" {
+			constraints = append(constraints, c)
+		}
+	}
+	if len(constraints) == 0 {
+		constraints = []string{"*"}
+	}
+	return "vers:" + purlType(ecosystem) + "/" + strings.Join(constraints, "|")
+}
+
+// versContains reports whether the version is in a vers range as written by versRange, whose constraints all apply,
+// e.g. "vers:npm/>=7.0|<=7.5.1". Ranges of versions only, e.g. "vers:npm/1.0.0|1.0.1", contain each of them. The second
+// return value is false if the versions cannot be compared.
+func versContains(vers string, version string) (in bool, ok bool) {
+	var (
+		equal []string
+	)
+	_, spec, _ := strings.Cut(strings.TrimPrefix(vers, "vers:"), "/")
+	in = true
+	for _, c := range strings.Split(spec, "|") {
+		c = strings.TrimSpace(c)
+		if c == "*" {
+			continue
+		}
+		op := "="
+		for _, prefix := range []string{">=", "<=", "!=", ">", "<", "="} {
+			if strings.HasPrefix(c, prefix) {
+				op, c = prefix, c[len(prefix):]
+				break
+			}
+		}
+		order, ok := compareVersions(version, c)
+		if !ok {
+			return false, false
+		}
+		switch op {
+		case "=":
+			equal = append(equal, c)
+			if order == 0 {
+				return true, true
+			}
+		case "!=":
+			in = in && order != 0
+		case ">=":
+			in = in && order >= 0
+		case ">":
+			in = in && order > 0
+		case "<=":
+			in = in && order <= 0
+		case "<":
+			in = in && order < 0
+		}
+	}
+	return in && len(equal) == 0, true
+}
+
+// compareVersions compares two versions of the form [v]MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD] with any number of
+// numeric components, e.g. "v5.2.1" or "7.0". Missing components count as 0 and versions with pre-release precede the
+// release. Returns -1, 0 or 1 and false if a version does not have this form.
+func compareVersions(a string, b string) (c int, ok bool) {
+	parse := func(v string) (numbers []int, pre string, ok bool) {
+		v, _, _ = strings.Cut(strings.TrimPrefix(strings.TrimSpace(v), "v"), "+")
+		v, pre, _ = strings.Cut(v, "-")
+		for _, s := range strings.Split(v, ".") {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				return nil, "", false
+			}
+			numbers = append(numbers, n)
+		}
+		return numbers, pre, true
+	}
+	na, preA, okA := parse(a)
+	nb, preB, okB := parse(b)
+	if !okA || !okB {
+		return 0, false
+	}
+
+	for i := range max(len(na), len(nb)) {
+		var x, y int
+		if i < len(na) {
+			x = na[i]
+		}
+		if i < len(nb) {
+			y = nb[i]
+		}
+		if x != y {
+			return cmp.Compare(x, y), true
+		}
+	}
+	switch {
+	case preA == preB:
+		return 0, true
+	case preA == "":
+		return 1, true
+	case preB == "":
+		return -1, true
+	}
+	return strings.Compare(preA, preB), true
+}
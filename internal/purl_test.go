@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageURL(t *testing.T) {
+	tests := []struct {
+		ecosystem string
+		name      string
+		version   string
+		want      string
+	}{
+		{ecosystem: "npm", name: "@remix-run/router", version: "1.0.0", want: "pkg:npm/%40remix-run/router@1.0.0"},
+		{ecosystem: "npm", name: "react-router", want: "pkg:npm/react-router"},
+		{ecosystem: "maven", name: "org.example:lib", version: "1.0", want: "pkg:maven/org.example/lib@1.0"},
+		{ecosystem: "pip", name: "Django_Rest", version: "3.0", want: "pkg:pypi/django-rest@3.0"},
+		{ecosystem: "go", name: "github.com/golang-jwt/jwt/v5", version: "5.2.2", want: "pkg:golang/github.com/golang-jwt/jwt/v5@5.2.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := packageURL(tt.ecosystem, tt.name, tt.version)
+			assert.Equal(t, tt.want, got)
+
+			// The package URL can be parsed back
+			_, name, version, err := parsePURL(got)
+			if assert.NoError(t, err) && tt.ecosystem != "pip" {
+				assert.Equal(t, tt.name, name)
+				assert.Equal(t, tt.version, version)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package internal
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/csaf-poc/ghsa/internal/utils"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+)
+
+// Summaries of references to the fix of a vulnerability.
+const (
+	fixCommit      = "Fix commit"
+	fixPullRequest = "Fix pull request"
+)
+
+// getReferences returns the document references: the GHSA itself, NVD and CVE.org entries of its CVE and, for global
+// advisories, the references listed by GitHub. Invalid and duplicate URLs are skipped.
+func (c *converter) getReferences(adv *repository.Advisory) (refs gocsaf.References) {
+	var (
+		seen = make(map[string]bool)
+	)
+	add := func(category gocsaf.ReferenceCategory, rawURL string, summary string) {
+		if !isURL(rawURL) || seen[rawURL] {
+			return
+		}
+		seen[rawURL] = true
+		refs = append(refs, &gocsaf.Reference{
+			ReferenceCategory: utils.Ref(string(category)),
+			Summary:           utils.Ref(summary),
+			URL:               utils.Ref(rawURL),
+		})
+	}
+
+	add(gocsaf.CSAFReferenceCategorySelf, adv.HTMLURL, "GitHub Security Advisory "+adv.GhsaID)
+	if cvePattern.MatchString(adv.CveID) {
+		add(gocsaf.CSAFReferenceCategoryExternal, "https://nvd.nist.gov/vuln/detail/"+adv.CveID,
+			"NVD entry of "+adv.CveID)
+		add(gocsaf.CSAFReferenceCategoryExternal, "https://www.cve.org/CVERecord?id="+adv.CveID,
+			"CVE record of "+adv.CveID)
+	}
+	if c.global != nil {
+		for _, ref := range c.global.References {
+			add(gocsaf.CSAFReferenceCategoryExternal, ref, referenceSummary(ref))
+		}
+	}
+	return
+}
+
+// referenceSummary describes the reference based on its host and path, e.g. "Commit" for a GitHub commit URL.
+func referenceSummary(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "Reference"
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	path := strings.ToLower(u.Path)
+
+	switch {
+	case host == "nvd.nist.gov":
+		return "NVD entry"
+	case host == "cve.org" || host == "cve.mitre.org":
+		return "CVE record"
+	case host == "pkg.go.dev" && strings.HasPrefix(path, "/vuln/"),
+		host == "rustsec.org", host == "osv.dev",
+		strings.Contains(path, "/security/advisories/"), strings.HasPrefix(path, "/advisories/"):
+		return "Advisory"
+	case strings.Contains(path, "/commit/") || strings.Contains(path, "/commits/"):
+		return "Commit"
+	case strings.Contains(path, "/pull/") || strings.Contains(path, "/merge_requests/"):
+		return "Pull request"
+	case strings.Contains(path, "/issues/"):
+		return "Issue"
+	case strings.Contains(path, "/releases/") || strings.Contains(path, "changelog"):
+		return "Release notes"
+	case host == "github.com" && strings.Contains(path, "/blob/"):
+		return "Source code"
+	case host == "":
+		return "Reference"
+	default:
+		return "Reference at " + host
+	}
+}
+
+// getVulnerabilityReferences returns the references listed by GitHub for global advisories, classified by their URL.
+// Commits and pull requests on GitHub are the fix of the vulnerability.
+func (c *converter) getVulnerabilityReferences() (refs gocsaf.References) {
+	var (
+		seen = make(map[string]bool)
+	)
+	if c.global == nil {
+		return nil
+	}
+
+	for _, ref := range c.global.References {
+		if !isURL(ref) || seen[ref] {
+			continue
+		}
+		seen[ref] = true
+
+		summary, ok := fixReferenceSummary(ref)
+		if !ok {
+			summary = referenceSummary(ref)
+		}
+		refs = append(refs, &gocsaf.Reference{
+			ReferenceCategory: utils.Ref(string(gocsaf.CSAFReferenceCategoryExternal)),
+			Summary:           utils.Ref(summary),
+			URL:               utils.Ref(ref),
+		})
+	}
+	return
+}
+
+// getFixURL returns the URL of the fix of a package: the first fix commit or, if there is none, the first fix pull
+// request of the references listed by GitHub. An advisory may reference fixes of several packages in different
+// repositories, so if the repository of the package is known (see packageRepository), only fixes in that repository
+// are considered; otherwise a fix is only attributed to the package if it is the only package of the advisory (single).
+// Returns an empty string if no fix is referenced.
+func (c *converter) getFixURL(pkg repository.Package, single bool) string {
+	var (
+		pullRequest string
+	)
+	if c.global == nil {
+		return ""
+	}
+	owner, repo := packageRepository(pkg)
+	if owner == "" && !single {
+		return ""
+	}
+
+	for _, ref := range c.global.References {
+		summary, ok := fixReferenceSummary(ref)
+		if !ok || !isURL(ref) {
+			continue
+		}
+		if refOwner, refRepo := repositoryFromURL(ref, false); owner != "" &&
+			(!strings.EqualFold(refOwner, owner) || !strings.EqualFold(refRepo, repo)) {
+			continue
+		}
+		switch {
+		case summary == fixCommit:
+			return ref
+		case pullRequest == "":
+			pullRequest = ref
+		}
+	}
+	return pullRequest
+}
+
+// packageRepository returns owner and repository on GitHub of packages whose name is derived from their repository:
+// Go modules hosted on GitHub (github.com/OWNER/REPO[/...]) and GitHub Actions (OWNER/REPO[/...]). Both are empty for
+// other packages.
+func packageRepository(pkg repository.Package) (owner string, repo string) {
+	var (
+		name string
+	)
+	switch strings.ToLower(pkg.Ecosystem) {
+	case "go":
+		name, _ = strings.CutPrefix(pkg.Name, "github.com/")
+		if name == pkg.Name {
+			return "", ""
+		}
+	case "actions":
+		name = pkg.Name
+	default:
+		return "", ""
+	}
+	parts := strings.Split(name, "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
+
+// fixReferenceSummary reports whether the URL is a commit (GITHUB/OWNER/REPO/commit/SHA) or a pull request
+// (GITHUB/OWNER/REPO/pull/NUMBER[/...]) on GitHub and returns the matching summary.
+func fixReferenceSummary(rawURL string) (summary string, ok bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || !strings.EqualFold(u.Host, "github.com") {
+		return "", false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 {
+		return "", false
+	}
+	switch parts[2] {
+	case "commit":
+		return fixCommit, true
+	case "pull":
+		return fixPullRequest, true
+	default:
+		return "", false
+	}
+}
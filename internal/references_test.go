@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/csaf-poc/ghsa/models/ghsa/global"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferenceSummary(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://github.com/golang-jwt/jwt/commit/0951d184286dece21f73c85673fd308786ffe9c3", want: "Commit"},
+		{url: "https://github.com/golang-jwt/jwt/pull/448", want: "Pull request"},
+		{url: "https://gitlab.com/group/project/-/merge_requests/12", want: "Pull request"},
+		{url: "https://github.com/golang-jwt/jwt/issues/447", want: "Issue"},
+		{url: "https://github.com/golang-jwt/jwt/releases/tag/v5.2.2", want: "Release notes"},
+		{url: "https://github.com/expressjs/express/blob/master/History.md", want: "Source code"},
+		{url: "https://github.com/golang-jwt/jwt/security/advisories/GHSA-mh63-6h87-95cp", want: "Advisory"},
+		{url: "https://pkg.go.dev/vuln/GO-2025-3553", want: "Advisory"},
+		{url: "https://nvd.nist.gov/vuln/detail/CVE-2025-30204", want: "NVD entry"},
+		{url: "https://www.example.com/blog/cve-2025-30204", want: "Reference at example.com"},
+		{url: "not a URL", want: "Reference"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.want, referenceSummary(tt.url))
+		})
+	}
+}
+
+func TestConverter_getFixURL(t *testing.T) {
+	var (
+		npm = repository.Package{Ecosystem: "npm", Name: "react-router"}
+		jwt = repository.Package{Ecosystem: "go", Name: "github.com/golang-jwt/jwt/v5"}
+	)
+	tests := []struct {
+		name       string
+		references []string
+		pkg        repository.Package
+		single     bool
+		want       string
+	}{
+		{
+			name: "Commit preferred over pull request",
+			references: []string{
+				"https://github.com/golang-jwt/jwt/pull/448",
+				"https://github.com/golang-jwt/jwt/commit/0951d184286dece21f73c85673fd308786ffe9c3",
+			},
+			pkg:    npm,
+			single: true,
+			want:   "https://github.com/golang-jwt/jwt/commit/0951d184286dece21f73c85673fd308786ffe9c3",
+		},
+		{
+			name: "Pull request",
+			references: []string{
+				"https://nvd.nist.gov/vuln/detail/CVE-2025-30204",
+				"https://github.com/golang-jwt/jwt/pull/448/commits/0951d184286dece21f73c85673fd308786ffe9c3",
+			},
+			pkg:    npm,
+			single: true,
+			want:   "https://github.com/golang-jwt/jwt/pull/448/commits/0951d184286dece21f73c85673fd308786ffe9c3",
+		},
+		{
+			name:       "No fix",
+			references: []string{"https://gitlab.com/group/project/-/commit/0951d184"},
+			pkg:        npm,
+			single:     true,
+			want:       "",
+		},
+		{
+			name:       "Several packages of unknown repository",
+			references: []string{"https://github.com/remix-run/react-router/commit/c84302972a152d851cf5dd859ff332b354b70111"},
+			pkg:        npm,
+			want:       "",
+		},
+		{
+			name: "Fix in the repository of the package",
+			references: []string{
+				"https://github.com/golang/go/commit/0951d184286dece21f73c85673fd308786ffe9c3",
+				"https://github.com/golang-jwt/jwt/pull/448",
+			},
+			pkg:  jwt,
+			want: "https://github.com/golang-jwt/jwt/pull/448",
+		},
+		{
+			name:       "No fix in the repository of the package",
+			references: []string{"https://github.com/golang/go/commit/0951d184286dece21f73c85673fd308786ffe9c3"},
+			pkg:        jwt,
+			single:     true,
+			want:       "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newConverter(withGlobal(&global.Advisory{References: tt.references}))
+			assert.Equal(t, tt.want, c.getFixURL(tt.pkg, tt.single))
+		})
+	}
+}
+
+func TestPackageRepository(t *testing.T) {
+	tests := []struct {
+		pkg       repository.Package
+		wantOwner string
+		wantRepo  string
+	}{
+		{pkg: repository.Package{Ecosystem: "go", Name: "github.com/golang-jwt/jwt/v5"}, wantOwner: "golang-jwt", wantRepo: "jwt"},
+		{pkg: repository.Package{Ecosystem: "actions", Name: "tj-actions/changed-files"}, wantOwner: "tj-actions", wantRepo: "changed-files"},
+		{pkg: repository.Package{Ecosystem: "go", Name: "golang.org/x/net"}},
+		{pkg: repository.Package{Ecosystem: "npm", Name: "@remix-run/router"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pkg.Name, func(t *testing.T) {
+			owner, repo := packageRepository(tt.pkg)
+			assert.Equal(t, tt.wantOwner, owner)
+			assert.Equal(t, tt.wantRepo, repo)
+		})
+	}
+}
+
+func TestGlobalToCSAF_FixURL(t *testing.T) {
+	var (
+		g global.Advisory
+	)
+	readJSON(t, "../examples/global_GHSA/GHSA-cpj6-fhp6-mr6j.json", &g)
+
+	// The fix commit of the only package is linked
+	adv, err := GlobalToCSAF(&g)
+	if !assert.NoError(t, err) {
+		return
+	}
+	remediations := adv.Vulnerabilities[0].Remediations
+	if assert.Len(t, remediations, 1) && assert.NotNil(t, remediations[0].URL) {
+		assert.Equal(t, "https://github.com/remix-run/react-router/commit/c84302972a152d851cf5dd859ff332b354b70111",
+			*remediations[0].URL)
+	}
+
+	// It is unknown which of several packages the fix commit belongs to
+	second := g.Vulnerabilities[0]
+	second.Package.Name = "@react-router/node"
+	g.Vulnerabilities = append(g.Vulnerabilities, second)
+	adv, err = GlobalToCSAF(&g)
+	if !assert.NoError(t, err) {
+		return
+	}
+	remediations = adv.Vulnerabilities[0].Remediations
+	if assert.Len(t, remediations, 2) {
+		assert.Nil(t, remediations[0].URL)
+		assert.Nil(t, remediations[1].URL)
+	}
+}
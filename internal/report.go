@@ -0,0 +1,243 @@
+package internal
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Test cases of a bulk report. The validate stage is split into the checks of the CSAF model (Advisory.Validate), the
+// schema validation and a test case per mandatory test (see BulkResult.MandatoryTests), which are named by the tests.
+const (
+	reportCaseFetch   = "fetch"
+	reportCaseConvert = "convert"
+	reportCaseModel   = "model"
+	reportCaseSchema  = "schema"
+	reportCaseStore   = "store"
+	reportCaseExport  = "export"
+	// reportCaseMandatory stands for the test cases of the mandatory tests
+	reportCaseMandatory = "mandatory tests"
+	// reportCaseRemote is the test case of a remote validation that could not be run, see BulkOptions.Validator
+	reportCaseRemote = "remote validation"
+)
+
+// reportCases are the test cases of every advisory in the order the stages are run.
+var reportCases = []string{
+	reportCaseFetch, reportCaseConvert, reportCaseModel, reportCaseSchema, reportCaseMandatory, reportCaseStore,
+	reportCaseExport,
+}
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite holds the test cases of a single advisory.
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is a stage of an advisory. Output such as warnings and paths is written to SystemOut.
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// WriteJUnitReport writes the results of a bulk run as JUnit XML report, so CI systems show failed advisories inline.
+// Every advisory is a test suite, named by its GHSA ID (or its reference if fetching failed), with a test case per
+// stage: fetch, convert, model, schema, one per mandatory test of section 6.1 of the CSAF standard, store and export.
+// The failed stage carries the error, with each schema violation on a line of its own, and the stages after it are
+// skipped; every failed mandatory test is a failure of its own, listing its violations line by line. The schema stage
+// is also skipped if the schema of the advisory is not available (see BulkResult.Unvalidated). The warnings of a
+// lenient conversion are the output of the convert test case and the paths of the written files the output of the
+// store and export test cases.
+func WriteJUnitReport(w io.Writer, results []BulkResult) (err error) {
+	var (
+		report = junitTestSuites{Name: generatorEngine}
+	)
+	for _, res := range results {
+		suite := getJUnitTestSuite(res)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, suite)
+	}
+
+	if _, err = io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err = enc.Encode(report); err != nil {
+		err = fmt.Errorf("could not encode report: %v", err)
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// getJUnitTestSuite returns the test suite of the result of an advisory.
+func getJUnitTestSuite(res BulkResult) (suite junitTestSuite) {
+	var (
+		failed = reportCase(res)
+		skip   bool
+	)
+	suite.Name = res.Ref
+	if res.GHSA != nil && res.GHSA.GhsaID != "" {
+		suite.Name = res.GHSA.GhsaID
+	}
+
+	for _, name := range reportCases {
+		if name == reportCaseMandatory {
+			for _, tc := range getMandatoryTestCases(res, suite.Name, skip, failed) {
+				if tc.Failure != nil {
+					suite.Failures++
+				}
+				if tc.Skipped != nil {
+					suite.Skipped++
+				}
+				suite.Cases = append(suite.Cases, tc)
+				suite.Tests++
+			}
+			skip = skip || failed == name
+			continue
+		}
+
+		tc := junitTestCase{Name: name, ClassName: suite.Name}
+		switch {
+		case skip:
+			tc.Skipped = &junitSkipped{Message: fmt.Sprintf("%s failed", failed)}
+			suite.Skipped++
+		case name == reportCaseSchema && res.Unvalidated != nil:
+			tc.Skipped = &junitSkipped{Message: res.Unvalidated.Error()}
+			suite.Skipped++
+		case name == failed:
+			tc.Failure = getJUnitFailure(res.Stage, res.Err)
+			suite.Failures++
+			skip = true
+		}
+
+		switch name {
+		case reportCaseConvert:
+			tc.SystemOut = strings.Join(warningLines(res.Warnings), "\n")
+		case reportCaseStore:
+			if res.Path != "" {
+				tc.SystemOut = "Stored at " + res.Path
+			}
+		case reportCaseExport:
+			for _, path := range res.Exports {
+				tc.SystemOut += "Exported to " + path + "\n"
+			}
+			tc.SystemOut = strings.TrimSuffix(tc.SystemOut, "\n")
+		}
+		suite.Cases = append(suite.Cases, tc)
+		suite.Tests++
+	}
+	return suite
+}
+
+// getMandatoryTestCases returns a test case per mandatory test of the result. If the tests were not run, the test
+// cases of RunMandatoryTests are skipped.
+func getMandatoryTestCases(res BulkResult, className string, skip bool, failed string) (cases []junitTestCase) {
+	results := res.MandatoryTests
+	if results == nil {
+		for _, test := range mandatoryTests {
+			results = append(results, MandatoryTestResult{Name: test.name})
+		}
+	}
+
+	for _, result := range results {
+		tc := junitTestCase{Name: result.Name, ClassName: className}
+		switch {
+		case skip:
+			tc.Skipped = &junitSkipped{Message: fmt.Sprintf("%s failed", failed)}
+		case res.MandatoryTests == nil:
+			tc.Skipped = &junitSkipped{Message: "not run"}
+		case len(result.Violations) > 0:
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("advisory fails the mandatory test (%d violations)", len(result.Violations)),
+				Type:    string(StageValidate),
+				Text:    strings.Join(result.Violations, "\n"),
+			}
+		}
+		cases = append(cases, tc)
+	}
+	return cases
+}
+
+// reportCase returns the test case of the failed stage of the result or an empty string if it succeeded.
+func reportCase(res BulkResult) string {
+	var (
+		schemaErr    *SchemaError
+		mandatoryErr *MandatoryTestError
+	)
+	switch res.Stage {
+	case "":
+		return ""
+	case StageValidate:
+		if errors.As(res.Err, &schemaErr) {
+			return reportCaseSchema
+		}
+		if errors.As(res.Err, &mandatoryErr) {
+			return reportCaseMandatory
+		}
+		return reportCaseModel
+	default:
+		return string(res.Stage)
+	}
+}
+
+// getJUnitFailure returns the failure of a stage. The violations of a schema error are listed line by line.
+func getJUnitFailure(stage Stage, err error) *junitFailure {
+	var (
+		schemaErr *SchemaError
+	)
+	f := &junitFailure{Type: string(stage)}
+	if err == nil {
+		return f
+	}
+	f.Message, f.Text = err.Error(), err.Error()
+	if errors.As(err, &schemaErr) {
+		f.Message = fmt.Sprintf("advisory violates the CSAF schema (%d violations)", len(schemaErr.Violations))
+		f.Text = strings.Join(schemaErr.Violations, "\n")
+	}
+	return f
+}
+
+// warningLines returns the individual warnings of a lenient conversion, which are joined errors.
+func warningLines(warnings error) (lines []string) {
+	if warnings == nil {
+		return nil
+	}
+	if joined, ok := warnings.(interface{ Unwrap() []error }); ok {
+		for _, w := range joined.Unwrap() {
+			lines = append(lines, warningLines(w)...)
+		}
+		return lines
+	}
+	return []string{"Warning: " + warnings.Error()}
+}
@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteJUnitReport(t *testing.T) {
+	var (
+		buf    bytes.Buffer
+		report junitTestSuites
+		n      = len(mandatoryTests)
+	)
+	results := []BulkResult{
+		{
+			Ref:            "https://github.com/golang-jwt/jwt/security/advisories/GHSA-mh63-6h87-95cp",
+			GHSA:           &repository.Advisory{GhsaID: "GHSA-mh63-6h87-95cp"},
+			Path:           "csaf/white/2025/ghsa-mh63-6h87-95cp.json",
+			Exports:        []string{"csaf/white/2025/ghsa-mh63-6h87-95cp.osv.json"},
+			Warnings:       errors.Join(errors.New("invalid CWE ID 'CWE-X'"), errors.New("vulnerability 1 has no package name")),
+			Unvalidated:    ErrNoCSAF21Schema,
+			MandatoryTests: []MandatoryTestResult{{Name: "6.1.1 Missing Definition of Product ID"}},
+		},
+		{
+			Ref:   "https://github.com/example/repo/security/advisories/GHSA-xxxx-xxxx-xxxx",
+			GHSA:  &repository.Advisory{GhsaID: "GHSA-xxxx-xxxx-xxxx"},
+			Stage: StageValidate,
+			Err:   &SchemaError{Violations: []string{"/document/title: missing", "/document/lang: invalid"}},
+		},
+		{Ref: "unknown", Stage: StageFetch, Err: ErrNotFound},
+		{
+			Ref:   "https://github.com/example/repo/security/advisories/GHSA-yyyy-yyyy-yyyy",
+			GHSA:  &repository.Advisory{GhsaID: "GHSA-yyyy-yyyy-yyyy"},
+			Stage: StageValidate,
+			Err: &MandatoryTestError{Failed: []MandatoryTestResult{{Name: "6.1.6 Contradicting Product Status",
+				Violations: []string{"/vulnerabilities/0/product_status/fixed/0: product CSAFPID-0001 is affected and fixed"}}}},
+			MandatoryTests: []MandatoryTestResult{
+				{Name: "6.1.1 Missing Definition of Product ID"},
+				{Name: "6.1.6 Contradicting Product Status",
+					Violations: []string{"/vulnerabilities/0/product_status/fixed/0: product CSAFPID-0001 is affected and fixed"}},
+			},
+		},
+	}
+	if !assert.NoError(t, WriteJUnitReport(&buf, results)) {
+		return
+	}
+	if !assert.NoError(t, xml.Unmarshal(buf.Bytes(), &report)) {
+		return
+	}
+	assert.Equal(t, 27+2*n, report.Tests)
+	assert.Equal(t, 3, report.Failures)
+	assert.Equal(t, 10+2*n, report.Skipped)
+	if !assert.Len(t, report.Suites, 4) {
+		return
+	}
+
+	ok := report.Suites[0]
+	assert.Equal(t, "GHSA-mh63-6h87-95cp", ok.Name)
+	assert.Equal(t, 0, ok.Failures)
+	assert.Equal(t, "Warning: invalid CWE ID 'CWE-X'\nWarning: vulnerability 1 has no package name",
+		testCase(t, ok, reportCaseConvert).SystemOut)
+	if schema := testCase(t, ok, reportCaseSchema); assert.NotNil(t, schema.Skipped) {
+		assert.Equal(t, ErrNoCSAF21Schema.Error(), schema.Skipped.Message)
+	}
+	mandatory := testCase(t, ok, "6.1.1 Missing Definition of Product ID")
+	assert.Nil(t, mandatory.Failure)
+	assert.Nil(t, mandatory.Skipped)
+	assert.Equal(t, "Stored at csaf/white/2025/ghsa-mh63-6h87-95cp.json", testCase(t, ok, reportCaseStore).SystemOut)
+	assert.Equal(t, "Exported to csaf/white/2025/ghsa-mh63-6h87-95cp.osv.json",
+		testCase(t, ok, reportCaseExport).SystemOut)
+
+	invalid := report.Suites[1]
+	assert.Nil(t, testCase(t, invalid, reportCaseModel).Failure)
+	if failure := testCase(t, invalid, reportCaseSchema).Failure; assert.NotNil(t, failure) {
+		assert.Equal(t, "advisory violates the CSAF schema (2 violations)", failure.Message)
+		assert.Equal(t, "/document/title: missing\n/document/lang: invalid", failure.Text)
+	}
+	// The mandatory tests were not run, so all of them are skipped
+	for _, test := range mandatoryTests {
+		assert.NotNil(t, testCase(t, invalid, test.name).Skipped, test.name)
+	}
+	assert.NotNil(t, testCase(t, invalid, reportCaseStore).Skipped)
+
+	unknown := report.Suites[2]
+	assert.Equal(t, "unknown", unknown.Name)
+	if failure := testCase(t, unknown, reportCaseFetch).Failure; assert.NotNil(t, failure) {
+		assert.Equal(t, "fetch", failure.Type)
+	}
+	assert.Equal(t, 5+n, unknown.Skipped)
+
+	failed := report.Suites[3]
+	assert.Equal(t, 1, failed.Failures)
+	assert.Nil(t, testCase(t, failed, "6.1.1 Missing Definition of Product ID").Failure)
+	if failure := testCase(t, failed, "6.1.6 Contradicting Product Status").Failure; assert.NotNil(t, failure) {
+		assert.Equal(t, "advisory fails the mandatory test (1 violations)", failure.Message)
+		assert.Equal(t, "/vulnerabilities/0/product_status/fixed/0: product CSAFPID-0001 is affected and fixed",
+			failure.Text)
+	}
+	if skipped := testCase(t, failed, reportCaseStore).Skipped; assert.NotNil(t, skipped) {
+		assert.Equal(t, "mandatory tests failed", skipped.Message)
+	}
+}
+
+// testCase returns the test case of the suite with the given name.
+func testCase(t *testing.T, suite junitTestSuite, name string) junitTestCase {
+	t.Helper()
+	for _, tc := range suite.Cases {
+		if tc.Name == name {
+			return tc
+		}
+	}
+	t.Fatalf("test suite %s has no test case %s", suite.Name, name)
+	return junitTestCase{}
+}
+
+func TestReportCase(t *testing.T) {
+	tests := []struct {
+		name string
+		res  BulkResult
+		want string
+	}{
+		{name: "Success", want: ""},
+		{name: "Model", res: BulkResult{Stage: StageValidate, Err: errors.New("missing title")}, want: reportCaseModel},
+		{name: "Schema", res: BulkResult{Stage: StageValidate, Err: &SchemaError{}}, want: reportCaseSchema},
+		{name: "Mandatory", res: BulkResult{Stage: StageValidate, Err: &MandatoryTestError{}}, want: reportCaseMandatory},
+		{name: "Store", res: BulkResult{Stage: StageStore, Err: errors.New("disk full")}, want: reportCaseStore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, reportCase(tt.res))
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// signatureFileSuffix is appended to the path of a signed file for its detached signature (see CSAF 2.0 section
+// 7.1.20), e.g. ghsa-mh63-6h87-95cp.json.asc.
+const signatureFileSuffix = ".asc"
+
+// LoadSigningKey reads the ASCII-armored OpenPGP private key at path, e.g. exported with gpg --armor
+// --export-secret-keys, and decrypts it with the passphrase if it is encrypted. The first key of the file is used.
+func LoadSigningKey(path string, passphrase string) (key *openpgp.Entity, err error) {
+	var (
+		b    []byte
+		keys openpgp.EntityList
+	)
+	b, err = os.ReadFile(path)
+	if err != nil {
+		err = fmt.Errorf("could not read signing key: %v", err)
+		return nil, err
+	}
+
+	keys, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(b))
+	if err != nil {
+		err = fmt.Errorf("could not parse signing key: %v", err)
+		return nil, err
+	}
+	key = keys[0]
+	if key.PrivateKey == nil {
+		return nil, errors.New("signing key is no private key")
+	}
+	if key.PrivateKey.Encrypted {
+		if passphrase == "" {
+			return nil, errors.New("signing key is encrypted, but no passphrase is given")
+		}
+		if err = key.DecryptPrivateKeys([]byte(passphrase)); err != nil {
+			err = fmt.Errorf("could not decrypt signing key: %v", err)
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// writeSigned writes b to path like writeFile and, if the store has a signing key, its detached ASCII-armored
+// signature to path.asc.
+func (s *Store) writeSigned(path string, b []byte) (err error) {
+	var (
+		signature bytes.Buffer
+	)
+	if err = writeFile(path, b); err != nil || s.SigningKey == nil {
+		return err
+	}
+
+	err = openpgp.ArmoredDetachSign(&signature, s.SigningKey, bytes.NewReader(b), nil)
+	if err != nil {
+		err = fmt.Errorf("could not sign %s: %v", path, err)
+		return err
+	}
+	return writeFile(path+signatureFileSuffix, append(signature.Bytes(), '\n'))
+}
@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSigningKey generates an OpenPGP key, encrypted with the passphrase unless it is empty, and writes it armored to
+// a temporary file. Returns the path of the file and the public key.
+func writeSigningKey(t *testing.T, passphrase string) (path string, public openpgp.EntityList) {
+	t.Helper()
+
+	key, err := openpgp.NewEntity("Example PSIRT", "", "psirt@example.com", nil)
+	require.NoError(t, err)
+	var b bytes.Buffer
+	w, err := armor.Encode(&b, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	// Self-signatures are created before the key is encrypted
+	require.NoError(t, key.SerializePrivate(w, nil))
+	if passphrase != "" {
+		b.Reset()
+		require.NoError(t, key.EncryptPrivateKeys([]byte(passphrase), nil))
+		w, err = armor.Encode(&b, openpgp.PrivateKeyType, nil)
+		require.NoError(t, err)
+		require.NoError(t, key.SerializePrivateWithoutSigning(w, nil))
+	}
+	require.NoError(t, w.Close())
+
+	path = filepath.Join(t.TempDir(), "key.asc")
+	require.NoError(t, os.WriteFile(path, b.Bytes(), 0600))
+	return path, openpgp.EntityList{key}
+}
+
+func TestLoadSigningKey(t *testing.T) {
+	plain, _ := writeSigningKey(t, "")
+	encrypted, _ := writeSigningKey(t, "secret")
+	invalid := filepath.Join(t.TempDir(), "invalid.asc")
+	require.NoError(t, os.WriteFile(invalid, []byte("no key"), 0600))
+
+	tests := []struct {
+		name       string
+		path       string
+		passphrase string
+		wantErr    string
+	}{
+		{name: "Unencrypted key", path: plain},
+		{name: "Encrypted key", path: encrypted, passphrase: "secret"},
+		{name: "Missing passphrase", path: encrypted, wantErr: "signing key is encrypted, but no passphrase is given"},
+		{name: "Wrong passphrase", path: encrypted, passphrase: "wrong", wantErr: "could not decrypt signing key"},
+		{name: "No key", path: invalid, wantErr: "could not parse signing key"},
+		{name: "Missing file", path: filepath.Join(t.TempDir(), "missing.asc"), wantErr: "could not read signing key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := LoadSigningKey(tt.path, tt.passphrase)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.False(t, key.PrivateKey.Encrypted)
+			}
+		})
+	}
+}
+
+func TestStore_Signed(t *testing.T) {
+	var (
+		ghsa repository.Advisory
+	)
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &ghsa)
+	adv, err := ToCSAF(&ghsa, WithProfile(csaf.CategoryVEX))
+	require.NoError(t, err)
+
+	path, public := writeSigningKey(t, "secret")
+	s := NewStore(t.TempDir())
+	s.SigningKey, err = LoadSigningKey(path, "secret")
+	require.NoError(t, err)
+
+	csafPath, err := s.Save(adv)
+	require.NoError(t, err)
+	// The vulnerable version ranges are dropped with a warning, the document is written anyway
+	vexPath, _ := s.SaveOpenVEX(&ghsa, adv, csafPath)
+	require.NotEmpty(t, vexPath)
+	osvPath, err := s.SaveOSV(&ghsa, adv, csafPath)
+	require.NoError(t, err)
+
+	// The CSAF document and the OpenVEX export share the signing key of the store
+	for _, path := range []string{csafPath, vexPath} {
+		signed, err := os.ReadFile(path)
+		require.NoError(t, err)
+		signature, err := os.ReadFile(path + ".asc")
+		if !assert.NoError(t, err) {
+			continue
+		}
+		_, err = openpgp.CheckArmoredDetachedSignature(public, bytes.NewReader(signed), bytes.NewReader(signature), nil)
+		assert.NoError(t, err, path)
+
+		// Modified documents fail the check
+		signed = append(signed, ' ')
+		_, err = openpgp.CheckArmoredDetachedSignature(public, bytes.NewReader(signed), bytes.NewReader(signature), nil)
+		assert.Error(t, err, path)
+	}
+	_, err = os.Stat(osvPath + ".asc")
+	assert.True(t, os.IsNotExist(err))
+
+	// Without a key, nothing is signed
+	s = NewStore(t.TempDir())
+	csafPath, err = s.Save(adv)
+	require.NoError(t, err)
+	_, err = os.Stat(csafPath + ".asc")
+	assert.True(t, os.IsNotExist(err))
+}
@@ -2,13 +2,165 @@ package internal
 
 import (
 	"errors"
-	"log/slog"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/csaf-poc/ghsa/models/csaf"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
 )
 
-// TODO(lebogg): Implement
-func StoreCSAF(_ *csaf.Advisory) error {
-	slog.Info("Not implemented yet")
-	return errors.New("not implemented yet")
+// DefaultStoreDir is the directory StoreCSAF writes advisories to.
+const DefaultStoreDir = "csaf"
+
+// Store writes CSAF advisories into a directory following the layout of a CSAF provider
+// (see CSAF 2.0 section 7.1.11): DIR/TLP_LABEL/YEAR/FILENAME. The year is taken from the initial release date and the
+// file name is derived from the tracking ID (see CSAF 2.0 section 5.1).
+type Store struct {
+	Dir string
+	// Force allows storing draft advisories (e.g. converted from GHSAs that are not published yet) with a public TLP
+	// label, which is refused by default to not disclose unpublished vulnerabilities.
+	Force bool
+	// SigningKey signs the stored CSAF documents and the OpenVEX exports with a detached signature next to them, e.g.
+	// DIR/white/2025/ghsa-mh63-6h87-95cp.json.asc (see LoadSigningKey). Documents are not signed if it is nil.
+	SigningKey *openpgp.Entity
+}
+
+// NewStore creates a Store writing to dir.
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+// StoreCSAF writes the advisory into DefaultStoreDir.
+func StoreCSAF(adv *csaf.Advisory) error {
+	_, err := NewStore(DefaultStoreDir).Save(adv)
+	return err
+}
+
+// Save writes the advisory into the store and returns the path of the written file. The file is written to a temporary
+// file first and renamed afterward, so readers never see partially written advisories. If the store has a signing key,
+// the detached signature is written next to it.
+func (s *Store) Save(adv *csaf.Advisory) (path string, err error) {
+	var (
+		b []byte
+	)
+
+	path, err = s.Path(adv)
+	if err != nil {
+		return "", err
+	}
+	if !s.Force && isDraft(adv) && isPublicTLP(tlpDirectory(adv)) {
+		err = fmt.Errorf("refusing to store draft advisory as TLP:%s", strings.ToUpper(tlpDirectory(adv)))
+		return "", err
+	}
+
+	b, err = csaf.MarshalIndent(adv, "", "  ")
+	if err != nil {
+		err = fmt.Errorf("could not marshal advisory: %v", err)
+		return "", err
+	}
+
+	err = s.writeSigned(path, append(b, '\n'))
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// writeFile writes b to a temporary file in the directory of path first and renames it afterward, so readers never
+// see partially written files. Missing directories are created.
+func writeFile(path string, b []byte) (err error) {
+	var (
+		f *os.File
+	)
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		err = fmt.Errorf("could not create directory: %v", err)
+		return
+	}
+
+	f, err = os.CreateTemp(filepath.Dir(path), ".tmp-*.json")
+	if err != nil {
+		err = fmt.Errorf("could not create temporary file: %v", err)
+		return
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+	if err != nil {
+		err = fmt.Errorf("could not write file: %v", err)
+		return
+	}
+
+	err = os.Rename(f.Name(), path)
+	if err != nil {
+		err = fmt.Errorf("could not move file into place: %v", err)
+		return
+	}
+	return nil
+}
+
+// Path returns the path the advisory is stored at.
+func (s *Store) Path(adv *csaf.Advisory) (path string, err error) {
+	if adv == nil || adv.Document == nil || adv.Document.Tracking == nil || adv.Document.Tracking.ID == nil {
+		return "", errors.New("advisory has no tracking ID")
+	}
+	if adv.Document.Tracking.InitialReleaseDate == nil || len(*adv.Document.Tracking.InitialReleaseDate) < 4 {
+		return "", errors.New("advisory has no initial release date")
+	}
+
+	path = filepath.Join(
+		s.Dir,
+		tlpDirectory(adv),
+		(*adv.Document.Tracking.InitialReleaseDate)[:4],
+		csafFilename(string(*adv.Document.Tracking.ID)),
+	)
+	return path, nil
+}
+
+// tlpDirectory returns the lower-cased TLP label of the advisory, e.g. "white" or, for the TLP 2.0 labels of CSAF 2.1,
+// "clear" and "amber+strict". Advisories without TLP label are treated as TLP:WHITE, or TLP:CLEAR for CSAF 2.1.
+func tlpDirectory(adv *csaf.Advisory) string {
+	if adv.CSAF21 != nil {
+		if adv.CSAF21.Document != nil && adv.CSAF21.Document.TLPLabel != "" {
+			return strings.ToLower(adv.CSAF21.Document.TLPLabel)
+		}
+		return strings.ToLower(TLPLabelClear)
+	}
+	d := adv.Document.Distribution
+	if d == nil || d.TLP == nil || d.TLP.DocumentTLPLabel == nil {
+		return "white"
+	}
+	return strings.ToLower(string(*d.TLP.DocumentTLPLabel))
+}
+
+// csafFilename builds the file name from the tracking ID: lower-cased, with every character other than
+// '+', '-', a-z and 0-9 replaced by '_'.
+func csafFilename(id string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '+' || r == '-' || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(id))
+	return name + ".json"
+}
+
+// isDraft reports whether the advisory has the tracking status draft.
+func isDraft(adv *csaf.Advisory) bool {
+	t := adv.Document.Tracking
+	return t.Status != nil && *t.Status == gocsaf.CSAFTrackingStatusDraft
+}
+
+// isPublicTLP reports whether documents of the TLP directory may be disclosed without restriction.
+func isPublicTLP(tlpDir string) bool {
+	return tlpDir == "white" || tlpDir == "clear"
 }
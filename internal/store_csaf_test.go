@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/csaf-poc/ghsa/internal/utils"
+	"github.com/csaf-poc/ghsa/models/csaf"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore_Save(t *testing.T) {
+	tests := []struct {
+		name     string
+		adv      *csaf.Advisory
+		force    bool
+		wantPath string
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			name: "Happy path",
+			adv: &csaf.Advisory{Advisory: gocsaf.Advisory{Document: &csaf.Document{
+				Distribution: &gocsaf.DocumentDistribution{TLP: &gocsaf.TLP{DocumentTLPLabel: utils.Ref(gocsaf.TLPLabel(gocsaf.TLPLabelAmber))}},
+				Tracking: &gocsaf.Tracking{
+					ID:                 utils.Ref(gocsaf.TrackingID("GHSA-mh63-6h87-95cp")),
+					InitialReleaseDate: utils.Ref("2025-03-21T20:51:37Z"),
+				},
+			}}},
+			wantPath: "amber/2025/ghsa-mh63-6h87-95cp.json",
+			wantErr:  assert.NoError,
+		},
+		{
+			name: "Default TLP and special characters",
+			adv: &csaf.Advisory{Advisory: gocsaf.Advisory{Document: &csaf.Document{
+				Tracking: &gocsaf.Tracking{
+					ID:                 utils.Ref(gocsaf.TrackingID("Example Company/2025:001")),
+					InitialReleaseDate: utils.Ref("2025-03-21T20:51:37Z"),
+				},
+			}}},
+			wantPath: "white/2025/example_company_2025_001.json",
+			wantErr:  assert.NoError,
+		},
+		{
+			name: "TLP 2.0 label of CSAF 2.1",
+			adv: &csaf.Advisory{
+				Advisory: gocsaf.Advisory{Document: &csaf.Document{
+					Distribution: &gocsaf.DocumentDistribution{TLP: &gocsaf.TLP{DocumentTLPLabel: utils.Ref(gocsaf.TLPLabel(gocsaf.TLPLabelRed))}},
+					Tracking: &gocsaf.Tracking{
+						ID:                 utils.Ref(gocsaf.TrackingID("GHSA-mh63-6h87-95cp")),
+						InitialReleaseDate: utils.Ref("2025-03-21T20:51:37Z"),
+					},
+				}},
+				CSAF21: &csaf.CSAF21{Document: &csaf.Document21{TLPLabel: TLPLabelAmberStrict}},
+			},
+			wantPath: "amber+strict/2025/ghsa-mh63-6h87-95cp.json",
+			wantErr:  assert.NoError,
+		},
+		{
+			name: "Default TLP of CSAF 2.1",
+			adv: &csaf.Advisory{
+				Advisory: gocsaf.Advisory{Document: &csaf.Document{
+					Tracking: &gocsaf.Tracking{
+						ID:                 utils.Ref(gocsaf.TrackingID("GHSA-mh63-6h87-95cp")),
+						InitialReleaseDate: utils.Ref("2025-03-21T20:51:37Z"),
+					},
+				}},
+				CSAF21: &csaf.CSAF21{},
+			},
+			wantPath: "clear/2025/ghsa-mh63-6h87-95cp.json",
+			wantErr:  assert.NoError,
+		},
+		{
+			name: "Forced draft with public TLP",
+			adv: &csaf.Advisory{Advisory: gocsaf.Advisory{Document: &csaf.Document{
+				Tracking: &gocsaf.Tracking{
+					ID:                 utils.Ref(gocsaf.TrackingID("GHSA-mh63-6h87-95cp")),
+					InitialReleaseDate: utils.Ref("2025-03-21T20:51:37Z"),
+					Status:             utils.Ref(gocsaf.CSAFTrackingStatusDraft),
+				},
+			}}},
+			force:    true,
+			wantPath: "white/2025/ghsa-mh63-6h87-95cp.json",
+			wantErr:  assert.NoError,
+		},
+		{
+			name: "Err: Draft with public TLP",
+			adv: &csaf.Advisory{Advisory: gocsaf.Advisory{Document: &csaf.Document{
+				Tracking: &gocsaf.Tracking{
+					ID:                 utils.Ref(gocsaf.TrackingID("GHSA-mh63-6h87-95cp")),
+					InitialReleaseDate: utils.Ref("2025-03-21T20:51:37Z"),
+					Status:             utils.Ref(gocsaf.CSAFTrackingStatusDraft),
+				},
+			}}},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorContains(t, err, "refusing to store draft advisory as TLP:WHITE")
+			},
+		},
+		{
+			name: "Err: No tracking ID",
+			adv:  &csaf.Advisory{Advisory: gocsaf.Advisory{Document: &csaf.Document{}}},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorContains(t, err, "no tracking ID")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store := NewStore(dir)
+			store.Force = tt.force
+			got, err := store.Save(tt.adv)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, filepath.Join(dir, filepath.FromSlash(tt.wantPath)), got)
+			assert.FileExists(t, got)
+
+			// No temporary files must be left behind
+			entries, err := os.ReadDir(filepath.Dir(got))
+			assert.NoError(t, err)
+			assert.Len(t, entries, 1)
+		})
+	}
+}
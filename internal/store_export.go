@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+)
+
+// Suffixes replacing the ".json" suffix of the CSAF document for the exports stored next to it.
+const (
+	osvFileSuffix       = ".osv.json"
+	cycloneDXFileSuffix = ".cdx.json"
+	openVEXFileSuffix   = ".openvex.json"
+	htmlFileSuffix      = ".html"
+	markdownFileSuffix  = ".md"
+)
+
+// SaveOSV converts the GHSA into an OSV record (see ToOSV) and writes it next to the CSAF document of adv, e.g.
+// DIR/white/2025/ghsa-mh63-6h87-95cp.osv.json, so it inherits the TLP directory of the document. Returns the path of
+// the written file. It can be used as Exporter of a bulk run.
+func (s *Store) SaveOSV(ghsa *repository.Advisory, adv *csaf.Advisory, csafPath string) (path string, err error) {
+	vuln, err := ToOSV(ghsa)
+	if err != nil {
+		err = fmt.Errorf("could not convert advisory to OSV: %v", err)
+		return "", err
+	}
+	return s.saveExport(vuln, adv, csafPath, osvFileSuffix)
+}
+
+// SaveCycloneDX converts the GHSA into a CycloneDX BOM of the given version (see ToCycloneDX) and writes it next to the
+// CSAF document of adv like SaveOSV, e.g. DIR/white/2025/ghsa-mh63-6h87-95cp.cdx.json.
+func (s *Store) SaveCycloneDX(ghsa *repository.Advisory, adv *csaf.Advisory, csafPath string, specVersion string) (
+	path string, err error,
+) {
+	bom, err := ToCycloneDX(ghsa, specVersion)
+	if err != nil {
+		err = fmt.Errorf("could not convert advisory to CycloneDX: %v", err)
+		return "", err
+	}
+	return s.saveExport(bom, adv, csafPath, cycloneDXFileSuffix)
+}
+
+// SaveOpenVEX converts the product statuses of the advisory into an OpenVEX document (see ToOpenVEX) and writes it next
+// to the CSAF document like SaveOSV, e.g. DIR/white/2025/ghsa-mh63-6h87-95cp.openvex.json. The GHSA is not used.
+// If the store has a signing key, the document is signed like the CSAF documents (see Store.SigningKey). If statements
+// were dropped, the document is written and the path is returned together with the error listing them.
+func (s *Store) SaveOpenVEX(_ *repository.Advisory, adv *csaf.Advisory, csafPath string) (path string, err error) {
+	var (
+		b []byte
+	)
+	doc, err := ToOpenVEX(adv)
+	if doc == nil {
+		err = fmt.Errorf("could not convert advisory to OpenVEX: %v", err)
+		return "", err
+	}
+	warnings := err
+
+	if b, err = marshalExport(doc); err != nil {
+		return "", err
+	}
+	if path, err = s.exportPath(adv, csafPath, openVEXFileSuffix); err != nil {
+		return "", err
+	}
+	if err = s.writeSigned(path, b); err != nil {
+		return "", err
+	}
+	return path, warnings
+}
+
+// SaveHTML renders the advisory as HTML page (see ToHTML) and writes it next to the CSAF document like SaveOSV, e.g.
+// DIR/white/2025/ghsa-mh63-6h87-95cp.html. The GHSA is not used.
+func (s *Store) SaveHTML(_ *repository.Advisory, adv *csaf.Advisory, csafPath string) (path string, err error) {
+	page, err := ToHTML(adv)
+	if err != nil {
+		return "", err
+	}
+	return s.saveRendered(page, adv, csafPath, htmlFileSuffix)
+}
+
+// SaveMarkdown renders the advisory as Markdown (see ToMarkdown) and writes it next to the CSAF document like SaveOSV,
+// e.g. DIR/white/2025/ghsa-mh63-6h87-95cp.md. The GHSA is not used.
+func (s *Store) SaveMarkdown(_ *repository.Advisory, adv *csaf.Advisory, csafPath string) (path string, err error) {
+	doc, err := ToMarkdown(adv)
+	if err != nil {
+		return "", err
+	}
+	return s.saveRendered(doc, adv, csafPath, markdownFileSuffix)
+}
+
+// saveExport writes v as JSON next to the CSAF document of adv, which is stored at csafPath. The path is derived from
+// the advisory if csafPath is empty.
+func (s *Store) saveExport(v any, adv *csaf.Advisory, csafPath string, suffix string) (path string, err error) {
+	b, err := marshalExport(v)
+	if err != nil {
+		return "", err
+	}
+	return s.saveRendered(b, adv, csafPath, suffix)
+}
+
+// saveRendered writes b next to the CSAF document of adv like saveExport.
+func (s *Store) saveRendered(b []byte, adv *csaf.Advisory, csafPath string, suffix string) (path string, err error) {
+	if path, err = s.exportPath(adv, csafPath, suffix); err != nil {
+		return "", err
+	}
+	if err = writeFile(path, b); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// marshalExport marshals v as indented JSON with a trailing newline.
+func marshalExport(v any) ([]byte, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal export: %v", err)
+	}
+	return append(b, '\n'), nil
+}
+
+// exportPath returns the path of an export with the suffix next to the CSAF document of adv, which is stored at
+// csafPath. The path is derived from the advisory if csafPath is empty.
+func (s *Store) exportPath(adv *csaf.Advisory, csafPath string, suffix string) (path string, err error) {
+	if csafPath == "" {
+		if csafPath, err = s.Path(adv); err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSuffix(csafPath, ".json") + suffix, nil
+}
@@ -0,0 +1,384 @@
+package internal
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/csaf-poc/ghsa/models/csaf"
+	gocsaf "github.com/gocsaf/csaf/v3/csaf"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// csaf21SchemaURL is the ID of the CSAF 2.1 JSON schema of OASIS.
+const csaf21SchemaURL = "https://docs.oasis-open.org/csaf/csaf/v2.1/schema/csaf.json"
+
+// ErrNoCSAF21Schema is returned by ValidateCSAF for CSAF 2.1 advisories if the CSAF 2.1 JSON schema of OASIS is not
+// available, see LoadSchemas. The advisory is not validated in this case.
+var ErrNoCSAF21Schema = errors.New("the CSAF 2.1 JSON schema of OASIS is not available")
+
+// schemas holds the JSON schemas used to validate CSAF 2.1 advisories, see schema/README.md.
+//
+//go:embed schema/*.json
+var schemas embed.FS
+
+// schemaRegistry holds the JSON schemas by their ID ($id) and the compiled CSAF 2.1 schema.
+var schemaRegistry = struct {
+	sync.Mutex
+	docs     map[string][]byte
+	compiled *jsonschema.Schema
+}{docs: make(map[string][]byte)}
+
+func init() {
+	files, err := fs.Glob(schemas, "schema/*.json")
+	if err != nil {
+		panic(err)
+	}
+	for _, file := range files {
+		b, err := schemas.ReadFile(file)
+		if err == nil {
+			err = addSchema(b)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("invalid embedded schema '%s': %v", file, err))
+		}
+	}
+}
+
+// LoadSchemas adds the JSON schemas in dir to the schemas used by ValidateCSAF. Every file with suffix .json is
+// registered by its ID ($id), so the official CSAF 2.1 schema of OASIS and the schemas it references, e.g. the CVSS v4.0
+// schema of FIRST, are resolved by their real IDs regardless of their file names.
+func LoadSchemas(dir string) (err error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		err = fmt.Errorf("no JSON schemas in '%s'", dir)
+		return
+	}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err = addSchema(b); err != nil {
+			err = fmt.Errorf("invalid schema '%s': %v", file, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// addSchema registers the JSON schema by its ID and discards the compiled CSAF 2.1 schema, which may reference it.
+// Older drafts name the ID "id".
+func addSchema(b []byte) (err error) {
+	var (
+		schema struct {
+			ID      string `json:"$id"`
+			DraftID string `json:"id"`
+		}
+	)
+	if err = json.Unmarshal(b, &schema); err != nil {
+		return err
+	}
+	if schema.ID == "" {
+		schema.ID = schema.DraftID
+	}
+	if schema.ID == "" {
+		return errors.New("schema has no $id")
+	}
+
+	schemaRegistry.Lock()
+	defer schemaRegistry.Unlock()
+	schemaRegistry.docs[schemaKey(schema.ID)] = b
+	schemaRegistry.compiled = nil
+	return nil
+}
+
+// schemaKey returns the key of a schema ID in the registry. The query and fragment are dropped, since the CVSS schemas
+// of FIRST carry their date as query, e.g. https://www.first.org/cvss/cvss-v3.1.json?20211103, but are referenced
+// without it.
+func schemaKey(id string) string {
+	id, _, _ = strings.Cut(id, "#")
+	id, _, _ = strings.Cut(id, "?")
+	return id
+}
+
+// csaf21Schema returns the compiled CSAF 2.1 JSON schema. It is compiled once after the schemas changed.
+func csaf21Schema() (*jsonschema.Schema, error) {
+	schemaRegistry.Lock()
+	defer schemaRegistry.Unlock()
+	if schemaRegistry.compiled != nil {
+		return schemaRegistry.compiled, nil
+	}
+	if _, ok := schemaRegistry.docs[csaf21SchemaURL]; !ok {
+		return nil, ErrNoCSAF21Schema
+	}
+
+	schema, err := compileSchema(csaf21SchemaURL)
+	if err != nil {
+		return nil, err
+	}
+	schemaRegistry.compiled = schema
+	return schema, nil
+}
+
+// compileSchema compiles the registered schema with the given ID. The caller must hold the lock of the registry.
+func compileSchema(id string) (*jsonschema.Schema, error) {
+	c := jsonschema.NewCompiler()
+	c.AssertFormat = true
+	c.LoadURL = func(url string) (io.ReadCloser, error) {
+		b, ok := schemaRegistry.docs[schemaKey(url)]
+		if !ok {
+			return nil, fmt.Errorf("unknown schema '%s'", url)
+		}
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+	return c.Compile(id)
+}
+
+// registeredSchema returns the compiled schema with the given ID or nil if it is not registered.
+func registeredSchema(id string) (*jsonschema.Schema, error) {
+	schemaRegistry.Lock()
+	defer schemaRegistry.Unlock()
+	if _, ok := schemaRegistry.docs[id]; !ok {
+		return nil, nil
+	}
+	return compileSchema(id)
+}
+
+// ValidateCSAF checks the advisory against the CSAF JSON schema of its version. The advisory is serialized first, so
+// the check covers exactly what would be written to the store. CSAF 2.0 advisories are checked with the schema of
+// gocsaf, CSAF 2.1 advisories with the schema of OASIS, which must be loaded with LoadSchemas or embedded. Without it,
+// CSAF 2.1 advisories are checked as far as possible, see validateCSAF21Subset.
+// Returns a *SchemaError listing all schema violations, ErrNoCSAF21Schema if the advisory passed the checks without
+// the schema of OASIS or nil if the advisory is valid.
+func ValidateCSAF(adv *csaf.Advisory) (err error) {
+	var (
+		violations []string
+	)
+	if adv == nil {
+		err = fmt.Errorf("no advisory")
+		return
+	}
+
+	doc, err := csaf.ToDocument(adv)
+	if err != nil {
+		err = fmt.Errorf("could not marshal advisory: %v", err)
+		return
+	}
+
+	if adv.CSAF21 != nil {
+		violations, err = validateCSAF21(doc)
+	} else {
+		violations, err = gocsaf.ValidateCSAF(doc)
+	}
+	unvalidated := errors.Is(err, ErrNoCSAF21Schema)
+	if unvalidated {
+		violations, err = validateCSAF21Subset(adv)
+	}
+	if err != nil {
+		err = fmt.Errorf("could not validate advisory: %v", err)
+		return
+	}
+	if len(violations) > 0 {
+		err = &SchemaError{Violations: violations}
+		return
+	}
+	if unvalidated {
+		return ErrNoCSAF21Schema
+	}
+	return nil
+}
+
+// SchemaError is returned by ValidateCSAF if the advisory violates the CSAF JSON schema.
+type SchemaError struct {
+	// Violations lists the violations, each prefixed with the location in the document
+	Violations []string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("advisory violates the CSAF schema: %s", strings.Join(e.Violations, "; "))
+}
+
+// validateCSAF21 validates the document against the CSAF 2.1 JSON schema and returns the violations in the format of
+// gocsaf.ValidateCSAF.
+func validateCSAF21(doc any) (violations []string, err error) {
+	var (
+		valErr *jsonschema.ValidationError
+	)
+	schema, err := csaf21Schema()
+	if err != nil {
+		return nil, err
+	}
+	err = schema.Validate(doc)
+	if err == nil || !errors.As(err, &valErr) {
+		return nil, err
+	}
+
+	for _, e := range valErr.BasicOutput().Errors {
+		if e.Error == "" {
+			continue
+		}
+		loc := e.InstanceLocation
+		if loc == "" {
+			loc = e.AbsoluteKeywordLocation
+		}
+		violations = append(violations, loc+": "+e.Error)
+	}
+	return violations, nil
+}
+
+// validateCSAF21Subset validates a CSAF 2.1 advisory without the CSAF 2.1 JSON schema of OASIS. The properties CSAF 2.1
+// shares with CSAF 2.0 are checked against the CSAF 2.0 schema of gocsaf, the properties of CSAF 2.1 with checkCSAF21.
+func validateCSAF21Subset(adv *csaf.Advisory) (violations []string, err error) {
+	b, err := csaf.Marshal(adv)
+	if err != nil {
+		return nil, err
+	}
+	// Unmarshal strips the properties of CSAF 2.1, so the rest is a CSAF 2.0 document
+	parsed, err := csaf.Unmarshal(b)
+	if err != nil {
+		return nil, err
+	}
+	ext := parsed.CSAF21
+	parsed.CSAF21 = nil
+	doc, err := csaf.ToDocument(parsed)
+	if err != nil {
+		return nil, err
+	}
+	if violations, err = gocsaf.ValidateCSAF(doc); err != nil {
+		return nil, err
+	}
+	more, err := checkCSAF21(ext)
+	return append(violations, more...), err
+}
+
+// Patterns of the properties of CSAF 2.1 as given by the CSAF 2.1 standard.
+var (
+	cweIDPattern      = regexp.MustCompile(`^CWE-[1-9]\d{0,5}$`)
+	cweVersionPattern = regexp.MustCompile(`^[1-9]\d*\.([0-9]|([1-9]\d+))(\.\d+)?$`)
+	epssPattern       = regexp.MustCompile(`^(([0]\.([0-9])+)|([1]\.[0]+))$`)
+	tlpLabels21       = []string{TLPLabelClear, "GREEN", "AMBER", "AMBER+STRICT", "RED"}
+)
+
+// checkCSAF21 checks the properties of CSAF 2.1 against the CSAF 2.1 standard: the TLP label, the CWEs, the disclosure
+// date and the metrics. CVSS scores are checked against the schemas of FIRST if registered, CVSS v4 scores by their
+// vector string and score otherwise. Returns the violations in the format of gocsaf.ValidateCSAF.
+func checkCSAF21(ext *csaf.CSAF21) (violations []string, err error) {
+	if ext == nil || ext.Document == nil || !slices.Contains(tlpLabels21, ext.Document.TLPLabel) {
+		violations = append(violations, "/document/distribution/tlp/label: value must be one of "+
+			strings.Join(tlpLabels21, ", "))
+	}
+	if ext == nil {
+		return violations, nil
+	}
+
+	for i, v := range ext.Vulnerabilities {
+		loc := fmt.Sprintf("/vulnerabilities/%d", i)
+		for j, cwe := range v.CWEs {
+			if !cweIDPattern.MatchString(cwe.ID) || cwe.Name == "" || !cweVersionPattern.MatchString(cwe.Version) {
+				violations = append(violations, fmt.Sprintf("%s/cwes/%d: id, name and version of the CWE are "+
+					"required", loc, j))
+			}
+		}
+		if v.DisclosureDate != "" {
+			if _, err := time.Parse(time.RFC3339, v.DisclosureDate); err != nil {
+				violations = append(violations, loc+"/disclosure_date: invalid date-time")
+			}
+		}
+		for j, m := range v.Metrics {
+			more, err := checkMetric21(fmt.Sprintf("%s/metrics/%d", loc, j), m)
+			if err != nil {
+				return nil, err
+			}
+			violations = append(violations, more...)
+		}
+	}
+	return violations, nil
+}
+
+// checkMetric21 checks a metric of a CSAF 2.1 vulnerability at location loc, see checkCSAF21.
+func checkMetric21(loc string, m *csaf.Metric21) (violations []string, err error) {
+	if len(m.Products) == 0 {
+		violations = append(violations, loc+"/products: at least one product is required")
+	}
+	if m.Content == nil || (m.Content.CVSS3 == nil && m.Content.CVSS4 == nil && m.Content.EPSS == nil) {
+		violations = append(violations, loc+"/content: at least one score is required")
+		return violations, nil
+	}
+
+	if c := m.Content.CVSS3; c != nil {
+		id := "https://www.first.org/cvss/cvss-v3.1.json"
+		if c.Version != nil && *c.Version == gocsaf.CVSSVersion30 {
+			id = "https://www.first.org/cvss/cvss-v3.0.json"
+		}
+		more, err := validateAgainst(id, loc+"/content/cvss_v3", c)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, more...)
+	}
+
+	if c := m.Content.CVSS4; c != nil {
+		more, err := validateAgainst("https://www.first.org/cvss/cvss-v4.0.json", loc+"/content/cvss_v4", c)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, more...)
+		if c.Version != "4.0" || !cvss4Pattern.MatchString(c.VectorString) || c.BaseScore < 0 || c.BaseScore > 10 ||
+			c.BaseSeverity != string(cvss3Severity(c.BaseScore)) {
+			violations = append(violations, loc+"/content/cvss_v4: invalid CVSS v4.0 score")
+		}
+	}
+
+	if e := m.Content.EPSS; e != nil {
+		_, err := time.Parse(time.RFC3339, e.Timestamp)
+		if !epssPattern.MatchString(e.Percentile) || !epssPattern.MatchString(e.Probability) || err != nil {
+			violations = append(violations, loc+"/content/epss: invalid EPSS score")
+		}
+	}
+	return violations, nil
+}
+
+// validateAgainst validates v at location loc against the registered schema with the given ID. Returns no violations
+// if the schema is not registered.
+func validateAgainst(id string, loc string, v any) (violations []string, err error) {
+	var (
+		doc    any
+		valErr *jsonschema.ValidationError
+	)
+	schema, err := registeredSchema(id)
+	if schema == nil || err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	err = schema.Validate(doc)
+	if err == nil || !errors.As(err, &valErr) {
+		return nil, err
+	}
+	for _, e := range valErr.BasicOutput().Errors {
+		if e.Error != "" {
+			violations = append(violations, loc+e.InstanceLocation+": "+e.Error)
+		}
+	}
+	return violations, nil
+}
@@ -0,0 +1,171 @@
+package internal
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/csaf-poc/ghsa/internal/utils"
+	"github.com/csaf-poc/ghsa/models/csaf"
+	"github.com/csaf-poc/ghsa/models/ghsa/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// schemaDir holds the official CSAF 2.1 JSON schema of OASIS and the schemas it references. Without it, CSAF 2.1
+// advisories are not validated against the schema in tests.
+var schemaDir = flag.String("schemas", os.Getenv("CSAF_SCHEMAS"), "directory with the CSAF 2.1 JSON schema of OASIS and the schemas it references (defaults to $CSAF_SCHEMAS)")
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if *schemaDir != "" {
+		if err := LoadSchemas(*schemaDir); err != nil {
+			fmt.Fprintf(os.Stderr, "could not load schemas: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+// assertValidCSAF asserts that the advisory is valid according to the CSAF JSON schema of its version. CSAF 2.1
+// advisories are checked without the CSAF 2.1 schema if it is not available (see schemaDir and ValidateCSAF), so
+// violations found that way fail as well.
+func assertValidCSAF(t *testing.T, adv *csaf.Advisory) bool {
+	t.Helper()
+	err := ValidateCSAF(adv)
+	if errors.Is(err, ErrNoCSAF21Schema) {
+		t.Log("CSAF 2.1 schema of OASIS not available, run with -schemas DIR")
+		return true
+	}
+	return assert.NoError(t, err)
+}
+
+// withSchemas replaces the registered schemas with the embedded ones for the duration of the test.
+func withSchemas(t *testing.T) {
+	schemaRegistry.Lock()
+	docs := maps.Clone(schemaRegistry.docs)
+	delete(schemaRegistry.docs, csaf21SchemaURL)
+	schemaRegistry.compiled = nil
+	schemaRegistry.Unlock()
+
+	t.Cleanup(func() {
+		schemaRegistry.Lock()
+		schemaRegistry.docs, schemaRegistry.compiled = docs, nil
+		schemaRegistry.Unlock()
+	})
+}
+
+func TestValidateCSAF_NoCSAF21Schema(t *testing.T) {
+	var (
+		ghsa repository.Advisory
+	)
+	withSchemas(t)
+
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &ghsa)
+	adv, err := ToCSAF(&ghsa, WithCSAFVersion(csaf.Version21))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.ErrorIs(t, ValidateCSAF(adv), ErrNoCSAF21Schema)
+
+	// The properties shared with CSAF 2.0 and the properties of CSAF 2.1 are still checked
+	tests := []struct {
+		name   string
+		modify func(adv *csaf.Advisory)
+		want   string
+	}{
+		{
+			name:   "Missing title",
+			modify: func(adv *csaf.Advisory) { adv.Document.Title = nil },
+			want:   "/document/title: expected string, but got null",
+		},
+		{
+			name:   "TLP 1.0 label",
+			modify: func(adv *csaf.Advisory) { adv.CSAF21.Document.TLPLabel = "WHITE" },
+			want:   "/document/distribution/tlp/label: value must be one of CLEAR",
+		},
+		{
+			name:   "CWE without version",
+			modify: func(adv *csaf.Advisory) { adv.CSAF21.Vulnerabilities[0].CWEs[0].Version = "" },
+			want:   "/vulnerabilities/0/cwes/0: id, name and version of the CWE are required",
+		},
+		{
+			name: "CVSS v3 score",
+			modify: func(adv *csaf.Advisory) {
+				adv.CSAF21.Vulnerabilities[0].Metrics[0].Content.CVSS3.BaseScore = utils.Ref(11.0)
+			},
+			want: "/vulnerabilities/0/metrics/0/content/cvss_v3/baseScore: must be <= 10",
+		},
+		{
+			name: "CVSS v4 severity",
+			modify: func(adv *csaf.Advisory) {
+				adv.CSAF21.Vulnerabilities[0].Metrics[0].Content.CVSS4 = &csaf.CVSS4{
+					Version:      "4.0",
+					VectorString: "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N",
+					BaseScore:    9.3,
+					BaseSeverity: "NONE",
+				}
+			},
+			want: "/vulnerabilities/0/metrics/0/content/cvss_v4: invalid CVSS v4.0 score",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schemaErr *SchemaError
+			adv, err := ToCSAF(&ghsa, WithCSAFVersion(csaf.Version21))
+			if !assert.NoError(t, err) {
+				return
+			}
+			tt.modify(adv)
+			if assert.ErrorAs(t, ValidateCSAF(adv), &schemaErr) {
+				assert.Contains(t, schemaErr.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSchemas(t *testing.T) {
+	var (
+		ghsa      repository.Advisory
+		schemaErr *SchemaError
+		dir       = t.TempDir()
+	)
+	withSchemas(t)
+
+	// The schemas are resolved by their IDs, not by their file names; the CVSS v3.1 schema is referenced without the
+	// date in its ID
+	files := map[string]string{
+		"a.json": `{"$schema": "http://json-schema.org/draft-07/schema#", "$id": "` + csaf21SchemaURL + `",
+			"type": "object", "required": ["document", "vulnerabilities"],
+			"properties": {"vulnerabilities": {"type": "array", "items": {"properties": {"metrics": {"type": "array",
+			"items": {"properties": {"content": {"properties": {
+				"cvss_v3": {"$ref": "https://www.first.org/cvss/cvss-v3.1.json"},
+				"cvss_v4": {"$ref": "https://www.first.org/cvss/cvss-v4.0.json"}}}}}}}}}}}`,
+		"b.json": `{"$schema": "http://json-schema.org/draft-07/schema#", "$id": "https://www.first.org/cvss/cvss-v4.0.json",
+			"type": "object", "required": ["version", "vectorString"]}`,
+	}
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	if !assert.NoError(t, LoadSchemas(dir)) {
+		return
+	}
+
+	readJSON(t, "../examples/repository_GHSA/GHSA-mh63-6h87-95cp.json", &ghsa)
+	adv, err := ToCSAF(&ghsa, WithCSAFVersion(csaf.Version21))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, ValidateCSAF(adv))
+
+	adv.Vulnerabilities = nil
+	err = ValidateCSAF(adv)
+	if assert.ErrorAs(t, err, &schemaErr) {
+		assert.Contains(t, schemaErr.Error(), "missing properties: 'vulnerabilities'")
+	}
+
+	assert.Error(t, LoadSchemas(t.TempDir()))
+}
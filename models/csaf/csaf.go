@@ -2,7 +2,93 @@ package csaf
 
 import "github.com/gocsaf/csaf/v3/csaf"
 
-type Advisory = csaf.Advisory
+// Document categories of the CSAF profiles.
+const (
+	CategoryBase             = "csaf_base"
+	CategorySecurityAdvisory = "csaf_security_advisory"
+	CategoryVEX              = "csaf_vex"
+	CategoryWithdrawn        = "csaf_withdrawn"
+)
+
+// Versions of the CSAF standard an advisory can be serialized as.
+const (
+	Version20 = "2.0"
+	Version21 = "2.1"
+)
+
+// Advisory is a CSAF advisory. It extends the CSAF 2.0 model of gocsaf with the properties of CSAF 2.1.
+type Advisory struct {
+	csaf.Advisory
+	// CSAF21 holds the properties of CSAF 2.1. If set, the advisory is serialized as CSAF 2.1 document.
+	CSAF21 *CSAF21 `json:"-"`
+}
+
 type Document = csaf.Document
 type ProductTree = csaf.ProductTree
 type Vulnerabilities = csaf.Vulnerabilities
+
+// CSAF21 holds the properties of CSAF 2.1 that are not part of CSAF 2.0 or replace properties of CSAF 2.0.
+type CSAF21 struct {
+	// Document extends the document of the advisory
+	Document *Document21
+	// Vulnerabilities extends the vulnerabilities of the advisory with the same index
+	Vulnerabilities []*Vulnerability21
+}
+
+// Document21 holds the properties of a CSAF 2.1 document that differ from CSAF 2.0.
+type Document21 struct {
+	// Category replaces the document category if set, e.g. with CategoryWithdrawn
+	Category string
+	// TLPLabel replaces the TLP label with a label of TLP 2.0: CLEAR, GREEN, AMBER, AMBER+STRICT or RED
+	TLPLabel string
+}
+
+// Vulnerability21 holds the properties of a CSAF 2.1 vulnerability. The CSAF 2.0 properties cwe, scores and
+// release_date are replaced by cwes, metrics and disclosure_date.
+type Vulnerability21 struct {
+	CWEs           []*CWE21    `json:"cwes,omitempty"`
+	DisclosureDate string      `json:"disclosure_date,omitempty"`
+	Metrics        []*Metric21 `json:"metrics,omitempty"`
+}
+
+// CWE21 is a weakness of a CSAF 2.1 vulnerability.
+type CWE21 struct {
+	// ID of the weakness, e.g. CWE-79
+	ID string `json:"id"`
+	// Name of the weakness as given by the CWE catalogue
+	Name string `json:"name"`
+	// Version of the CWE catalogue the name was taken from
+	Version string `json:"version"`
+}
+
+// Metric21 is a metric of a CSAF 2.1 vulnerability for the given products. It replaces the scores of CSAF 2.0.
+type Metric21 struct {
+	Content  *MetricContent21 `json:"content"`
+	Products []string         `json:"products"`
+	// Source is the URL of the source that determined the metric
+	Source string `json:"source,omitempty"`
+}
+
+// MetricContent21 holds the scores of a metric.
+type MetricContent21 struct {
+	CVSS3 *csaf.CVSS3 `json:"cvss_v3,omitempty"`
+	CVSS4 *CVSS4      `json:"cvss_v4,omitempty"`
+	EPSS  *EPSS       `json:"epss,omitempty"`
+}
+
+// CVSS4 is a CVSS v4.0 score. Only the base metrics are supported.
+type CVSS4 struct {
+	Version      string  `json:"version"`
+	VectorString string  `json:"vectorString"`
+	BaseScore    float64 `json:"baseScore"`
+	BaseSeverity string  `json:"baseSeverity"`
+}
+
+// EPSS is the score of the Exploit Prediction Scoring System at the given time.
+type EPSS struct {
+	// Percentile is the rank of the probability among all scored vulnerabilities, e.g. "0.04494"
+	Percentile string `json:"percentile"`
+	// Probability of exploitation activity in the next 30 days, e.g. "0.00022"
+	Probability string `json:"probability"`
+	Timestamp   string `json:"timestamp"`
+}
@@ -0,0 +1,240 @@
+package csaf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// The gocsaf models serialize acknowledgments as "acknowledgements", which is not allowed by the CSAF schema.
+// Marshal and Unmarshal translate between both property names.
+const (
+	schemaAcknowledgments = "acknowledgments"
+	gocsafAcknowledgments = "acknowledgements"
+)
+
+// schema21 is the URL of the CSAF 2.1 JSON schema, which CSAF 2.1 documents reference.
+const schema21 = "https://docs.oasis-open.org/csaf/csaf/v2.1/schema/csaf.json"
+
+// Marshal returns the JSON encoding of the advisory as defined by the CSAF JSON schema.
+// Object properties are sorted alphabetically and HTML characters are not escaped.
+func Marshal(adv *Advisory) ([]byte, error) {
+	return MarshalIndent(adv, "", "")
+}
+
+// MarshalIndent is like Marshal but applies indent to format the output.
+func MarshalIndent(adv *Advisory, prefix string, indent string) ([]byte, error) {
+	var (
+		buf bytes.Buffer
+	)
+	doc, err := ToDocument(adv)
+	if err != nil {
+		return nil, err
+	}
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(prefix, indent)
+	if err = enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	// Encode terminates the value with a newline, Marshal does not
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// Unmarshal parses a CSAF 2.0 or CSAF 2.1 document as defined by the CSAF JSON schema into an advisory. The properties
+// of CSAF 2.1 documents that differ from CSAF 2.0 are parsed into CSAF21.
+func Unmarshal(data []byte) (adv *Advisory, err error) {
+	var (
+		doc  any
+		ext  *CSAF21
+		root map[string]any
+	)
+	if err = unmarshalNumbers(data, &doc); err != nil {
+		return nil, err
+	}
+	renameKey(doc, schemaAcknowledgments, gocsafAcknowledgments)
+	if root, _ = doc.(map[string]any); root == nil {
+		return nil, errors.New("advisory is not an object")
+	}
+	if document, _ := root["document"].(map[string]any); document != nil && document["csaf_version"] == Version21 {
+		if ext, err = fromCSAF21(root); err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	adv = &Advisory{CSAF21: ext}
+	if err = json.Unmarshal(b, adv); err != nil {
+		return nil, err
+	}
+	return adv, nil
+}
+
+// ToDocument returns the advisory as generic JSON document (maps, slices and values) as defined by the CSAF JSON
+// schema, e.g. for schema validation.
+func ToDocument(adv *Advisory) (doc any, err error) {
+	b, err := json.Marshal(adv)
+	if err != nil {
+		return nil, err
+	}
+	if err = unmarshalNumbers(b, &doc); err != nil {
+		return nil, err
+	}
+	renameKey(doc, gocsafAcknowledgments, schemaAcknowledgments)
+	if adv.CSAF21 != nil {
+		err = toCSAF21(doc, adv.CSAF21)
+	}
+	return doc, err
+}
+
+// toCSAF21 turns the generic CSAF 2.0 document into a CSAF 2.1 document with the properties of ext.
+func toCSAF21(doc any, ext *CSAF21) error {
+	root, _ := doc.(map[string]any)
+	if root == nil {
+		return errors.New("advisory is not an object")
+	}
+	root["$schema"] = schema21
+	if document, ok := root["document"].(map[string]any); ok {
+		document["csaf_version"] = Version21
+		if ext.Document != nil && ext.Document.Category != "" {
+			document["category"] = ext.Document.Category
+		}
+		if ext.Document != nil && ext.Document.TLPLabel != "" {
+			distribution, _ := document["distribution"].(map[string]any)
+			if distribution == nil {
+				distribution = make(map[string]any)
+				document["distribution"] = distribution
+			}
+			tlp, _ := distribution["tlp"].(map[string]any)
+			if tlp == nil {
+				tlp = make(map[string]any)
+				distribution["tlp"] = tlp
+			}
+			tlp["label"] = ext.Document.TLPLabel
+		}
+	}
+
+	vulns, _ := root["vulnerabilities"].([]any)
+	for i, v := range vulns {
+		vuln, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		// CSAF 2.1 replaces these properties, see Vulnerability21
+		delete(vuln, "cwe")
+		delete(vuln, "scores")
+		delete(vuln, "release_date")
+		if i >= len(ext.Vulnerabilities) || ext.Vulnerabilities[i] == nil {
+			continue
+		}
+		if err := merge(vuln, ext.Vulnerabilities[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fromCSAF21 removes the properties of the CSAF 2.1 document root that differ from CSAF 2.0 and returns them. It
+// reverts toCSAF21, so the document parses as CSAF 2.0 document.
+func fromCSAF21(root map[string]any) (ext *CSAF21, err error) {
+	ext = &CSAF21{Document: &Document21{}}
+	delete(root, "$schema")
+	if document, ok := root["document"].(map[string]any); ok {
+		document["csaf_version"] = Version20
+		if category, _ := document["category"].(string); category == CategoryWithdrawn {
+			ext.Document.Category = category
+		}
+		distribution, _ := document["distribution"].(map[string]any)
+		if tlp, ok := distribution["tlp"].(map[string]any); ok {
+			ext.Document.TLPLabel, _ = tlp["label"].(string)
+			if label, ok := tlpLabels20[ext.Document.TLPLabel]; ok {
+				tlp["label"] = label
+			}
+		}
+	}
+
+	vulns, _ := root["vulnerabilities"].([]any)
+	for _, v := range vulns {
+		vuln, _ := v.(map[string]any)
+		v21 := &Vulnerability21{}
+		if err = extract(vuln, v21, "cwes", "disclosure_date", "metrics"); err != nil {
+			return nil, err
+		}
+		ext.Vulnerabilities = append(ext.Vulnerabilities, v21)
+	}
+	return ext, nil
+}
+
+// tlpLabels20 maps the TLP 2.0 labels of CSAF 2.1 to the closest TLP label of CSAF 2.0.
+var tlpLabels20 = map[string]string{
+	"CLEAR":        "WHITE",
+	"GREEN":        "GREEN",
+	"AMBER":        "AMBER",
+	"AMBER+STRICT": "AMBER",
+	"RED":          "RED",
+}
+
+// extract removes the properties keys from obj and unmarshals them into v.
+func extract(obj map[string]any, v any, keys ...string) error {
+	var (
+		props = make(map[string]any)
+	)
+	for _, key := range keys {
+		if value, ok := obj[key]; ok {
+			props[key] = value
+			delete(obj, key)
+		}
+	}
+	b, err := json.Marshal(props)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// merge adds the properties of the JSON encoding of v to obj.
+func merge(obj map[string]any, v any) error {
+	var (
+		props map[string]any
+	)
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err = unmarshalNumbers(b, &props); err != nil {
+		return err
+	}
+	for key, value := range props {
+		obj[key] = value
+	}
+	return nil
+}
+
+// unmarshalNumbers unmarshals data into v and keeps numbers as json.Number, so they are not altered.
+func unmarshalNumbers(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// renameKey renames the object property from to the property to in the whole document.
+func renameKey(doc any, from string, to string) {
+	switch v := doc.(type) {
+	case map[string]any:
+		if value, ok := v[from]; ok {
+			delete(v, from)
+			v[to] = value
+		}
+		for _, value := range v {
+			renameKey(value, from, to)
+		}
+	case []any:
+		for _, value := range v {
+			renameKey(value, from, to)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package cyclonedx
+
+// BOMFormat is the format identifier of CycloneDX documents.
+const BOMFormat = "CycloneDX"
+
+// Versions of the CycloneDX specification a BOM can be written as.
+const (
+	SpecVersion15 = "1.5"
+	SpecVersion16 = "1.6"
+)
+
+// BOM represents a CycloneDX bill of materials (see https://cyclonedx.org/docs/1.6/json/). It covers the fields needed
+// to describe vulnerabilities of components, i.e. a VEX or VDR document.
+type BOM struct {
+	BOMFormat       string          `json:"bomFormat"`   // required
+	SpecVersion     string          `json:"specVersion"` // required
+	Version         int             `json:"version,omitempty"`
+	Metadata        *Metadata       `json:"metadata,omitempty"`
+	Components      []Component     `json:"components,omitempty"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities,omitempty"`
+}
+
+// Metadata describes the BOM itself.
+type Metadata struct {
+	Timestamp string `json:"timestamp,omitempty"`
+	Tools     *Tools `json:"tools,omitempty"`
+}
+
+// Tools lists the tools that created the BOM.
+type Tools struct {
+	Components []Component `json:"components,omitempty"`
+}
+
+// ComponentType is the type of a Component.
+type ComponentType string
+
+const (
+	ComponentTypeApplication ComponentType = "application"
+	ComponentTypeLibrary     ComponentType = "library"
+)
+
+// Component represents a software component, e.g. a package.
+type Component struct {
+	Type    ComponentType `json:"type"` // required
+	BOMRef  string        `json:"bom-ref,omitempty"`
+	Group   string        `json:"group,omitempty"`
+	Name    string        `json:"name"` // required
+	Version string        `json:"version,omitempty"`
+	PURL    string        `json:"purl,omitempty"`
+}
+
+// Vulnerability represents a vulnerability and the components it affects.
+type Vulnerability struct {
+	BOMRef         string                   `json:"bom-ref,omitempty"`
+	ID             string                   `json:"id,omitempty"`
+	Source         *Source                  `json:"source,omitempty"`
+	References     []VulnerabilityReference `json:"references,omitempty"`
+	Ratings        []Rating                 `json:"ratings,omitempty"`
+	CWEs           []int                    `json:"cwes,omitempty"`
+	Description    string                   `json:"description,omitempty"`
+	Detail         string                   `json:"detail,omitempty"`
+	Recommendation string                   `json:"recommendation,omitempty"`
+	Advisories     []Advisory               `json:"advisories,omitempty"`
+	Created        string                   `json:"created,omitempty"`
+	Published      string                   `json:"published,omitempty"`
+	Updated        string                   `json:"updated,omitempty"`
+	Rejected       string                   `json:"rejected,omitempty"`
+	Credits        *Credits                 `json:"credits,omitempty"`
+	Analysis       *Analysis                `json:"analysis,omitempty"`
+	Affects        []Affect                 `json:"affects,omitempty"`
+}
+
+// Source is the source of vulnerability information, e.g. NVD.
+type Source struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+// VulnerabilityReference is the same vulnerability in another source, e.g. its CVE.
+type VulnerabilityReference struct {
+	ID     string  `json:"id"`     // required
+	Source *Source `json:"source"` // required
+}
+
+// RatingMethod is the scoring system of a Rating.
+type RatingMethod string
+
+const (
+	RatingMethodCVSSv3  RatingMethod = "CVSSv3"
+	RatingMethodCVSSv31 RatingMethod = "CVSSv31"
+	RatingMethodCVSSv4  RatingMethod = "CVSSv4"
+	RatingMethodOther   RatingMethod = "other"
+)
+
+// Severity is the qualitative severity of a Rating.
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityHigh     Severity = "high"
+	SeverityMedium   Severity = "medium"
+	SeverityLow      Severity = "low"
+	SeverityNone     Severity = "none"
+	SeverityUnknown  Severity = "unknown"
+)
+
+// Rating is the severity of a vulnerability according to a scoring system.
+type Rating struct {
+	Source   *Source      `json:"source,omitempty"`
+	Score    *float64     `json:"score,omitempty"`
+	Severity Severity     `json:"severity,omitempty"`
+	Method   RatingMethod `json:"method,omitempty"`
+	Vector   string       `json:"vector,omitempty"`
+}
+
+// Advisory is a URL with further information about the vulnerability.
+type Advisory struct {
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url"` // required
+}
+
+// Credits lists the individuals credited for the vulnerability.
+type Credits struct {
+	Individuals []OrganizationalContact `json:"individuals,omitempty"`
+}
+
+// OrganizationalContact is a person.
+type OrganizationalContact struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// AnalysisState is the exploitability of a vulnerability in the affected components.
+type AnalysisState string
+
+const (
+	AnalysisStateResolved      AnalysisState = "resolved"
+	AnalysisStateExploitable   AnalysisState = "exploitable"
+	AnalysisStateInTriage      AnalysisState = "in_triage"
+	AnalysisStateFalsePositive AnalysisState = "false_positive"
+	AnalysisStateNotAffected   AnalysisState = "not_affected"
+)
+
+// Analysis describes the impact of a vulnerability on the affected components.
+type Analysis struct {
+	State    AnalysisState `json:"state,omitempty"`
+	Response []string      `json:"response,omitempty"`
+	Detail   string        `json:"detail,omitempty"`
+}
+
+// Affect references a component affected by the vulnerability.
+type Affect struct {
+	// Ref is the bom-ref of the component
+	Ref      string          `json:"ref"` // required
+	Versions []AffectVersion `json:"versions,omitempty"`
+}
+
+// AffectStatus is the status of versions of an affected component.
+type AffectStatus string
+
+const (
+	AffectStatusAffected   AffectStatus = "affected"
+	AffectStatusUnaffected AffectStatus = "unaffected"
+	AffectStatusUnknown    AffectStatus = "unknown"
+)
+
+// AffectVersion is a version or a version range (as vers range) of an affected component. Exactly one of Version and
+// Range is set.
+type AffectVersion struct {
+	Version string       `json:"version,omitempty"`
+	Range   string       `json:"range,omitempty"`
+	Status  AffectStatus `json:"status,omitempty"`
+}
@@ -18,7 +18,7 @@ type Advisory struct {
 	EPSP                  EPSP                `json:"epss"`
 	GithubReviewedAt      time.Time           `json:"github_reviewed_at"`
 	HTMLURL               string              `json:"html_url"`
-	Identifiers           Identifier          `json:"identifiers"`
+	Identifiers           []Identifier        `json:"identifiers"`
 	NVDPublishedAt        time.Time           `json:"nvd_published_at"`
 	PublishedAt           time.Time           `json:"published_at"`
 	References            []string            `json:"references"`
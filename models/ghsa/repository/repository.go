@@ -27,7 +27,7 @@ type Advisory struct {
 	CWEs            []CWE            `json:"cwes"`             // required
 	CWEIds          []string         `json:"cwe_ids"`          // required
 	Credits         []Credit         `json:"credits"`          // required
-	CreditsDetailed []CreditDetailed `json:"credits detailed"` // required
+	CreditsDetailed []CreditDetailed `json:"credits_detailed"` // required
 	// Required. A list of users that collaborate on the advisory
 	CollaboratingUsers []User `json:"collaborating_users"`
 	// Required. A list of teams that collaborate on the advisory
@@ -216,3 +216,25 @@ type Repository struct {
 	TreesURL         string  `json:"trees_url"`         // required
 	HooksURL         string  `json:"hooks_url"`         // required
 }
+
+// CreateRequest represents the request body to create a repository security advisory.
+// See https://docs.github.com/en/rest/security-advisories/repository-advisories?apiVersion=2022-11-28#create-a-repository-security-advisory
+type CreateRequest struct {
+	Summary         string                `json:"summary"`     // required
+	Description     string                `json:"description"` // required
+	CVEID           string                `json:"cve_id,omitempty"`
+	Vulnerabilities []CreateVulnerability `json:"vulnerabilities"` // required
+	CWEIDs          []string              `json:"cwe_ids,omitempty"`
+	Credits         []Credit              `json:"credits,omitempty"`
+	// Severity must not be set together with CVSSVectorString
+	Severity         string `json:"severity,omitempty"`
+	CVSSVectorString string `json:"cvss_vector_string,omitempty"`
+}
+
+// CreateVulnerability represents a product affected by the advisory of a CreateRequest.
+type CreateVulnerability struct {
+	Package                Package  `json:"package"` // required
+	VulnerableVersionRange string   `json:"vulnerable_version_range,omitempty"`
+	PatchedVersions        string   `json:"patched_versions,omitempty"`
+	VulnerableFunctions    []string `json:"vulnerable_functions,omitempty"`
+}
@@ -0,0 +1,71 @@
+package openvex
+
+// Context is the JSON-LD context of OpenVEX documents of the specification version 0.2.0.
+const Context = "https://openvex.dev/ns/v0.2.0"
+
+// Document represents an OpenVEX document (see https://github.com/openvex/spec/blob/main/OPENVEX-SPEC.md).
+type Document struct {
+	Context     string      `json:"@context"` // required
+	ID          string      `json:"@id"`      // required
+	Author      string      `json:"author"`   // required
+	Role        string      `json:"role,omitempty"`
+	Timestamp   string      `json:"timestamp"` // required
+	LastUpdated string      `json:"last_updated,omitempty"`
+	Version     int         `json:"version"` // required
+	Tooling     string      `json:"tooling,omitempty"`
+	Statements  []Statement `json:"statements"` // required
+}
+
+// Status is the impact of a vulnerability on the products of a Statement.
+type Status string
+
+const (
+	StatusNotAffected        Status = "not_affected"
+	StatusAffected           Status = "affected"
+	StatusFixed              Status = "fixed"
+	StatusUnderInvestigation Status = "under_investigation"
+)
+
+// Justification explains why products are not affected. The justifications match the flags of CSAF.
+type Justification string
+
+const (
+	JustificationComponentNotPresent                         Justification = "component_not_present"
+	JustificationVulnerableCodeNotPresent                    Justification = "vulnerable_code_not_present"
+	JustificationVulnerableCodeNotInExecutePath              Justification = "vulnerable_code_not_in_execute_path"
+	JustificationVulnerableCodeCannotBeControlledByAdversary Justification = "vulnerable_code_cannot_be_controlled_by_adversary"
+	JustificationInlineMitigationsAlreadyExist               Justification = "inline_mitigations_already_exist"
+)
+
+// Statement asserts the status of a vulnerability for products.
+type Statement struct {
+	Vulnerability Vulnerability `json:"vulnerability"` // required
+	Timestamp     string        `json:"timestamp,omitempty"`
+	Products      []Product     `json:"products,omitempty"`
+	Status        Status        `json:"status"` // required
+	StatusNotes   string        `json:"status_notes,omitempty"`
+	// Justification or ImpactStatement is required for StatusNotAffected
+	Justification   Justification `json:"justification,omitempty"`
+	ImpactStatement string        `json:"impact_statement,omitempty"`
+	// ActionStatement is required for StatusAffected
+	ActionStatement string `json:"action_statement,omitempty"`
+}
+
+// Vulnerability identifies the vulnerability of a Statement.
+type Vulnerability struct {
+	ID          string   `json:"@id,omitempty"`
+	Name        string   `json:"name"` // required
+	Description string   `json:"description,omitempty"`
+	Aliases     []string `json:"aliases,omitempty"`
+}
+
+// Product is a software product, identified by its package URL.
+type Product struct {
+	ID          string       `json:"@id"` // required
+	Identifiers *Identifiers `json:"identifiers,omitempty"`
+}
+
+// Identifiers are the software identifiers of a Product.
+type Identifiers struct {
+	PURL string `json:"purl,omitempty"`
+}
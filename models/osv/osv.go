@@ -0,0 +1,120 @@
+package osv
+
+// Vulnerability represents a single record in the Open Source Vulnerability (OSV) format.
+// It was created with the OSV schema 1.6 (https://ossf.github.io/osv-schema/) and covers the fields that GitHub
+// populates in the github/advisory-database repository.
+type Vulnerability struct {
+	SchemaVersion    string           `json:"schema_version,omitempty"`
+	ID               string           `json:"id"`       // required
+	Modified         string           `json:"modified"` // required
+	Published        string           `json:"published,omitempty"`
+	Withdrawn        string           `json:"withdrawn,omitempty"`
+	Aliases          []string         `json:"aliases,omitempty"`
+	Related          []string         `json:"related,omitempty"`
+	Summary          string           `json:"summary,omitempty"`
+	Details          string           `json:"details,omitempty"`
+	Severity         []Severity       `json:"severity,omitempty"`
+	Affected         []Affected       `json:"affected,omitempty"`
+	References       []Reference      `json:"references,omitempty"`
+	Credits          []Credit         `json:"credits,omitempty"`
+	DatabaseSpecific DatabaseSpecific `json:"database_specific,omitempty"`
+}
+
+// SeverityType is the scoring system of a Severity.
+type SeverityType string
+
+const (
+	SeverityTypeCVSSv2 SeverityType = "CVSS_V2"
+	SeverityTypeCVSSv3 SeverityType = "CVSS_V3"
+	SeverityTypeCVSSv4 SeverityType = "CVSS_V4"
+)
+
+// Severity represents the severity of a vulnerability as a vector string of the given scoring system.
+type Severity struct {
+	Type  SeverityType `json:"type"`  // required
+	Score string       `json:"score"` // required
+}
+
+// Affected represents a package affected by the vulnerability.
+type Affected struct {
+	Package           Package                  `json:"package"`
+	Severity          []Severity               `json:"severity,omitempty"`
+	Ranges            []Range                  `json:"ranges,omitempty"`
+	Versions          []string                 `json:"versions,omitempty"`
+	EcosystemSpecific map[string]any           `json:"ecosystem_specific,omitempty"`
+	DatabaseSpecific  AffectedDatabaseSpecific `json:"database_specific,omitempty"`
+}
+
+// Package identifies an affected package within its ecosystem.
+type Package struct {
+	Ecosystem string `json:"ecosystem"` // required
+	Name      string `json:"name"`      // required
+	PURL      string `json:"purl,omitempty"`
+}
+
+// RangeType is the versioning scheme of a Range.
+type RangeType string
+
+const (
+	RangeTypeSemver    RangeType = "SEMVER"
+	RangeTypeEcosystem RangeType = "ECOSYSTEM"
+	RangeTypeGit       RangeType = "GIT"
+)
+
+// Range represents the affected versions of a package as a list of events.
+type Range struct {
+	Type   RangeType `json:"type"` // required
+	Repo   string    `json:"repo,omitempty"`
+	Events []Event   `json:"events"` // required
+}
+
+// Event represents a single version event in a Range. Exactly one of the fields is set.
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+// AffectedDatabaseSpecific contains the GitHub specific information of an affected package.
+type AffectedDatabaseSpecific struct {
+	LastKnownAffectedVersionRange string `json:"last_known_affected_version_range,omitempty"`
+}
+
+// ReferenceType is the type of a Reference.
+type ReferenceType string
+
+const (
+	ReferenceTypeAdvisory   ReferenceType = "ADVISORY"
+	ReferenceTypeArticle    ReferenceType = "ARTICLE"
+	ReferenceTypeDetection  ReferenceType = "DETECTION"
+	ReferenceTypeDiscussion ReferenceType = "DISCUSSION"
+	ReferenceTypeReport     ReferenceType = "REPORT"
+	ReferenceTypeFix        ReferenceType = "FIX"
+	ReferenceTypeIntroduced ReferenceType = "INTRODUCED"
+	ReferenceTypePackage    ReferenceType = "PACKAGE"
+	ReferenceTypeEvidence   ReferenceType = "EVIDENCE"
+	ReferenceTypeWeb        ReferenceType = "WEB"
+)
+
+// Reference represents a URL with further information about the vulnerability.
+type Reference struct {
+	Type ReferenceType `json:"type"` // required
+	URL  string        `json:"url"`  // required
+}
+
+// Credit represents a person or organization credited for the vulnerability.
+type Credit struct {
+	Name    string   `json:"name"` // required
+	Contact []string `json:"contact,omitempty"`
+	Type    string   `json:"type,omitempty"`
+}
+
+// DatabaseSpecific contains the GitHub specific information of a record.
+type DatabaseSpecific struct {
+	CWEIDs           []string `json:"cwe_ids,omitempty"`
+	Severity         string   `json:"severity,omitempty"`
+	GithubReviewed   bool     `json:"github_reviewed,omitempty"`
+	GithubReviewedAt string   `json:"github_reviewed_at,omitempty"`
+	NVDPublishedAt   string   `json:"nvd_published_at,omitempty"`
+}